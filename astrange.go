@@ -0,0 +1,124 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"sync"
+)
+
+// parsedFile is the syntax tree of a revision of an open document.
+type parsedFile struct {
+	revision int
+	fset     *token.FileSet
+	file     *ast.File
+}
+
+// parsedFiles caches the syntax tree of the latest revision of each open Go document.
+type parsedFiles struct {
+	mu    sync.Mutex
+	files map[DocumentURI]parsedFile
+}
+
+// get returns the syntax tree of doc, parsing it unless the revision is cached. Syntax errors
+// are tolerated; nil is returned only when nothing could be parsed.
+func (p *parsedFiles) get(uri DocumentURI, doc document) (*token.FileSet, *ast.File) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if f, ok := p.files[uri]; ok && f.revision == doc.Revision {
+		return f.fset, f.file
+	}
+
+	fset := token.NewFileSet()
+	file, _ := parser.ParseFile(fset, uriToPath(string(uri)), doc.Text, parser.AllErrors)
+	if p.files == nil {
+		p.files = make(map[DocumentURI]parsedFile)
+	}
+	p.files[uri] = parsedFile{revision: doc.Revision, fset: fset, file: file}
+
+	return fset, file
+}
+
+func (p *parsedFiles) forget(uri DocumentURI) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.files, uri)
+}
+
+// spansLines reports whether n is the file or a declaration over several lines, e.g. a
+// function or a var block: an issue at its start is about the line, not all of it.
+func spansLines(fset *token.FileSet, n ast.Node) bool {
+	switch n.(type) {
+	case *ast.File:
+		return true
+	case ast.Decl:
+		return fset.Position(n.Pos()).Line != fset.Position(n.End()).Line
+	}
+
+	return false
+}
+
+// nodeEnd returns the end of the node golangci-lint most likely points at with issue: the
+// largest expression starting at its position, e.g. the whole call errcheck reports rather
+// than the name of the function, or else the smallest node starting there.
+func (h *langHandler) nodeEnd(uri DocumentURI, issue *Issue) (Position, bool) {
+	if issue.Pos.Line <= 0 || issue.Pos.Column <= 0 || !strings.HasSuffix(h.paths.uriToPath(string(uri)), ".go") {
+		return Position{}, false
+	}
+	doc, ok := h.documents.get(uri)
+	if !ok {
+		return Position{}, false
+	}
+	fset, file := h.parsed.get(uri, doc)
+	if file == nil {
+		return Position{}, false
+	}
+
+	tf := fset.File(file.Pos())
+	if tf == nil || issue.Pos.Line > tf.LineCount() {
+		return Position{}, false
+	}
+	offset := tf.Offset(tf.LineStart(issue.Pos.Line)) + issue.Pos.Column - 1
+	if offset >= tf.Size() {
+		return Position{}, false
+	}
+	pos := tf.Pos(offset)
+
+	var expr, smallest ast.Node
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil || n.Pos() > pos || n.End() <= pos {
+			// Nodes not containing pos have no descendant starting at it.
+			return false
+		}
+		if n.Pos() == pos && !spansLines(fset, n) {
+			if _, ok := n.(ast.Expr); ok && expr == nil {
+				expr = n
+			}
+			smallest = n
+		}
+
+		return true
+	})
+	node := expr
+	if node == nil {
+		node = smallest
+	}
+	if node == nil || !node.End().IsValid() {
+		return Position{}, false
+	}
+
+	end := fset.Position(node.End())
+	lines := strings.Split(doc.Text, "\n")
+	if end.Line < 1 || end.Line > len(lines) {
+		return Position{}, false
+	}
+	line := strings.TrimRight(lines[end.Line-1], "\r")
+	if end.Column-1 > len(line) {
+		return Position{}, false
+	}
+
+	return Position{Line: end.Line - 1, Character: h.encoding.character(line, end.Column-1)}, true
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNodeEnd(t *testing.T) {
+	const text = `package test
+
+import "os"
+
+var (
+	a = 1
+)
+
+func f() {
+	os.Remove("x")
+}
+`
+	tests := []struct {
+		name         string
+		line, column int
+		want         Position
+		wantOK       bool
+	}{
+		{name: "package clause", line: 1, column: 1},
+		{name: "var block", line: 5, column: 1},
+		{name: "call", line: 10, column: 2, want: Position{Line: 9, Character: 15}, wantOK: true},
+		{name: "function name", line: 9, column: 6, want: Position{Line: 8, Character: 6}, wantOK: true},
+		{name: "function signature", line: 9, column: 1, want: Position{Line: 8, Character: 8}, wantOK: true},
+		{name: "past the end", line: 20, column: 1},
+	}
+
+	h := newLangHandler(&testLogger{}, false)
+	uri := DocumentURI("file:///work/test.go")
+	h.documents.open(uri, text, 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issue := &Issue{}
+			issue.Pos.Line, issue.Pos.Column = tt.line, tt.column
+			got, ok := h.nodeEnd(uri, issue)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("nodeEnd() = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// BenchmarkNodeEnd measures the end of an issue in a large file, parsed anew for every
+// revision and looked up in the cached tree otherwise.
+func BenchmarkNodeEnd(b *testing.B) {
+	var text strings.Builder
+	text.WriteString("package test\n\nimport \"os\"\n")
+	const funcs = 5000
+	for i := 0; i < funcs; i++ {
+		fmt.Fprintf(&text, "\nfunc f%d() {\n\tos.Remove(\"x\")\n}\n", i)
+	}
+	issue := &Issue{}
+	issue.Pos.Line, issue.Pos.Column = 4+4*(funcs/2)+2, 2
+
+	h := newLangHandler(&testLogger{}, false)
+	uri := DocumentURI("file:///work/large.go")
+
+	b.Run("parse", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			h.documents.open(uri, text.String(), i)
+			h.parsed.forget(uri)
+			if _, ok := h.nodeEnd(uri, issue); !ok {
+				b.Fatal("no node at the issue")
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		h.documents.open(uri, text.String(), 1)
+		for i := 0; i < b.N; i++ {
+			if _, ok := h.nodeEnd(uri, issue); !ok {
+				b.Fatal("no node at the issue")
+			}
+		}
+	})
+}
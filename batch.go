@@ -0,0 +1,207 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSaveBatchThreshold = 4
+	defaultSaveBatchWindow    = 200 // milliseconds
+)
+
+// saveBatcher detects bursts of didSave such as "Save All". Saves are forwarded one by one
+// until threshold of them arrive within window; the rest of the burst is collected and
+// handed to flush once no save arrived for window.
+type saveBatcher struct {
+	mu     sync.Mutex
+	recent []time.Time
+	batch  map[DocumentURI]struct{}
+	timer  *time.Timer
+
+	config func() (threshold int, window time.Duration)
+	single func(uri DocumentURI)
+	flush  func(uris []DocumentURI)
+}
+
+func (b *saveBatcher) add(uri DocumentURI) {
+	threshold, window := b.config()
+	if threshold <= 0 {
+		b.single(uri)
+
+		return
+	}
+
+	now := time.Now()
+
+	b.mu.Lock()
+	recent := b.recent[:0]
+	for _, t := range b.recent {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	b.recent = append(recent, now)
+
+	if b.batch == nil && len(b.recent) < threshold {
+		b.mu.Unlock()
+		b.single(uri)
+
+		return
+	}
+
+	if b.batch == nil {
+		b.batch = make(map[DocumentURI]struct{})
+	}
+	b.batch[uri] = struct{}{}
+
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.timer = time.AfterFunc(window, b.fire)
+	b.mu.Unlock()
+}
+
+func (b *saveBatcher) fire() {
+	b.mu.Lock()
+	uris := make([]DocumentURI, 0, len(b.batch))
+	for uri := range b.batch {
+		uris = append(uris, uri)
+	}
+	b.batch = nil
+	b.recent = b.recent[:0]
+	b.mu.Unlock()
+
+	if len(uris) > 0 {
+		b.flush(uris)
+	}
+}
+
+func (h *langHandler) saveBatchConfig() (int, time.Duration) {
+	opts := h.currentOptions()
+
+	return opts.SaveBatchThreshold, time.Duration(opts.SaveBatchWindow) * time.Millisecond
+}
+
+// lintBatch lints the modules of the saved files with one `./...` run each
+// and publishes the diagnostics of their packages. In single-flight mode the files are handed
+// to the scheduler instead, to be linted with the other pending requests.
+func (h *langHandler) lintBatch(uris []DocumentURI, trigger Trigger) {
+	if h.singleFlight() {
+		for _, uri := range uris {
+			h.enqueue(uri, trigger)
+		}
+
+		return
+	}
+
+	h.lintTogether(uris, false)
+}
+
+// lintTogether lints the packages of uris with one run per module, of `./...` or, with
+// packagesOnly, of just the directories of uris, and publishes their diagnostics.
+func (h *langHandler) lintTogether(uris []DocumentURI, packagesOnly bool) {
+	defer h.recoverPanic("batch lint")
+
+	for _, uri := range uris {
+		if err := h.authorizeFor(h.paths.uriToPath(string(uri))); err != nil {
+			h.notifyError(err.Error())
+
+			return
+		}
+	}
+
+	// Files of a nested folder override are linted with its command, in a run of their own.
+	type batchRun struct {
+		root, folder string
+	}
+	byRun := make(map[batchRun][]DocumentURI)
+	for _, uri := range uris {
+		if h.publishConflicts(uri) {
+			continue
+		}
+
+		path := h.paths.uriToPath(string(uri))
+		dir := filepath.Dir(path)
+		if !h.included(dir) {
+			h.publishPackage(uri, map[DocumentURI][]Diagnostic{uri: make([]Diagnostic, 0)})
+
+			continue
+		}
+
+		root := findModuleRoot(dir)
+		if root == "" {
+			root = h.root()
+		}
+		key := batchRun{root: root, folder: h.folderDir(path)}
+		byRun[key] = append(byRun[key], uri)
+	}
+
+	for key, uris := range byRun {
+		root := key.root
+		h.logger.Printf("golangci-lint-langserver: linting %d files with one run in %s", len(uris), root)
+
+		lc := h.folderLintCommand(root, "./...")
+		if key.folder != "" {
+			lc = h.lintCommandFor(key.folder, root, "./...")
+			if rel, err := filepath.Rel(root, key.folder); err == nil && rel != "." && isSubdir(root, key.folder) {
+				lc = h.lintCommandFor(key.folder, root, "./"+filepath.ToSlash(rel)+"/...")
+			}
+		}
+		if packagesOnly || h.includes() {
+			// The run mustn't leave the included packages either.
+			lc.Args = append(lc.Args[:len(lc.Args)-1], packageTargets(h.paths, root, uris)...)
+		}
+
+		result, run, err := h.runLint(lc)
+		if err != nil {
+			h.notifyLintError(err)
+
+			for uri, diagnostics := range h.isolateFailure(root, uris) {
+				h.publishPackage(uri, diagnostics)
+			}
+
+			continue
+		}
+		h.notifier.reset()
+
+		dirs := make(map[string]struct{})
+		for _, uri := range uris {
+			dir := filepath.Dir(h.paths.uriToPath(string(uri)))
+			if _, ok := dirs[dir]; ok {
+				continue
+			}
+			dirs[dir] = struct{}{}
+			h.broken.set(dir, false)
+
+			h.publishPackage(uri, h.packageDiagnostics(uri, root, result, run))
+		}
+	}
+}
+
+// packageTargets returns the distinct directories of uris, translated by paths, relative to
+// root when inside it.
+func packageTargets(paths *pathMapper, root string, uris []DocumentURI) []string {
+	var targets []string
+	seen := make(map[string]bool)
+	for _, uri := range uris {
+		dir := filepath.Dir(paths.uriToPath(string(uri)))
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		target := dir
+		if rel, err := filepath.Rel(root, dir); err == nil && rel == "." {
+			target = "."
+		} else if err == nil && isSubdir(root, dir) {
+			target = "./" + filepath.ToSlash(rel)
+		}
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	return targets
+}
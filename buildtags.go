@@ -0,0 +1,150 @@
+package main
+
+import (
+	"go/build/constraint"
+	"runtime"
+	"strings"
+)
+
+const (
+	buildTagsModeAdd  = "add"
+	buildTagsModeSkip = "skip"
+)
+
+// platformTags are the GOOS and GOARCH values, which --build-tags can't satisfy.
+var platformTags = []string{
+	"aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos", "ios", "js", "linux", "nacl",
+	"netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows", "zos", "unix",
+	"386", "amd64", "arm", "arm64", "loong64", "mips", "mipsle", "mips64", "mips64le", "ppc64", "ppc64le",
+	"riscv64", "s390x", "wasm",
+}
+
+// buildConstraint returns the build constraint of a Go file, from //go:build or else from the // +build lines.
+func buildConstraint(text string) constraint.Expr {
+	var plus constraint.Expr
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+
+		switch {
+		case constraint.IsGoBuild(line):
+			if expr, err := constraint.Parse(line); err == nil {
+				return expr
+			}
+		case constraint.IsPlusBuild(line):
+			expr, err := constraint.Parse(line)
+			if err != nil {
+				continue
+			}
+			if plus == nil {
+				plus = expr
+			} else {
+				plus = &constraint.AndExpr{X: plus, Y: expr}
+			}
+		}
+	}
+
+	return plus
+}
+
+// satisfied returns a constraint.Expr.Eval callback for the current platform with tags set.
+func satisfied(tags []string) func(string) bool {
+	return func(tag string) bool {
+		switch {
+		case tag == runtime.GOOS, tag == runtime.GOARCH, tag == "gc", tag == "cgo":
+			return true
+		case tag == "unix":
+			return runtime.GOOS != "windows" && runtime.GOOS != "plan9" && runtime.GOOS != "js" && runtime.GOOS != "wasip1"
+		case strings.HasPrefix(tag, "go1."):
+			return true
+		}
+
+		return containsFold(tags, tag)
+	}
+}
+
+// positiveTags returns the tags of expr that are not negated and could be passed with --build-tags.
+func positiveTags(expr constraint.Expr, negated bool, tags []string) []string {
+	switch e := expr.(type) {
+	case *constraint.AndExpr:
+		return positiveTags(e.Y, negated, positiveTags(e.X, negated, tags))
+	case *constraint.OrExpr:
+		return positiveTags(e.Y, negated, positiveTags(e.X, negated, tags))
+	case *constraint.NotExpr:
+		return positiveTags(e.X, !negated, tags)
+	case *constraint.TagExpr:
+		if negated || strings.HasPrefix(e.Tag, "go1.") || containsFold(platformTags, e.Tag) || containsFold(tags, e.Tag) {
+			return tags
+		}
+
+		return append(tags, e.Tag)
+	}
+
+	return tags
+}
+
+// fileBuildTags returns the tags to add to command so that the build constraint of text is satisfied.
+// ok is false when no tags satisfy it, e.g. because it is for another platform.
+func fileBuildTags(text string, command []string) (tags []string, expr constraint.Expr, ok bool) {
+	expr = buildConstraint(text)
+	if expr == nil {
+		return nil, nil, true
+	}
+
+	configured := flagValues(command, "--build-tags")
+	if expr.Eval(satisfied(configured)) {
+		return nil, expr, true
+	}
+
+	tags = positiveTags(expr, false, nil)
+	if !expr.Eval(satisfied(append(configured, tags...))) {
+		return nil, expr, false
+	}
+
+	return tags, expr, true
+}
+
+// flagValues returns the comma-separated values of every occurrence of the flag.
+func flagValues(args []string, name string) []string {
+	var values []string
+	for i := 0; i < len(args); i++ {
+		var value string
+		switch {
+		case args[i] == name && i+1 < len(args):
+			i++
+			value = args[i]
+		case strings.HasPrefix(args[i], name+"="):
+			value = strings.TrimPrefix(args[i], name+"=")
+		default:
+			continue
+		}
+
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+
+	return values
+}
+
+// withBuildTags merges tags into the --build-tags of command.
+func withBuildTags(command []string, tags []string) []string {
+	if len(tags) == 0 || len(command) == 0 {
+		return command
+	}
+
+	merged := flagValues(command[1:], "--build-tags")
+	for _, tag := range tags {
+		if !containsFold(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+
+	args := removeFlag(command[1:], "--build-tags")
+
+	return append(append([]string{command[0]}, args...), "--build-tags="+strings.Join(merged, ","))
+}
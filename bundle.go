@@ -0,0 +1,154 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+const cmdCaptureBundle = "golangci-lint.captureDiagnosticsBundle"
+
+// CaptureBundleArgs is the optional argument of the golangci-lint.captureDiagnosticsBundle command.
+type CaptureBundleArgs struct {
+	// URI is the current document, whose last output is included.
+	URI DocumentURI `json:"uri,omitempty"`
+	// FullPaths keeps file paths readable instead of hashing them.
+	FullPaths bool `json:"fullPaths,omitempty"`
+}
+
+// bundleFile is a JSON file of the bundle.
+type bundleFile struct {
+	name string
+	v    interface{}
+}
+
+// bundleOutput is the output of the last run behind the diagnostics of a document.
+type bundleOutput struct {
+	URI    string   `json:"uri"`
+	Run    *runInfo `json:"run,omitempty"`
+	Issues []Issue  `json:"issues"`
+	// Errors holds the details of the failed runs diagnosed in the document.
+	Errors []*LintErrorDetails `json:"errors,omitempty"`
+}
+
+var (
+	fileURIPattern = regexp.MustCompile(`file://(/[^\s"'<>]*)`)
+	// absPathPattern matches absolute paths that don't belong to a URL.
+	absPathPattern = regexp.MustCompile(`(^|[\s"'=(\[])(/[^\s"'()<>,\]]+)`)
+)
+
+// logLines is implemented by loggers keeping their recent lines.
+type logLines interface {
+	lines() []string
+}
+
+// executeCaptureBundle writes the configuration, the golangci-lint version, the recent log
+// lines and lint timings and the last output for the current document to a zip file under
+// the temporary directory, hashing paths unless asked not to, and returns its path.
+func (h *langHandler) executeCaptureBundle(arguments []json.RawMessage) (string, error) {
+	var args CaptureBundleArgs
+	if len(arguments) > 0 {
+		if err := json.Unmarshal(arguments[0], &args); err != nil {
+			return "", err
+		}
+	}
+	r := &recorder{keepPaths: args.FullPaths}
+
+	f, err := ioutil.TempFile("", "golangci-lint-langserver-*.zip")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+
+	config := h.configuration()
+	version := "unknown"
+	if config.Features.Detected {
+		version = config.Features.Version.String()
+	}
+
+	var log []string
+	if l, ok := h.logger.(logLines); ok {
+		for _, line := range l.lines() {
+			log = append(log, scrubPaths(r, line))
+		}
+	}
+
+	files := []bundleFile{
+		{"configuration.json", config},
+		{"lints.json", h.lints.list()},
+	}
+	if args.URI != "" {
+		output := bundleOutput{URI: string(args.URI), Run: h.issues.run(args.URI), Issues: make([]Issue, 0)}
+		for _, issue := range h.issues.get(args.URI) {
+			issue.SourceLines = nil
+			output.Issues = append(output.Issues, issue)
+		}
+		for _, d := range h.publisher.published()[args.URI] {
+			if d.Data != nil && d.Data.Error != nil {
+				output.Errors = append(output.Errors, d.Data.Error)
+			}
+		}
+		files = append(files, bundleFile{"output.json", output})
+	}
+
+	for _, file := range files {
+		b, err := json.MarshalIndent(file.v, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		// Anonymizing with paths kept only drops the environment variables, which may hold credentials.
+		b = (&recorder{keepPaths: true}).anonymizeParams(b)
+		if err := writeZipFile(zw, file.name, scrubPaths(r, string(b))); err != nil {
+			return "", err
+		}
+	}
+	if err := writeZipFile(zw, "version.txt", version+"\n"); err != nil {
+		return "", err
+	}
+	if err := writeZipFile(zw, "log.txt", strings.Join(log, "\n")+"\n"); err != nil {
+		return "", err
+	}
+
+	if err := zw.Close(); err != nil {
+		_ = os.Remove(f.Name())
+
+		return "", err
+	}
+
+	h.showMessage(MTInfo, h.catalog().Sprintf(messages.BundleCaptured, f.Name()))
+
+	return f.Name(), nil
+}
+
+func writeZipFile(zw *zip.Writer, name, content string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write([]byte(content))
+
+	return err
+}
+
+// scrubPaths hashes the file URIs and absolute paths in free text like r does for recordings.
+func scrubPaths(r *recorder, s string) string {
+	if r.keepPaths {
+		return s
+	}
+
+	s = fileURIPattern.ReplaceAllStringFunc(s, r.uri)
+
+	return absPathPattern.ReplaceAllStringFunc(s, func(match string) string {
+		m := absPathPattern.FindStringSubmatch(match)
+
+		return m[1] + r.path(m[2])
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// issueID identifies an issue across the publish/codeAction round trip.
+func issueID(issue *Issue) string {
+	//nolint:gosec
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s\x00%s\x00%d\x00%d\x00%s",
+		issue.FromLinter, issue.Pos.Filename, issue.Pos.Line, issue.Pos.Column, issue.Text)))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+type cacheEntry struct {
+	issues []Issue
+	run    *runInfo
+}
+
+// issueCache holds the issues behind the diagnostics last published per URI
+// together with the run that produced them.
+type issueCache struct {
+	mu      sync.Mutex
+	entries map[DocumentURI]cacheEntry
+}
+
+func newIssueCache() *issueCache {
+	return &issueCache{
+		entries: make(map[DocumentURI]cacheEntry),
+	}
+}
+
+func (c *issueCache) replace(uri DocumentURI, issues []Issue, run *runInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(issues) == 0 && run == nil {
+		delete(c.entries, uri)
+
+		return
+	}
+	c.entries[uri] = cacheEntry{issues: issues, run: run}
+}
+
+func (c *issueCache) get(uri DocumentURI) []Issue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries[uri].issues
+}
+
+func (c *issueCache) run(uri DocumentURI) *runInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries[uri].run
+}
+
+func (c *issueCache) find(uri DocumentURI, id string) (Issue, bool) {
+	for _, issue := range c.get(uri) {
+		issue := issue
+		if issueID(&issue) == id {
+			return issue, true
+		}
+	}
+
+	return Issue{}, false
+}
+
+// counts returns the number of cached issues per document.
+func (c *issueCache) counts() map[DocumentURI]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[DocumentURI]int, len(c.entries))
+	for uri, entry := range c.entries {
+		counts[uri] = len(entry.issues)
+	}
+
+	return counts
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+const cmdCleanCache = "golangci-lint.cleanCache"
+
+// cacheDir returns the cache directory golangci-lint uses with opts, like golangci-lint itself
+// resolves it: the cacheDir option, GOLANGCI_LINT_CACHE or the golangci-lint directory of the
+// user cache directory.
+func cacheDir(opts Options) string {
+	if opts.CacheDir != "" {
+		return opts.CacheDir
+	}
+	if dir := os.Getenv("GOLANGCI_LINT_CACHE"); dir != "" {
+		return dir
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "golangci-lint")
+}
+
+// dirSize returns the total size of the files under dir.
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size
+}
+
+// cacheSizeMaxAge is how long a measured size of the cache directory is reported before it
+// is measured again.
+const cacheSizeMaxAge = time.Minute
+
+// cacheSize measures the cache directory in the background, since walking it may take long.
+type cacheSize struct {
+	mu        sync.Mutex
+	dir       string
+	size      int64
+	measured  time.Time
+	measuring bool
+}
+
+// get returns the last size measured of dir, 0 if none, and starts measuring it again when
+// that size is older than cacheSizeMaxAge.
+func (c *cacheSize) get(dir string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if dir != c.dir {
+		c.dir, c.size, c.measured = dir, 0, time.Time{}
+	}
+	if !c.measuring && time.Since(c.measured) >= cacheSizeMaxAge {
+		c.measuring = true
+		go c.measure(dir)
+	}
+
+	return c.size
+}
+
+func (c *cacheSize) measure(dir string) {
+	size := dirSize(dir)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.measuring = false
+	if dir == c.dir {
+		c.size, c.measured = size, time.Now()
+	}
+}
+
+// reset drops the measured size, e.g. once the cache was cleaned.
+func (c *cacheSize) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.size, c.measured = 0, time.Time{}
+}
+
+// executeCleanCache runs golangci-lint cache clean and lints everything again, since
+// the results may come from the cache that was just removed.
+func (h *langHandler) executeCleanCache() {
+	defer h.recoverPanic("clean cache")
+
+	if err := h.authorizeFor(h.root()); err != nil {
+		h.notifyError(err.Error())
+
+		return
+	}
+
+	opts := h.currentOptions()
+	command, _ := h.commandFor(h.root())
+	lc := lintCommand{Args: []string{command[0], "cache", "clean"}, Dir: h.root(), Env: opts.env()}
+
+	out, err := lc.cmd().CombinedOutput()
+	if err != nil {
+		h.notifyError(h.catalog().Sprintf(messages.CleanCacheFailed, err, strings.TrimSpace(string(out))))
+
+		return
+	}
+
+	h.cacheSize.reset()
+	h.showMessage(MTInfo, h.catalog().Sprintf(messages.CacheCleaned, cacheDir(opts)))
+	h.invalidate("golangci-lint cache cleaned")
+}
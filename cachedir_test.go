@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheSize(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, size := range map[string]int{"a": 100, "sub/b": 23} {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var c cacheSize
+	if size := c.get(dir); size != 0 {
+		t.Errorf("first get = %d, want 0 before the measurement completes", size)
+	}
+	deadline := time.Now().Add(testTimeout)
+	for c.get(dir) != 123 {
+		if time.Now().After(deadline) {
+			t.Fatalf("size %d, want 123", c.get(dir))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The size isn't measured again within cacheSizeMaxAge.
+	if err := os.WriteFile(filepath.Join(dir, "c"), make([]byte, 1000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if size := c.get(dir); size != 123 {
+		t.Errorf("size %d within cacheSizeMaxAge, want the cached 123", size)
+	}
+
+	c.reset()
+	deadline = time.Now().Add(testTimeout)
+	for c.get(dir) != 1123 {
+		if time.Now().After(deadline) {
+			t.Fatalf("size %d after reset, want 1123", c.get(dir))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
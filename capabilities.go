@@ -0,0 +1,92 @@
+package main
+
+import "strings"
+
+// saveIncludesText decides whether didSave carries the document text.
+const saveIncludesText = true
+
+// commands lists the workspace/executeCommand commands handleWorkspaceExecuteCommand understands,
+// named with defaultCommandPrefix.
+var commands = []string{cmdRunWorkspace, cmdOpenRuleDocs, cmdCopyIssue, cmdCleanCache, cmdRunChanged, cmdEnableLinter, cmdDisableLinter, cmdCaptureBundle, cmdSnoozeIssue, cmdClearSnoozed, cmdOpenLocation}
+
+// defaultCommandPrefix is the prefix of the command names, unless the commandPrefix option
+// sets another one.
+const defaultCommandPrefix = "golangci-lint."
+
+// commandID returns the name of cmd under prefix.
+func commandID(prefix, cmd string) string {
+	return prefix + strings.TrimPrefix(cmd, defaultCommandPrefix)
+}
+
+// commandIDs returns the names of every command under prefix.
+func commandIDs(prefix string) []string {
+	ids := make([]string, len(commands))
+	for i, cmd := range commands {
+		ids[i] = commandID(prefix, cmd)
+	}
+
+	return ids
+}
+
+// resolveCommandID returns the command named id under prefix, as named in commands.
+func resolveCommandID(prefix, id string) (string, bool) {
+	if !strings.HasPrefix(id, prefix) {
+		return "", false
+	}
+	cmd := defaultCommandPrefix + strings.TrimPrefix(id, prefix)
+	for _, known := range commands {
+		if known == cmd {
+			return cmd, true
+		}
+	}
+
+	return "", false
+}
+
+// serverCapabilities derives the capabilities announced at initialize from the effective options,
+// what the client supports and the features of golangci-lint, so they can't drift from what the
+// handler actually does.
+func serverCapabilities(opts Options, caps ClientCapabilities, features featureSet) ServerCapabilities {
+	capabilities := ServerCapabilities{
+		PositionEncoding: string(negotiatePositionEncoding(caps)),
+		TextDocumentSync: TextDocumentSyncOptions{
+			Change:    TDSKFull,
+			OpenClose: true,
+			Save:      &SaveOptions{IncludeText: saveIncludesText},
+		},
+		CodeActionProvider: &CodeActionOptions{
+			CodeActionKinds: []CodeActionKind{CAKQuickFix},
+			ResolveProvider: true,
+		},
+		ExecuteCommandProvider: &ExecuteCommandOptions{
+			Commands: commandIDs(opts.CommandPrefix),
+		},
+	}
+
+	capabilities.Workspace = &WorkspaceServerCapabilities{}
+	capabilities.Workspace.WorkspaceFolders.Supported = true
+	capabilities.Workspace.WorkspaceFolders.ChangeNotifications = true
+	capabilities.Workspace.FileOperations = &FileOperationsServerCapabilities{
+		DidRename: &FileOperationRegistrationOptions{
+			Filters: []FileOperationFilter{
+				{Scheme: "file", Pattern: FileOperationPattern{Glob: "**/*.go", Matches: FOPKFile}},
+				{Scheme: "file", Pattern: FileOperationPattern{Glob: "**", Matches: FOPKFolder}},
+			},
+		},
+	}
+
+	capabilities.InlayHintProvider = opts.HiddenIssuesHint
+	if opts.MessageLinks {
+		capabilities.DocumentLinkProvider = &DocumentLinkOptions{}
+	}
+
+	if configVerifySupported(features) {
+		capabilities.Experimental = &ExperimentalCapabilities{ConfigVerify: true}
+	}
+
+	if pullMode(opts, caps) {
+		capabilities.DiagnosticProvider = &DiagnosticOptions{InterFileDependencies: true, WorkspaceDiagnostics: true}
+	}
+
+	return capabilities
+}
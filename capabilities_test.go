@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// checkGolden compares got with the golden file testdata/name, rewriting it with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	golden := filepath.Join("testdata", filepath.FromSlash(name))
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs, run go test -update to update it:\n%s", golden, got)
+	}
+}
+
+func TestServerCapabilities(t *testing.T) {
+	everything := defaultOptions()
+	everything.HiddenIssuesHint = true
+	everything.MessageLinks = true
+	everything.DiagnosticMode = diagnosticModePull
+	everything.CommandPrefix = "custom."
+
+	var pullClient ClientCapabilities
+	pullClient.General.PositionEncodings = []string{"utf-8", "utf-16"}
+	pullClient.TextDocument.Diagnostic = &struct{}{}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		caps     ClientCapabilities
+		features featureSet
+	}{
+		{name: "default", opts: defaultOptions()},
+		{
+			name:     "everything",
+			opts:     everything,
+			caps:     pullClient,
+			features: featureSet{Detected: true, Version: golangciVersion{Major: 2, Minor: 1, Patch: 6}, OutputJSONPath: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.MarshalIndent(serverCapabilities(tt.opts, tt.caps, tt.features), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, "capabilities/"+tt.name+".json", append(b, '\n'))
+		})
+	}
+}
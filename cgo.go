@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// cgoLineDirective matches the //line directives cgo writes into the processed copies of the
+// source files, e.g. "//line /src/pkg/foo.go:12:1".
+var cgoLineDirective = regexp.MustCompile(`^//line (.+?):(\d+)(?::\d+)?$`)
+
+// cgoGenerated reports whether path is a file the go command generated for cgo: the processed
+// copies of the source files (foo.cgo1.go), the _cgo_* files, or anything under the build
+// cache or an _obj directory.
+func cgoGenerated(path string) bool {
+	base := filepath.Base(path)
+	if strings.HasPrefix(base, "_cgo_") || strings.HasSuffix(base, ".cgo1.go") || strings.HasSuffix(base, ".cgo2.go") {
+		return true
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if elem == "go-build" || elem == "_obj" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cgoOriginal returns the source file and line the last //line directive before line of the
+// cgo-generated file at path gives it.
+func cgoOriginal(path string, line int) (string, int, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, false
+	}
+	defer f.Close()
+
+	var (
+		file    string
+		base, n int
+		at      int
+	)
+	scanner := bufio.NewScanner(f)
+	for n < line && scanner.Scan() {
+		n++
+		m := cgoLineDirective.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil || n == line {
+			continue
+		}
+		file, at = m[1], n
+		base, _ = strconv.Atoi(m[2])
+	}
+	if file == "" || n < line || !filepath.IsAbs(file) || cgoGenerated(file) {
+		return "", 0, false
+	}
+
+	// The directive numbers the line after it.
+	return file, base + line - at - 1, true
+}
+
+// mapCgoIssues moves the issues golangci-lint reported in cgo-generated files of a run from dir
+// to the source lines their //line directives point at, and drops those without one: they
+// belong to no file the user edits, and their path could be taken for an open document.
+func (h *langHandler) mapCgoIssues(dir string, issues []Issue) []Issue {
+	kept := issues[:0]
+	dropped := 0
+	for _, issue := range issues {
+		path := issueFilePath(dir, &issue)
+		if !cgoGenerated(path) {
+			kept = append(kept, issue)
+
+			continue
+		}
+
+		file, line, ok := cgoOriginal(path, issue.Pos.Line)
+		if _, err := os.Stat(file); !ok || err != nil {
+			dropped++
+
+			continue
+		}
+
+		delta := line - issue.Pos.Line
+		issue.Pos.Filename, issue.Pos.Line, issue.Pos.Offset = file, line, 0
+		if issue.LineRange.From > 0 {
+			issue.LineRange.From += delta
+			issue.LineRange.To += delta
+		}
+		// cgo rewrites the calls into C, so the columns of a fix don't apply to the source.
+		issue.Replacement = nil
+		kept = append(kept, issue)
+	}
+	if dropped > 0 {
+		h.logger.Printf("golangci-lint-langserver: dropped %d issues in files generated by cgo", dropped)
+	}
+
+	return kept
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
+)
+
+// cgoRecordingHome is the home directory of the machine testdata/cgo was recorded on.
+const cgoRecordingHome = "/home/ci"
+
+// copyCgoFixture copies testdata/cgo into a temporary home, moving the paths of the recording
+// there, and returns the result of the run of the package together with the home.
+func copyCgoFixture(t *testing.T) (*lint.Result, string) {
+	t.Helper()
+
+	home := t.TempDir()
+	err := filepath.Walk(filepath.Join("testdata", "cgo"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(filepath.Join("testdata", "cgo"), path)
+		if err != nil {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		// The fixture lays out the package, the build cache and the result under the home.
+		dst := filepath.Join(home, rel)
+		switch strings.SplitN(filepath.ToSlash(rel), "/", 2)[0] {
+		case "go-build":
+			dst = filepath.Join(home, ".cache", rel)
+		case "src":
+			dst = filepath.Join(home, "src", "cgo", filepath.Base(rel))
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+
+		return os.WriteFile(dst, bytes.ReplaceAll(b, []byte(cgoRecordingHome), []byte(filepath.ToSlash(home))), 0o644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filepath.Join(home, "result.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	result, err := lint.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result, home
+}
+
+func TestMapCgoIssues(t *testing.T) {
+	result, home := copyCgoFixture(t)
+	dir := filepath.Join(home, "src", "cgo")
+	logger := &testLogger{}
+	h := newLangHandler(logger, false)
+
+	type position struct {
+		Linter   string
+		Filename string
+		Line     int
+		From, To int
+		Fix      bool
+	}
+	var got []position
+	for _, issue := range h.mapCgoIssues(dir, result.Issues) {
+		got = append(got, position{
+			Linter:   issue.FromLinter,
+			Filename: issue.Pos.Filename,
+			Line:     issue.Pos.Line,
+			From:     issue.LineRange.From,
+			To:       issue.LineRange.To,
+			Fix:      issue.Replacement != nil,
+		})
+	}
+	want := []position{
+		// The processed copy of checksum.go maps back through its //line directive.
+		{Linter: "gosec", Filename: filepath.Join(dir, "checksum.go"), Line: 27},
+		{Linter: "err113", Filename: filepath.Join(dir, "checksum.go"), Line: 24, From: 24, To: 24},
+		// _cgo_gotypes.go and _cgo_import.go have no source: their issues are dropped.
+		{Linter: "mnd", Filename: "plain.go", Line: 5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mapped issues %+v, want %+v", got, want)
+	}
+	if want := "dropped 2 issues in files generated by cgo"; !strings.Contains(logger.String(), want) {
+		t.Errorf("log %q doesn't state %q", logger, want)
+	}
+}
+
+func TestCgoOriginal(t *testing.T) {
+	_, home := copyCgoFixture(t)
+	cache := filepath.Join(home, ".cache", "go-build")
+	cgo1 := filepath.Join(cache, "9c", "9c09074845b68c9cde118716125ec60bbfdd28ff0f3ffbd7c73c9b43ee6fa53e-d")
+	gotypes := filepath.Join(cache, "7c", "7cb054bdd03a3c4aa0f22224f1942b3c9504d2a745c427d69cab378bf1abfcab-d")
+	source := filepath.Join(home, "src", "cgo", "checksum.go")
+
+	tests := []struct {
+		name string
+		path string
+		line int
+		file string
+		want int
+	}{
+		{name: "package clause", path: cgo1, line: 4, file: source, want: 1},
+		{name: "call into C", path: cgo1, line: 30, file: source, want: 27},
+		{name: "the directive itself", path: cgo1, line: 3},
+		{name: "before the directive", path: cgo1, line: 1},
+		{name: "past the end", path: cgo1, line: 100},
+		{name: "_cgo_gotypes.go", path: gotypes, line: 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !cgoGenerated(tt.path) {
+				t.Errorf("%s isn't taken for a cgo-generated file", tt.path)
+			}
+			file, line, ok := cgoOriginal(tt.path, tt.line)
+			if ok != (tt.file != "") || file != tt.file || line != tt.want {
+				t.Errorf("cgoOriginal(%d) = %q, %d, %v, want %q, %d", tt.line, file, line, ok, tt.file, tt.want)
+			}
+		})
+	}
+	if cgoGenerated(source) {
+		t.Errorf("%s is taken for a cgo-generated file", source)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+const cmdRunChanged = "golangci-lint.runChanged"
+
+// RunChangedArgs is the optional argument of the golangci-lint.runChanged command.
+type RunChangedArgs struct {
+	// Rev adds the files changed since the merge base of Rev and HEAD, e.g. "origin/main".
+	Rev string `json:"rev,omitempty"`
+}
+
+// executeRunChanged lints the packages of the Go files git reports as changed and clears the
+// diagnostics of the deleted ones.
+func (h *langHandler) executeRunChanged(arguments []json.RawMessage, token ProgressToken) error {
+	var args RunChangedArgs
+	if len(arguments) > 0 {
+		if err := json.Unmarshal(arguments[0], &args); err != nil {
+			return err
+		}
+	}
+
+	h.scheduler.Go(func() { h.lintChanged(args, token) })
+
+	return nil
+}
+
+func (h *langHandler) lintChanged(args RunChangedArgs, token ProgressToken) {
+	defer h.recoverPanic("changed files lint")
+
+	if err := h.authorizeFor(h.root()); err != nil {
+		h.notifyError(err.Error())
+
+		return
+	}
+
+	changes, err := changedGoFiles(h.root(), args.Rev)
+	if err != nil {
+		h.notifyError(h.catalog().Sprintf(messages.GitFailed, err))
+
+		return
+	}
+
+	for _, path := range changes.Deleted {
+		h.clearFile(h.paths.pathToURI(path))
+		h.linted.forget(h.paths.pathToURI(path))
+	}
+
+	dirs := make(map[string]struct{})
+	for _, path := range append(changes.Changed, changes.Deleted...) {
+		// The package of a deleted file changed too, unless it has no Go file left.
+		dir := filepath.Dir(path)
+		if !h.included(dir) {
+			continue
+		}
+		if matches, _ := filepath.Glob(filepath.Join(dir, "*.go")); len(matches) > 0 {
+			dirs[dir] = struct{}{}
+		}
+	}
+	if len(dirs) == 0 {
+		h.showMessage(MTInfo, h.catalog().Sprintf(messages.NoChangedFiles, h.root()))
+
+		return
+	}
+
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+	sort.Strings(sorted)
+
+	start := time.Now()
+	revisions := h.documents.revisions()
+	stale := make(map[DocumentURI]struct{})
+
+	h.progress(token, &WorkDoneProgressBegin{Kind: "begin", Title: h.catalog().Sprintf(messages.LintingChanged)})
+	for i, dir := range sorted {
+		unit := h.packageUnit(dir)
+		h.progress(token, &WorkDoneProgressReport{
+			Kind:       "report",
+			Message:    h.catalog().Sprintf(messages.LintedPackages, unit.target, i+1, len(sorted)),
+			Percentage: i * 100 / len(sorted),
+		})
+
+		diagnostics, err := h.lintWorkspaceUnit(unit, start, revisions, stale)
+		if err != nil {
+			h.notifyError(h.catalog().Sprintf(messages.WorkspaceRunFailed, h.errToDiagnostics(err, nil)[0].Message))
+
+			continue
+		}
+		h.clearPackage(dir, diagnostics, stale)
+		h.publishFiles(diagnostics)
+	}
+	h.progress(token, &WorkDoneProgressEnd{Kind: "end"})
+
+	for uri := range stale {
+		h.schedule(uri, TriggerStale)
+	}
+}
+
+// packageUnit lints the package in dir alone, from the module owning it.
+func (h *langHandler) packageUnit(dir string) workspaceUnit {
+	unit := h.dirUnit(dir)
+	unit.target = strings.TrimSuffix(strings.TrimSuffix(unit.target, "..."), "/")
+
+	return unit
+}
+
+// clearPackage clears the previously published files of the package in dir that have
+// no diagnostics after a run, except the stale ones.
+func (h *langHandler) clearPackage(dir string, diagnostics map[DocumentURI][]Diagnostic, stale map[DocumentURI]struct{}) {
+	h.publishedMu.Lock()
+	defer h.publishedMu.Unlock()
+
+	for uri := range h.published[dir] {
+		if _, ok := stale[uri]; ok {
+			continue
+		}
+		if _, ok := diagnostics[uri]; !ok {
+			h.publishDiagnostics(uri, []Diagnostic{})
+			h.issues.replace(uri, nil, nil)
+			delete(h.published[dir], uri)
+		}
+	}
+}
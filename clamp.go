@@ -0,0 +1,54 @@
+package main
+
+import "strings"
+
+// maxPosition is the largest line or character a client accepts, the maximum of an LSP uinteger.
+const maxPosition = 1<<31 - 1
+
+// clampRange keeps r within lines, the text of the document, or within maxPosition when the
+// text isn't tracked, so that stale or malformed issues never point past the document.
+func (e positionEncoding) clampRange(r Range, lines []string) Range {
+	r.Start = e.clampPosition(r.Start, lines)
+	r.End = e.clampPosition(r.End, lines)
+	if r.End.Line < r.Start.Line || (r.End.Line == r.Start.Line && r.End.Character < r.Start.Character) {
+		r.End = r.Start
+	}
+
+	return r
+}
+
+func (e positionEncoding) clampPosition(p Position, lines []string) Position {
+	if lines == nil {
+		p.Line = min(max(p.Line, 0), maxPosition)
+		p.Character = min(max(p.Character, 0), maxPosition)
+
+		return p
+	}
+
+	p.Line = max(p.Line, 0)
+	if p.Line >= len(lines) {
+		// Past the end, e.g. after an edit removed lines: the end of the last line.
+		p.Line = len(lines) - 1
+		p.Character = maxPosition
+	}
+	line := strings.TrimRight(lines[p.Line], "\r")
+	p.Character = min(max(p.Character, 0), e.character(line, len(line)))
+
+	return p
+}
+
+// documentLines returns the lines of the tracked text of uri, or nil if it isn't open.
+func (h *langHandler) documentLines(uri DocumentURI) []string {
+	doc, ok := h.documents.get(uri)
+	if !ok {
+		return nil
+	}
+
+	return strings.Split(doc.Text, "\n")
+}
+
+// unplacedIssue reports whether issue has no usable position, such as a package-level
+// finding without a file name or line, which is shown on the whole first line.
+func unplacedIssue(issue *Issue) bool {
+	return issue.Pos.Filename == "" || issue.Pos.Line <= 0
+}
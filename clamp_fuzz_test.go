@@ -0,0 +1,60 @@
+//go:build go1.18
+// +build go1.18
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// FuzzClampRange converts issues at arbitrary lines and columns of arbitrary documents and
+// checks that the range of the diagnostic is ordered and inside the document.
+func FuzzClampRange(f *testing.F) {
+	f.Add("package test\n\nfunc A() {}\n", 3, 6, false)
+	f.Add("package test\r\n\r\nvar s = \"日本😀\"\r\n", 3, 14, true)
+	f.Add("", 1, 1, false)
+	f.Add("\ufeffpackage test", 1, 100, true)
+	f.Add("package test\n", 0, -4, false)
+	f.Add("package test\n", 40, 2, true)
+	f.Add("x\xff\xfe\n", 1, 3, true)
+	f.Add("package test\n", 1<<40, 1<<40, false)
+
+	dir := f.TempDir()
+	uri := pathToURI(filepath.Join(dir, "a.go"))
+	f.Fuzz(func(t *testing.T, text string, line, column int, utf16 bool) {
+		h := newLangHandler(&testLogger{}, false)
+		h.encoding = positionEncodingUTF8
+		if utf16 {
+			h.encoding = positionEncodingUTF16
+		}
+		h.documents.open(uri, text, 1)
+
+		var issue Issue
+		issue.FromLinter = "fake"
+		issue.Text = "issue"
+		issue.Pos.Filename = "a.go"
+		issue.Pos.Line = line
+		issue.Pos.Column = column
+
+		r := h.fileDiagnostic(uri, filepath.Join(dir, "a.go"), &issue).Range
+		if r.Start.Line < 0 || r.Start.Character < 0 || r.End.Line < 0 || r.End.Character < 0 {
+			t.Fatalf("negative range %+v", r)
+		}
+		if r.End.Line < r.Start.Line || (r.End.Line == r.Start.Line && r.End.Character < r.Start.Character) {
+			t.Fatalf("reversed range %+v", r)
+		}
+
+		lines := h.documentLines(uri)
+		for _, p := range []Position{r.Start, r.End} {
+			if p.Line >= len(lines) {
+				t.Fatalf("range %+v past the %d lines of the document", r, len(lines))
+			}
+			l := strings.TrimRight(lines[p.Line], "\r")
+			if n := h.encoding.character(l, len(l)); p.Character > n {
+				t.Fatalf("range %+v past the %d characters of line %d", r, n, p.Line)
+			}
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClampRange(t *testing.T) {
+	lines := strings.Split("package test\r\n\r\nvar s = \"日本\"\n", "\n")
+	tests := []struct {
+		name     string
+		encoding positionEncoding
+		lines    []string
+		r        Range
+		want     Range
+	}{
+		{
+			name:  "inside",
+			lines: lines,
+			r:     Range{Start: Position{Line: 0, Character: 8}, End: Position{Line: 0, Character: 12}},
+			want:  Range{Start: Position{Line: 0, Character: 8}, End: Position{Line: 0, Character: 12}},
+		},
+		{
+			name:  "past the line ending",
+			lines: lines,
+			r:     Range{Start: Position{Line: 0, Character: 8}, End: Position{Line: 0, Character: 40}},
+			want:  Range{Start: Position{Line: 0, Character: 8}, End: Position{Line: 0, Character: 12}},
+		},
+		{
+			name:  "past the last line",
+			lines: lines,
+			r:     Range{Start: Position{Line: 7, Character: 1}, End: Position{Line: 9, Character: 0}},
+			want:  Range{Start: Position{Line: 3}, End: Position{Line: 3}},
+		},
+		{
+			name:  "negative",
+			lines: lines,
+			r:     Range{Start: Position{Line: -1, Character: -5}, End: Position{Line: 0, Character: -1}},
+			want:  Range{},
+		},
+		{
+			name:  "reversed",
+			lines: lines,
+			r:     Range{Start: Position{Line: 2, Character: 4}, End: Position{Line: 1, Character: 0}},
+			want:  Range{Start: Position{Line: 2, Character: 4}, End: Position{Line: 2, Character: 4}},
+		},
+		{
+			name:     "utf-16 end of a multi-byte line",
+			encoding: positionEncodingUTF16,
+			lines:    lines,
+			r:        Range{Start: Position{Line: 2, Character: 0}, End: Position{Line: 2, Character: 100}},
+			want:     Range{Start: Position{Line: 2, Character: 0}, End: Position{Line: 2, Character: 12}},
+		},
+		{
+			name: "untracked text",
+			r:    Range{Start: Position{Line: -3, Character: 2}, End: Position{Line: maxPosition + 1, Character: maxPosition + 1}},
+			want: Range{Start: Position{Line: 0, Character: 2}, End: Position{Line: maxPosition, Character: maxPosition}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoding := tt.encoding
+			if encoding == "" {
+				encoding = positionEncodingUTF8
+			}
+			if got := encoding.clampRange(tt.r, tt.lines); got != tt.want {
+				t.Errorf("clampRange(%+v) = %+v, want %+v", tt.r, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnplacedIssue(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		line     int
+		want     bool
+	}{
+		{name: "placed", filename: "a.go", line: 3},
+		{name: "no file name", line: 3, want: true},
+		{name: "no line", filename: "a.go", want: true},
+		{name: "negative line", filename: "a.go", line: -1, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var issue Issue
+			issue.Pos.Filename = tt.filename
+			issue.Pos.Line = tt.line
+			if got := unplacedIssue(&issue); got != tt.want {
+				t.Errorf("unplacedIssue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"strings"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+const (
+	actionFix    = "fix"
+	actionNoLint = "nolint"
+)
+
+func (h *langHandler) handleTextDocumentCodeAction(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params CodeActionParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	uri := params.TextDocument.URI
+	lazy := h.supportsResolve("edit")
+
+	actions := make([]CodeAction, 0)
+	if !h.actionable(uri) {
+		// Edits would target a read-only file or one outside the workspace.
+		return actions, nil
+	}
+	var snoozes, copies []CodeAction
+	for _, issue := range h.codeActionIssues(uri, params.Context.Diagnostics) {
+		issue := issue
+		diagnostic := h.fileDiagnostic(uri, h.paths.uriToPath(string(uri)), &issue)
+
+		for _, action := range h.issueActions(&issue) {
+			codeAction := CodeAction{
+				Title:       action.title,
+				Kind:        CAKQuickFix,
+				Diagnostics: []Diagnostic{diagnostic},
+				IsPreferred: action.preferred,
+			}
+
+			if lazy {
+				// The edit is computed in codeAction/resolve only for the action the user picks.
+				codeAction.Data = &CodeActionData{URI: uri, IssueID: issueID(&issue), Action: action.name}
+			} else {
+				edit, err := h.actionEdit(uri, &issue, action.name)
+				if err != nil {
+					h.logger.Printf("golangci-lint-langserver: %s", err)
+
+					continue
+				}
+				codeAction.Edit = edit
+			}
+
+			actions = append(actions, codeAction)
+		}
+
+		if action, ok := h.ruleDocsAction(&issue); ok {
+			actions = append(actions, action)
+		}
+
+		snoozes = append(snoozes, h.snoozeAction(uri, &issue, diagnostic))
+		copies = append(copies, h.copyIssueAction(uri, diagnostic))
+	}
+
+	// Snoozing and copying are the least likely picks, so they come after every other action.
+	return append(append(actions, snoozes...), copies...), nil
+}
+
+func (h *langHandler) handleCodeActionResolve(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var action CodeAction
+	if err := json.Unmarshal(*req.Params, &action); err != nil {
+		return nil, err
+	}
+
+	if action.Data == nil {
+		return action, nil
+	}
+	if !h.actionable(action.Data.URI) {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: h.catalog().Sprintf(messages.NotActionable, h.paths.uriToPath(string(action.Data.URI)))}
+	}
+
+	issue, ok := h.issues.find(action.Data.URI, action.Data.IssueID)
+	if !ok {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: h.catalog().Sprintf(messages.IssueGone)}
+	}
+
+	edit, err := h.actionEdit(action.Data.URI, &issue, action.Data.Action)
+	if err != nil {
+		return nil, err
+	}
+	action.Edit = edit
+	h.stats.linters.picked(issue.FromLinter, action.Data.Action)
+
+	return action, nil
+}
+
+func (h *langHandler) supportsResolve(property string) bool {
+	support := h.clientCaps.TextDocument.CodeAction.ResolveSupport
+	if support == nil {
+		return false
+	}
+
+	for _, p := range support.Properties {
+		if p == property {
+			return true
+		}
+	}
+
+	return false
+}
+
+// codeActionIssues returns the cached issues behind diagnostics, identified by their data
+// or, for clients that don't round-trip it, by line and linter.
+func (h *langHandler) codeActionIssues(uri DocumentURI, diagnostics []Diagnostic) []Issue {
+	cached := h.issues.get(uri)
+	seen := make(map[string]struct{})
+
+	var issues []Issue
+	for _, d := range diagnostics {
+		for _, issue := range cached {
+			issue := issue
+
+			id := issueID(&issue)
+			if d.Data != nil {
+				if d.Data.IssueID != id {
+					continue
+				}
+			} else if linter := diagnosticLinter(&d); d.Range.Start.Line != max(issue.Pos.Line-1, 0) || (linter != "" && linter != issue.FromLinter) {
+				continue
+			}
+
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues
+}
+
+type issueAction struct {
+	name      string
+	title     string
+	preferred bool
+}
+
+func (h *langHandler) issueActions(issue *Issue) []issueAction {
+	var actions []issueAction
+	if issue.Replacement != nil {
+		actions = append(actions, issueAction{
+			name:      actionFix,
+			title:     h.catalog().Sprintf(messages.ApplyFix, issue.FromLinter),
+			preferred: true,
+		})
+	}
+
+	return append(actions, issueAction{
+		name:  actionNoLint,
+		title: h.catalog().Sprintf(messages.DisableLinterForLine, issue.FromLinter),
+	})
+}
+
+func (h *langHandler) actionEdit(uri DocumentURI, issue *Issue, action string) (*WorkspaceEdit, error) {
+	var edits []TextEdit
+	switch action {
+	case actionFix:
+		if issue.Replacement == nil {
+			return nil, h.catalog().Errorf(messages.NoFix, issue.FromLinter)
+		}
+		edits = []TextEdit{replacementEdit(issue, h.encoding, h.lineEnding(uri))}
+	case actionNoLint:
+		edit, err := h.noLintEdit(uri, issue)
+		if err != nil {
+			return nil, err
+		}
+		edits = []TextEdit{edit}
+	default:
+		return nil, fmt.Errorf("unknown code action: %s", action)
+	}
+
+	return &WorkspaceEdit{Changes: map[DocumentURI][]TextEdit{uri: edits}}, nil
+}
+
+// replacementEdit returns the edit applying the replacement of issue, its new lines ending
+// with eol, the line ending of the file.
+func replacementEdit(issue *Issue, encoding positionEncoding, eol string) TextEdit {
+	r := issue.Replacement
+	line := max(issue.Pos.Line-1, 0)
+
+	if r.Inline != nil {
+		text := issueLine(issue)
+
+		return TextEdit{
+			Range: Range{
+				Start: Position{Line: line, Character: encoding.character(text, r.Inline.StartCol)},
+				End:   Position{Line: line, Character: encoding.character(text, r.Inline.StartCol+r.Inline.Length)},
+			},
+			NewText: withLineEnding(r.Inline.NewString, eol),
+		}
+	}
+
+	from, to := issue.LineRange.From, issue.LineRange.To
+	if from <= 0 {
+		from, to = issue.Pos.Line, issue.Pos.Line
+	}
+
+	edit := TextEdit{
+		Range: Range{
+			Start: Position{Line: max(from-1, 0)},
+			End:   Position{Line: max(to, from)},
+		},
+	}
+	if !r.NeedOnlyDelete {
+		edit.NewText = withLineEnding(strings.Join(r.NewLines, "\n")+"\n", eol)
+	}
+
+	return edit
+}
+
+// noLintEdit appends a //nolint directive for the linter to the line of the issue,
+// extending an existing directive when there is one. A trailing comment becomes the
+// explanation of the directive, since golangci-lint only reads a directive starting the comment.
+func (h *langHandler) noLintEdit(uri DocumentURI, issue *Issue) (TextEdit, error) {
+	line := max(issue.Pos.Line-1, 0)
+
+	var text string
+	if doc, ok := h.documents.text(uri); ok {
+		lines := strings.Split(doc, "\n")
+		if line < len(lines) {
+			text = strings.TrimRight(lines[line], "\r")
+		}
+	} else if len(issue.SourceLines) > 0 {
+		text = issueLine(issue)
+	} else {
+		return TextEdit{}, fmt.Errorf("no source for line %d of %s", issue.Pos.Line, uri)
+	}
+
+	if i := strings.Index(text, "//nolint:"); i >= 0 {
+		end := len(text)
+		if j := strings.IndexAny(text[i:], " \t"); j >= 0 {
+			end = i + j
+		}
+
+		end = h.encoding.character(text, end)
+
+		return TextEdit{
+			Range:   Range{Start: Position{Line: line, Character: end}, End: Position{Line: line, Character: end}},
+			NewText: "," + issue.FromLinter,
+		}, nil
+	}
+
+	if i := lineComment(text); i >= 0 {
+		start := h.encoding.character(text, i)
+
+		return TextEdit{
+			Range:   Range{Start: Position{Line: line, Character: start}, End: Position{Line: line, Character: start}},
+			NewText: "//nolint:" + issue.FromLinter + " ",
+		}, nil
+	}
+
+	end := h.encoding.character(text, len(text))
+
+	return TextEdit{
+		Range:   Range{Start: Position{Line: line, Character: end}, End: Position{Line: line, Character: end}},
+		NewText: " //nolint:" + issue.FromLinter,
+	}, nil
+}
+
+// lineComment returns the byte offset of the // comment ending the Go source line, or -1.
+func lineComment(line string) int {
+	src := []byte(line)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, func(token.Position, string) {}, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		switch {
+		case tok == token.EOF:
+			return -1
+		case tok == token.COMMENT && strings.HasPrefix(lit, "//"):
+			return file.Offset(pos)
+		}
+	}
+}
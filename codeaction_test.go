@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestNoLintEdit(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{name: "plain line", line: "\tfoo()", want: "\tfoo() //nolint:errcheck"},
+		{name: "existing directive", line: "\tfoo() //nolint:gosec", want: "\tfoo() //nolint:gosec,errcheck"},
+		{name: "existing directive with explanation", line: "\tfoo() //nolint:gosec // checked", want: "\tfoo() //nolint:gosec,errcheck // checked"},
+		{name: "trailing comment", line: "\tfoo() // note", want: "\tfoo() //nolint:errcheck // note"},
+		{name: "comment marker in a string", line: `	foo("http://example.com")`, want: `	foo("http://example.com") //nolint:errcheck`},
+		{name: "string and trailing comment", line: "\tfoo(`//x`) // note", want: "\tfoo(`//x`) //nolint:errcheck // note"},
+		{name: "block comment", line: "\tfoo() /* note */", want: "\tfoo() /* note */ //nolint:errcheck"},
+		{name: "multi-byte text", line: "\tfoo(\"héllo\") // note", want: "\tfoo(\"héllo\") //nolint:errcheck // note"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newLangHandler(&testLogger{}, false)
+			uri := DocumentURI("file:///work/a.go")
+			h.documents.open(uri, "package a\n"+tt.line+"\n", 1)
+			issue := &Issue{FromLinter: "errcheck"}
+			issue.Pos.Line = 2
+
+			edit, err := h.noLintEdit(uri, issue)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := applyEdit(tt.line, edit, h.encoding); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// applyEdit applies an edit within the single line text.
+func applyEdit(text string, edit TextEdit, encoding positionEncoding) string {
+	start, end := len(text), len(text)
+	for i := range text {
+		if encoding.character(text, i) == edit.Range.Start.Character && start == len(text) {
+			start = i
+		}
+		if encoding.character(text, i) == edit.Range.End.Character && end == len(text) {
+			end = i
+		}
+	}
+
+	return text[:start] + edit.NewText + text[end:]
+}
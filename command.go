@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+// lintCommand is a fully resolved golangci-lint invocation.
+type lintCommand struct {
+	Args []string // including the executable
+	Dir  string
+	Env  []string // variables set in addition to the server's environment
+	// Profile names the profile the command runs, if any.
+	Profile string
+	// Background runs yield to the lints the user waits for.
+	Background bool
+	// File is the document the run lints, for the placeholders of the command.
+	File string
+	// Fast runs only the fast linters, see the twoPass option.
+	Fast bool
+	// Priority lowers the priority of the run, see the processPriority option.
+	Priority ProcessPriority
+	// ctx kills the run when done, if set.
+	ctx context.Context
+}
+
+// resolveFileCommand resolves the invocation linting the package of the file at path.
+// In a go.work workspace, golangci-lint runs from the module owning the file.
+func resolveFileCommand(command []string, rootDir string, modules []string, path string) lintCommand {
+	dir, _ := filepath.Split(path)
+
+	cmdDir := dir
+	if module := owningModule(modules, path); module != "" {
+		cmdDir = module
+	} else if rootDir != "" && isSubdir(rootDir, path) {
+		cmdDir = rootDir
+	}
+
+	lc := newLintCommand(command, cmdDir, dir)
+	lc.File = path
+
+	return lc
+}
+
+func newLintCommand(command []string, dir, target string) lintCommand {
+	args := make([]string, 0, len(command)+1)
+	args = append(args, command...)
+	args = append(args, target)
+
+	return lintCommand{Args: args, Dir: dir}
+}
+
+// withConcurrency sets --concurrency to n, unless n is 0.
+func withConcurrency(command []string, n int) []string {
+	if n <= 0 || len(command) == 0 {
+		return command
+	}
+
+	args := removeFlag(command[1:], "--concurrency")
+
+	return append(append([]string{command[0]}, args...), "--concurrency="+strconv.Itoa(n))
+}
+
+func (c lintCommand) cmd() *exec.Cmd {
+	if c.ctx != nil {
+		return c.cmdContext(c.ctx)
+	}
+
+	return c.cmdContext(context.Background())
+}
+
+// cmdContext returns the command, killed when ctx is done.
+func (c lintCommand) cmdContext(ctx context.Context) *exec.Cmd {
+	args := withPriority(c.Args, c.Priority)
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = c.Dir
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+
+	return cmd
+}
+
+// printCommand writes the invocation the server would run for the file at path without starting the LSP loop.
+// initOptions is decoded like initializationOptions.
+func printCommand(w io.Writer, path, rootDir, initOptions string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if rootDir != "" {
+		if rootDir, err = filepath.Abs(rootDir); err != nil {
+			return err
+		}
+	}
+
+	msgs := messages.New("", nil)
+	opts, err := decodeOptions(json.RawMessage(initOptions), msgs)
+	if err != nil {
+		return err
+	}
+
+	features, err := detectFeatures(opts.Command[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to detect golangci-lint features: %s\n", err)
+	}
+	if err := features.checkSupported(msgs); err != nil {
+		return err
+	}
+
+	var modules []string
+	if rootDir != "" {
+		modules, _ = (&goWork{}).load(rootDir)
+	}
+
+	command := withBuildTags(withConcurrency(normalizeCommand(opts.Command, features), opts.concurrency()), opts.BuildTags)
+	if b, err := ioutil.ReadFile(path); err == nil {
+		tags, _, _ := fileBuildTags(string(b), command)
+		command = withBuildTags(command, tags)
+	}
+
+	lc := resolveFileCommand(command, rootDir, modules, path)
+	lc.Env = opts.env()
+	lc = withGOPATHMode(lc, probeGoEnv(lc.Env), path)
+	lc, missing := expandPlaceholders(lc)
+	if missing != "" {
+		return msgs.Errorf(messages.UnresolvedPlaceholder, missing)
+	}
+
+	quoted := make([]string, 0, len(lc.Args))
+	for _, arg := range lc.Args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+
+	fmt.Fprintf(w, "argv: %s\n", strings.Join(quoted, " "))
+	fmt.Fprintf(w, "cwd: %s\n", lc.Dir)
+	fmt.Fprintf(w, "env:")
+	for _, env := range lc.Env {
+		fmt.Fprintf(w, " %s", shellQuote(env))
+	}
+	fmt.Fprintln(w)
+
+	return nil
+}
+
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r == '/' || r == '-' || r == '_' || r == '.' || r == '=' || r == ',' || r == ':' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+	}) < 0 {
+		return s
+	}
+
+	return strconv.Quote(s)
+}
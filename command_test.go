@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveFileCommand(t *testing.T) {
+	root := t.TempDir()
+	path := func(name string) string {
+		return filepath.Join(root, filepath.FromSlash(name))
+	}
+	command := []string{"golangci-lint", "run"}
+	modules := []string{path("mod"), path("mod/inner")}
+
+	tests := []struct {
+		name    string
+		file    string
+		modules []string
+		dir     string
+		target  string
+	}{
+		{name: "root package", file: path("a.go"), dir: root, target: path("") + string(filepath.Separator)},
+		{name: "nested package", file: path("sub/pkg/b.go"), dir: root, target: path("sub/pkg") + string(filepath.Separator)},
+		{name: "work module", file: path("mod/x/c.go"), modules: modules, dir: path("mod"), target: path("mod/x") + string(filepath.Separator)},
+		{name: "nested work module", file: path("mod/inner/d.go"), modules: modules, dir: path("mod/inner"), target: path("mod/inner") + string(filepath.Separator)},
+		{name: "outside the work modules", file: path("tools/e.go"), modules: modules, dir: root, target: path("tools") + string(filepath.Separator)},
+		{name: "sibling sharing the root prefix", file: root + "2" + string(filepath.Separator) + "f.go", dir: root + "2" + string(filepath.Separator), target: root + "2" + string(filepath.Separator)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := resolveFileCommand(command, root, tt.modules, tt.file)
+			if lc.Dir != tt.dir {
+				t.Errorf("dir %q, want %q", lc.Dir, tt.dir)
+			}
+			if want := append(append([]string{}, command...), tt.target); !reflect.DeepEqual(lc.Args, want) {
+				t.Errorf("args %q, want %q", lc.Args, want)
+			}
+			if lc.File != tt.file {
+				t.Errorf("file %q, want %q", lc.File, tt.file)
+			}
+		})
+	}
+}
+
+func TestPrintCommand(t *testing.T) {
+	// t.Setenv needs Go 1.17.
+	old, ok := os.LookupEnv("GO111MODULE")
+	if err := os.Setenv("GO111MODULE", "on"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv("GO111MODULE", old)
+		} else {
+			os.Unsetenv("GO111MODULE")
+		}
+	})
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":   "module example.com/test\n\ngo 1.16\n",
+		"sub/b.go": "//go:build integration\n\npackage sub\n",
+	})
+	file := filepath.Join(root, "sub", "b.go")
+
+	var buf bytes.Buffer
+	initOptions := `{"command": ["golangci-lint-missing", "run", "--timeout=1m"], "gogc": 50, "buildTags": ["ci"], "cacheDir": "/tmp/lint cache"}`
+	if err := printCommand(&buf, file, root, initOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "argv: golangci-lint-missing run --timeout=1m --build-tags=ci,integration " + shellQuote(filepath.Join(root, "sub")+string(filepath.Separator)) + "\n" +
+		"cwd: " + root + "\n" +
+		"env: GOGC=50 \"GOLANGCI_LINT_CACHE=/tmp/lint cache\"\n"
+	if got := buf.String(); got != want {
+		t.Errorf("printed\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPrintCommandErrors(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "a.go")
+	if err := os.WriteFile(file, []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, initOptions := range map[string]string{
+		"invalid JSON":   `{"command": `,
+		"invalid option": `{"gogc": -1}`,
+		"placeholder":    `{"command": ["golangci-lint", "run", "--config=${unknown}"]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := printCommand(&buf, file, root, initOptions); err == nil {
+				t.Errorf("printed %q, want an error", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// companions returns the existing files the companionGlobs option pairs with the saved file
+// at path, such as the _gen.go file a generator rewrites after each save.
+func companions(globs map[string][]string, path string) []string {
+	dir, base := filepath.Split(path)
+	dir = filepath.Clean(dir)
+	vars := strings.NewReplacer(
+		"{{dir}}", dir,
+		"{{base}}", base,
+		"{{name}}", strings.TrimSuffix(base, filepath.Ext(base)),
+	)
+
+	found := make(map[string]struct{})
+	for pattern, templates := range globs {
+		if ok, _ := filepath.Match(pattern, base); !ok {
+			continue
+		}
+
+		for _, template := range templates {
+			glob := absFrom(dir, filepath.FromSlash(vars.Replace(template)))
+			matches, _ := filepath.Glob(glob)
+			for _, match := range matches {
+				if !samePath(match, path) {
+					found[match] = struct{}{}
+				}
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(found))
+	for path := range found {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+// lintCompanions lints the companions of the saved uri again, after the run of uri,
+// so that their diagnostics are published even when their contents changed behind our back.
+func (h *langHandler) lintCompanions(uri DocumentURI) {
+	paths := companions(h.currentOptions().CompanionGlobs, h.paths.uriToPath(string(uri)))
+	if len(paths) == 0 {
+		return
+	}
+
+	h.logger.Printf("golangci-lint-langserver: linting %d companions of %s", len(paths), uri)
+	go func() {
+		for _, path := range paths {
+			companion := h.paths.pathToURI(path)
+			h.linted.forget(companion)
+			h.enqueue(companion, TriggerCompanion)
+		}
+	}()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// minConfigVerifyVersion is the first golangci-lint with `config verify`.
+var minConfigVerifyVersion = golangciVersion{Major: 1, Minor: 59}
+
+// configVerifySource is the source of the diagnostics of golangci-lint configuration files.
+const configVerifySource = "golangci-lint config verify"
+
+var (
+	// configPointerRegexp finds the JSON pointer of a schema error: "- at '/linters'" in
+	// golangci-lint v2 and "jsonschema: '/linters' does not validate" in v1.
+	configPointerRegexp = regexp.MustCompile(`(?:at|jsonschema:) ['"](/[^'"]*)['"]`)
+	configLineRegexp    = regexp.MustCompile(`\bline (\d+)\b`)
+	// configPropertyRegexp names the unknown key of an error, which is more precise than its parent.
+	configPropertyRegexp = regexp.MustCompile(`additional propert(?:y|ies) '([^']+)'`)
+)
+
+// isLintConfig reports whether path is a golangci-lint configuration file.
+func isLintConfig(path string) bool {
+	base := filepath.Base(path)
+	for _, name := range configFileNames {
+		if base == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// verifyConfig runs `golangci-lint config verify` on the configuration file at uri and returns
+// its errors as diagnostics, none when the file is valid or golangci-lint is too old to verify it.
+func (h *langHandler) verifyConfig(uri DocumentURI) []Diagnostic {
+	h.mu.Lock()
+	features := h.features
+	h.mu.Unlock()
+
+	diagnostics := make([]Diagnostic, 0)
+	if !configVerifySupported(features) {
+		return diagnostics
+	}
+
+	path := h.paths.uriToPath(string(uri))
+	command, env := h.commandFor(path)
+	if !h.trusted(command[0], env) {
+		return diagnostics
+	}
+
+	lc := lintCommand{
+		Args: []string{command[0], "config", "verify", "--config", path},
+		Dir:  filepath.Dir(path),
+		Env:  append(append([]string{}, env...), h.currentOptions().env()...),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), detectTimeout)
+	defer cancel()
+
+	out, err := lc.cmdContext(ctx).CombinedOutput()
+	if err == nil {
+		return diagnostics
+	}
+
+	text, ok := h.documents.text(uri)
+	if !ok {
+		b, _ := ioutil.ReadFile(path)
+		text = string(b)
+	}
+
+	return configDiagnostics(string(out), text, h.encoding)
+}
+
+// configDiagnostics positions the errors of the output of `config verify` in the configuration
+// text: schema errors at the key their JSON pointer names, syntax errors at their line, and
+// anything else at the top of the file.
+func configDiagnostics(out, text string, encoding positionEncoding) []Diagnostic {
+	var root yaml.Node
+	_ = yaml.Unmarshal([]byte(text), &root)
+	lines := strings.Split(text, "\n")
+
+	source := configVerifySource
+	diagnostics := make([]Diagnostic, 0)
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "Failed executing command") || strings.HasPrefix(line, "jsonschema validation failed") {
+			continue
+		}
+
+		// 1-based; 0 means unknown.
+		row, column := 0, 0
+		if m := configPointerRegexp.FindStringSubmatch(line); m != nil {
+			pointer := m[1]
+			if p := configPropertyRegexp.FindStringSubmatch(line); p != nil {
+				pointer = strings.TrimSuffix(pointer, "/") + "/" + strings.ReplaceAll(strings.ReplaceAll(p[1], "~", "~0"), "/", "~1")
+			}
+			if node := pointerNode(&root, pointer); node != nil {
+				row, column = node.Line, node.Column
+			}
+		} else if m := configLineRegexp.FindStringSubmatch(line); m != nil {
+			row, _ = strconv.Atoi(m[1])
+		}
+
+		r := Range{}
+		if row > 0 && row <= len(lines) {
+			lineText := strings.TrimRight(lines[row-1], "\r")
+			start := len(lineText) - len(strings.TrimLeft(lineText, " \t"))
+			if column > 0 && column-1 <= len(lineText) {
+				start = column - 1
+			}
+			r = Range{
+				Start: Position{Line: row - 1, Character: encoding.character(lineText, start)},
+				End:   Position{Line: row - 1, Character: encoding.character(lineText, len(lineText))},
+			}
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    r,
+			Severity: DSError,
+			Source:   &source,
+			Message:  strings.TrimPrefix(line, "- "),
+		})
+	}
+	if len(diagnostics) == 0 {
+		diagnostics = append(diagnostics, Diagnostic{Severity: DSError, Source: &source, Message: strings.TrimSpace(out)})
+	}
+
+	return diagnostics
+}
+
+// pointerNode returns the node of the deepest key or item of doc the JSON pointer names.
+func pointerNode(doc *yaml.Node, pointer string) *yaml.Node {
+	if len(doc.Content) == 0 {
+		return nil
+	}
+
+	node, found := doc.Content[0], doc.Content[0]
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if token == "" {
+			break
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == token {
+					found, next = node.Content[i], node.Content[i+1]
+
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(node.Content) {
+				found, next = node.Content[i], node.Content[i]
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+
+	return found
+}
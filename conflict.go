@@ -0,0 +1,30 @@
+package main
+
+import "strings"
+
+// conflictDiagnostics returns one Information diagnostic with message per region
+// delimited by git conflict markers, or nil when text has none.
+func conflictDiagnostics(text, message string, encoding positionEncoding) []Diagnostic {
+	var diagnostics []Diagnostic
+
+	start := -1
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "<<<<<<<"):
+			start = i
+		case strings.HasPrefix(line, ">>>>>>>") && start >= 0:
+			diagnostics = append(diagnostics, Diagnostic{
+				Range: Range{
+					Start: Position{Line: start},
+					End:   Position{Line: i, Character: encoding.character(line, len(line))},
+				},
+				Severity: DSInformation,
+				Message:  message,
+			})
+			start = -1
+		}
+	}
+
+	return diagnostics
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+const conflicted = "package test\n\n<<<<<<< HEAD\nfunc B() {}\n=======\nfunc B() int { return 0 }\n>>>>>>> topic\n"
+
+// TestConflictsOnDisk checks that a file the client hasn't opened is checked for merge
+// conflicts on disk instead of being linted.
+func TestConflictsOnDisk(t *testing.T) {
+	ts := newTestServer(t, testConfig{files: map[string]string{"b.go": conflicted}})
+
+	params := DidSaveTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: ts.uri("b.go")}}
+	if err := ts.client.Notify(context.Background(), "textDocument/didSave", params); err != nil {
+		t.Fatal(err)
+	}
+	diagnostics := ts.waitPublished("b.go")
+	if len(diagnostics) != 1 || diagnostics[0].Range.Start.Line != 2 || diagnostics[0].Range.End.Line != 6 {
+		t.Errorf("diagnostics %+v, want the conflict of lines 2 to 6", diagnostics)
+	}
+	if runs := ts.runner.Runs(); len(runs) != 0 {
+		t.Errorf("%d runs of a conflicted file, want none", len(runs))
+	}
+}
+
+// TestConflictsDidChange checks that the conflict check sees the text of didChange rather
+// than the text the document was opened with.
+func TestConflictsDidChange(t *testing.T) {
+	ts := newTestServer(t, testConfig{})
+	ts.open("a.go")
+	ts.waitPublished("a.go")
+
+	for version, tt := range []struct {
+		text      string
+		conflicts bool
+	}{
+		{text: conflicted, conflicts: true},
+		{text: "package test\n\nfunc B() {}\n", conflicts: false},
+	} {
+		params := DidChangeTextDocumentParams{
+			TextDocument:   VersionedTextDocumentIdentifier{URI: ts.uri("a.go"), Version: version + 2},
+			ContentChanges: []TextDocumentContentChangeEvent{{Text: tt.text}},
+		}
+		if err := ts.client.Notify(context.Background(), "textDocument/didChange", params); err != nil {
+			t.Fatal(err)
+		}
+
+		deadline := time.Now().Add(testTimeout)
+		for {
+			doc, _ := ts.h.documents.get(ts.uri("a.go"))
+			if doc.Version == version+2 {
+				if doc.Text != tt.text {
+					t.Fatalf("text %q after didChange, want %q", doc.Text, tt.text)
+				}
+
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("version %d after didChange, want %d", doc.Version, version+2)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := ts.h.publishConflicts(ts.uri("a.go")); got != tt.conflicts {
+			t.Errorf("publishConflicts() = %v after version %d, want %v", got, version+2, tt.conflicts)
+		}
+	}
+}
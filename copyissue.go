@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+const cmdCopyIssue = "golangci-lint.copyIssue"
+
+// CopyIssueArgs is the argument of the golangci-lint.copyIssue command.
+type CopyIssueArgs struct {
+	URI   DocumentURI `json:"uri"`
+	Range Range       `json:"range"`
+}
+
+// executeCopyIssue returns the issue at the start of the range formatted like
+// golangci-lint's line-number output, for the client to put on the clipboard.
+func (h *langHandler) executeCopyIssue(arguments []json.RawMessage) (string, error) {
+	var args CopyIssueArgs
+	if len(arguments) > 0 {
+		if err := json.Unmarshal(arguments[0], &args); err != nil {
+			return "", err
+		}
+	}
+
+	path := h.paths.uriToPath(string(args.URI))
+	issue, ok := h.issueAt(args.URI, args.Range.Start)
+	if !ok {
+		return "", &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: h.catalog().Sprintf(messages.IssueNotFound, path, args.Range.Start.Line+1)}
+	}
+
+	return formatIssueLine(h.root(), path, &issue), nil
+}
+
+// issueAt returns the cached issue on the line of pos, preferring the one starting at its column.
+func (h *langHandler) issueAt(uri DocumentURI, pos Position) (Issue, bool) {
+	var (
+		found Issue
+		ok    bool
+	)
+	for _, issue := range h.issues.get(uri) {
+		if max(issue.Pos.Line-1, 0) != pos.Line {
+			continue
+		}
+		if max(issue.Pos.Column-1, 0) == pos.Character {
+			return issue, true
+		}
+		if !ok {
+			found, ok = issue, true
+		}
+	}
+
+	return found, ok
+}
+
+// formatIssueLine formats issue as `path:line:col: text (linter)` with path relative to rootDir and slash-separated.
+func formatIssueLine(rootDir, path string, issue *Issue) string {
+	if rootDir != "" {
+		if rel, err := filepath.Rel(rootDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+
+	pos := fmt.Sprintf("%s:%d", filepath.ToSlash(path), issue.Pos.Line)
+	if issue.Pos.Column > 0 {
+		pos += fmt.Sprintf(":%d", issue.Pos.Column)
+	}
+
+	return fmt.Sprintf("%s: %s (%s)", pos, issue.Text, issue.FromLinter)
+}
+
+// copyIssueAction offers to copy the issue behind diagnostic.
+func (h *langHandler) copyIssueAction(uri DocumentURI, diagnostic Diagnostic) CodeAction {
+	title := h.catalog().Sprintf(messages.CopyIssue)
+
+	return CodeAction{
+		Title:   title,
+		Kind:    CAKQuickFix,
+		Command: &Command{Title: title, Command: h.commandID(cmdCopyIssue), Arguments: []interface{}{CopyIssueArgs{URI: uri, Range: diagnostic.Range}}},
+	}
+}
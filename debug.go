@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sync"
+	"time"
+)
+
+const recentLintsSize = 20
+
+// lintTiming records one golangci-lint run for the debug endpoint.
+type lintTiming struct {
+	Dir      string    `json:"dir"`
+	Args     []string  `json:"args"`
+	Profile  string    `json:"profile,omitempty"`
+	Start    time.Time `json:"start"`
+	Duration int64     `json:"durationMs"`
+	Issues   int       `json:"issues"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// recentLints keeps the last recentLintsSize runs.
+type recentLints struct {
+	mu    sync.Mutex
+	lints []lintTiming
+}
+
+func (r *recentLints) add(t lintTiming) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.lints) == recentLintsSize {
+		r.lints = r.lints[1:]
+	}
+	r.lints = append(r.lints, t)
+}
+
+func (r *recentLints) list() []lintTiming {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]lintTiming{}, r.lints...)
+}
+
+// debugState is served at /state.
+type debugState struct {
+	Configuration Configuration       `json:"configuration"`
+	Stats         Stats               `json:"stats"`
+	Documents     []DocumentURI       `json:"documents"`
+	Pending       []DocumentURI       `json:"pendingPublishes"`
+	Issues        map[DocumentURI]int `json:"issues"`
+	RecentLints   []lintTiming        `json:"recentLints"`
+	Messages      []messageSummary    `json:"recentMessages"`
+}
+
+func (h *langHandler) debugState() debugState {
+	return debugState{
+		Configuration: h.configuration(),
+		Stats:         h.stats.snapshot(),
+		Documents:     h.documents.uris(),
+		Pending:       h.publisher.queued(),
+		Issues:        h.issues.counts(),
+		RecentLints:   h.lints.list(),
+		Messages:      h.traffic.list(),
+	}
+}
+
+// serveDebug serves pprof, /state and /healthz on addr, which must be a loopback address,
+// and returns the address actually listened on.
+func serveDebug(addr string, h *langHandler) (string, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return "", fmt.Errorf("debug address %s is not a loopback address", addr)
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/state", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(h.debugState()); err != nil {
+			h.logger.Printf("golangci-lint-langserver: %s", err)
+		}
+	})
+
+	go func() {
+		if err := http.Serve(l, mux); err != nil {
+			h.logger.Printf("golangci-lint-langserver: debug server: %s", err)
+		}
+	}()
+
+	return l.Addr().String(), nil
+}
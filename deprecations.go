@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+var (
+	// logfmtMessage matches the message of a logrus warning, as printed by golangci-lint before v1.57:
+	// level=warning msg="[runner] The linter 'golint' is deprecated (since v1.41.0) ..."
+	logfmtMessage = regexp.MustCompile(`^level=warn(?:ing)?\s+msg=("(?:[^"\\]|\\.)*")`)
+	// warnPrefix matches the prefix of a warning of later versions: WARN [config_reader] ...
+	warnPrefix = regexp.MustCompile(`^WARN\s+`)
+)
+
+// deprecationWarnings returns the deprecation warnings in the output golangci-lint wrote
+// besides its JSON document, with the ANSI colors of terminals removed.
+func deprecationWarnings(output []byte) []string {
+	stripped, err := ioutil.ReadAll(lint.StripANSI(bytes.NewReader(output)))
+	if err != nil {
+		return nil
+	}
+
+	var warnings []string
+	for _, line := range strings.Split(string(stripped), "\n") {
+		line = strings.TrimSpace(line)
+
+		var message string
+		if m := logfmtMessage.FindStringSubmatch(line); m != nil {
+			message, err = strconv.Unquote(m[1])
+			if err != nil {
+				continue
+			}
+		} else if loc := warnPrefix.FindStringIndex(line); loc != nil {
+			message = line[loc[1]:]
+		}
+
+		if strings.Contains(strings.ToLower(message), "deprecated") {
+			warnings = append(warnings, message)
+		}
+	}
+
+	return warnings
+}
+
+// deprecations collects the deprecation warnings of the session, each once.
+type deprecations struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+	list []string
+}
+
+// add records warnings and returns those not seen before.
+func (d *deprecations) add(warnings []string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.seen == nil {
+		d.seen = make(map[string]struct{})
+	}
+
+	var added []string
+	for _, warning := range warnings {
+		if _, ok := d.seen[warning]; ok {
+			continue
+		}
+		d.seen[warning] = struct{}{}
+		d.list = append(d.list, warning)
+		added = append(added, warning)
+	}
+
+	return added
+}
+
+func (d *deprecations) all() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]string{}, d.list...)
+}
+
+// reportDeprecations shows the deprecation warnings in the output of a run that weren't
+// shown in this session yet, unless the silenceDeprecations option is set.
+func (h *langHandler) reportDeprecations(output ...[]byte) {
+	var warnings []string
+	for _, out := range output {
+		warnings = append(warnings, deprecationWarnings(out)...)
+	}
+
+	added := h.deprecations.add(warnings)
+	if len(added) == 0 {
+		return
+	}
+
+	h.logger.Printf("golangci-lint-langserver: golangci-lint warns: %s", strings.Join(added, "; "))
+	if h.currentOptions().SilenceDeprecations {
+		return
+	}
+	h.showMessage(MTWarning, h.catalog().Sprintf(messages.DeprecationWarnings, "\n- "+strings.Join(added, "\n- ")))
+}
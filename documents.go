@@ -0,0 +1,149 @@
+package main
+
+import (
+	"io/ioutil"
+	"sync"
+)
+
+type document struct {
+	Text    string
+	Version int
+	// Revision increases on every change of Text, also for events without a version such as didSave.
+	Revision int
+}
+
+// documentStore tracks the text of the documents the client has opened.
+type documentStore struct {
+	mu    sync.Mutex
+	docs  map[DocumentURI]*document
+	paths *pathMapper
+}
+
+func newDocumentStore(paths *pathMapper) *documentStore {
+	return &documentStore{
+		docs:  make(map[DocumentURI]*document),
+		paths: paths,
+	}
+}
+
+func (s *documentStore) open(uri DocumentURI, text string, version int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revision := 0
+	if doc, ok := s.docs[uri]; ok {
+		revision = doc.Revision + 1
+	}
+	s.docs[uri] = &document{Text: stripBOM(text), Version: version, Revision: revision}
+}
+
+func (s *documentStore) update(uri DocumentURI, text string) {
+	text = stripBOM(text)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if doc, ok := s.docs[uri]; ok {
+		if doc.Text != text {
+			doc.Text = text
+			doc.Revision++
+		}
+
+		return
+	}
+	s.docs[uri] = &document{Text: text}
+}
+
+// change replaces the tracked text of an open document with the unsaved contents of the editor.
+func (s *documentStore) change(uri DocumentURI, text string, version int) {
+	text = stripBOM(text)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.docs[uri]
+	if !ok {
+		return
+	}
+	doc.Version = version
+	if doc.Text != text {
+		doc.Text = text
+		doc.Revision++
+	}
+}
+
+// reload replaces the tracked text of an open document with the file on disk.
+func (s *documentStore) reload(uri DocumentURI) {
+	b, err := ioutil.ReadFile(s.paths.uriToPath(string(uri)))
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if doc, ok := s.docs[uri]; ok && doc.Text != stripBOM(string(b)) {
+		doc.Text = stripBOM(string(b))
+		doc.Revision++
+	}
+}
+
+func (s *documentStore) close(uri DocumentURI) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.docs, uri)
+}
+
+func (s *documentStore) get(uri DocumentURI) (document, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.docs[uri]
+	if !ok {
+		return document{}, false
+	}
+
+	return *doc, true
+}
+
+// uris returns the URIs of every open document.
+func (s *documentStore) uris() []DocumentURI {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uris := make([]DocumentURI, 0, len(s.docs))
+	for uri := range s.docs {
+		uris = append(uris, uri)
+	}
+
+	return uris
+}
+
+// revisions returns the current revision of every tracked document.
+func (s *documentStore) revisions() map[DocumentURI]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revisions := make(map[DocumentURI]int, len(s.docs))
+	for uri, doc := range s.docs {
+		revisions[uri] = doc.Revision
+	}
+
+	return revisions
+}
+
+// text returns the tracked text of uri, falling back to the file on disk, without a byte
+// order mark either way.
+func (s *documentStore) text(uri DocumentURI) (string, bool) {
+	if doc, ok := s.get(uri); ok {
+		return doc.Text, true
+	}
+
+	b, err := ioutil.ReadFile(s.paths.uriToPath(string(uri)))
+	if err != nil {
+		return "", false
+	}
+
+	return stripBOM(string(b)), true
+}
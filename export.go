@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+	"github.com/nametake/golangci-lint-langserver/sarif"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+const (
+	exportScopeFile      = "file"
+	exportScopeWorkspace = "workspace"
+)
+
+// ExportSarifParams are the parameters of the golangci-lint/exportSarif request.
+type ExportSarifParams struct {
+	// Scope is "workspace" (the default) or "file".
+	Scope string      `json:"scope,omitempty"`
+	URI   DocumentURI `json:"uri,omitempty"`
+	// Path makes the server write the log to this file, relative to the root, instead of returning it.
+	Path string `json:"path,omitempty"`
+}
+
+// handleExportSarif returns the issues behind the published diagnostics as a SARIF log in a
+// JSON string, or writes it to params.Path and returns null.
+func (h *langHandler) handleExportSarif(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params ExportSarifParams
+	if err := decodeCustomParams(req, &params); err != nil {
+		return nil, err
+	}
+
+	var uris []DocumentURI
+	switch params.Scope {
+	case "", exportScopeWorkspace:
+		for uri := range h.issues.counts() {
+			uris = append(uris, uri)
+		}
+		sort.Slice(uris, func(i, j int) bool { return uris[i] < uris[j] })
+	case exportScopeFile:
+		if params.URI == "" {
+			return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: h.catalog().Sprintf(messages.ExportNeedsURI)}
+		}
+		uris = []DocumentURI{params.URI}
+	default:
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: h.catalog().Sprintf(messages.InvalidExportScope, params.Scope)}
+	}
+
+	b, err := json.MarshalIndent(h.sarifLog(uris), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if params.Path == "" {
+		return string(b), nil
+	}
+
+	path := params.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(h.root(), path)
+	}
+
+	return nil, ioutil.WriteFile(path, b, 0o644)
+}
+
+// sarifLog converts the cached issues of uris.
+func (h *langHandler) sarifLog(uris []DocumentURI) *sarif.Log {
+	var findings []sarif.Finding
+	for _, uri := range uris {
+		location := string(uri)
+		if path := h.paths.uriToPath(string(uri)); h.root() != "" && isSubdir(h.root(), path) {
+			location = h.workspacePath(path)
+		}
+
+		for _, issue := range h.issues.get(uri) {
+			issue := issue
+
+			helpURI, _ := h.docsURL(RuleDocsArgs{Code: ruleID(&issue), Linter: issue.FromLinter})
+			findings = append(findings, sarif.Finding{
+				Linter:      issue.FromLinter,
+				HelpURI:     helpURI,
+				Level:       sarifLevel(h.issueSeverity(&issue)),
+				Message:     issue.Text,
+				URI:         location,
+				StartLine:   issue.Pos.Line,
+				StartColumn: issue.Pos.Column,
+				EndLine:     issue.LineRange.To,
+			})
+		}
+	}
+
+	h.mu.Lock()
+	version := ""
+	if h.features.Detected {
+		version = h.features.Version.String()
+	}
+	h.mu.Unlock()
+
+	return sarif.Build("golangci-lint", version, "https://golangci-lint.run", findings)
+}
+
+func sarifLevel(severity DiagnosticSeverity) string {
+	switch severity {
+	case DSError:
+		return sarif.LevelError
+	case DSWarning:
+		return sarif.LevelWarning
+	default:
+		return sarif.LevelNote
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+const (
+	featureJSONOutput     = "json-output"
+	featureConfigVerify   = "config-verify"
+	featureShowStats      = "show-stats"
+	featureIssuesExitCode = "issues-exit-code"
+	featurePathPrefix     = "path-prefix"
+)
+
+// Feature tells whether the detected golangci-lint supports something the server relies on.
+type Feature struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	// Requires is the version or flag the feature needs.
+	Requires string `json:"requires"`
+}
+
+// FeatureMatrix is the result of the golangci-lint/features request.
+type FeatureMatrix struct {
+	Detected bool      `json:"detected"`
+	Version  string    `json:"version,omitempty"`
+	Features []Feature `json:"features"`
+}
+
+// featureMatrix lists what the golangci-lint of f supports; nothing when it wasn't detected.
+func featureMatrix(f featureSet) FeatureMatrix {
+	m := FeatureMatrix{Detected: f.Detected}
+	if f.Detected {
+		m.Version = f.Version.String()
+	}
+
+	m.Features = []Feature{
+		{Name: featureJSONOutput, Available: f.OutFormat || f.OutputJSONPath, Requires: "--out-format or --output.json.path"},
+		{Name: featureConfigVerify, Available: configVerifySupported(f), Requires: "golangci-lint " + minConfigVerifyVersion.String()},
+		{Name: featureShowStats, Available: f.ShowStats, Requires: "--show-stats"},
+		{Name: featureIssuesExitCode, Available: f.IssuesExitCode, Requires: "--issues-exit-code"},
+		{Name: featurePathPrefix, Available: f.PathPrefix, Requires: "--path-prefix"},
+	}
+
+	return m
+}
+
+// summary describes the matrix in a few words, e.g. "golangci-lint 1.54.2: no config-verify".
+func (m FeatureMatrix) summary() string {
+	if !m.Detected {
+		return "golangci-lint not detected"
+	}
+
+	var missing []string
+	for _, feature := range m.Features {
+		if !feature.Available {
+			missing = append(missing, "no "+feature.Name)
+		}
+	}
+	if len(missing) == 0 {
+		return "golangci-lint " + m.Version
+	}
+
+	return "golangci-lint " + m.Version + ": " + strings.Join(missing, ", ")
+}
+
+func configVerifySupported(f featureSet) bool {
+	return f.Detected && !f.Version.Less(minConfigVerifyVersion)
+}
+
+// serverVersion returns the module version the server was built from.
+func serverVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" || info.Main.Version == "(devel)" {
+		return "devel"
+	}
+
+	return strings.TrimPrefix(info.Main.Version, "v")
+}
+
+// handleFeatures answers golangci-lint/features with the matrix of the global command.
+func (h *langHandler) handleFeatures(_ context.Context, _ *jsonrpc2.Conn, _ *jsonrpc2.Request) (result interface{}, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return featureMatrix(h.features), nil
+}
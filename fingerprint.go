@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	highlightNewOff      = "off"
+	highlightNewMessage  = "message"
+	highlightNewSeverity = "severity"
+)
+
+var highlightNewModes = []string{highlightNewOff, highlightNewMessage, highlightNewSeverity}
+
+// fingerprintContext is the number of lines on each side of an issue whose code goes into its fingerprint.
+const fingerprintContext = 1
+
+var (
+	digits     = regexp.MustCompile(`\d+`)
+	whitespace = regexp.MustCompile(`\s+`)
+)
+
+// fingerprint identifies issue across runs of a file whose lines are text. Unlike issueID it
+// leaves out the position, and the numbers of the message that often derive from it, and
+// hashes the code around the issue instead, so that it survives lines shifting above it.
+func fingerprint(issue *Issue, lines []string) string {
+	var code []string
+	for line := issue.Pos.Line - 1 - fingerprintContext; line <= issue.Pos.Line-1+fingerprintContext; line++ {
+		if line >= 0 && line < len(lines) {
+			code = append(code, strings.TrimSpace(lines[line]))
+		}
+	}
+	text := whitespace.ReplaceAllString(digits.ReplaceAllString(issue.Text, "0"), " ")
+
+	//nolint:gosec
+	sum := sha1.Sum([]byte(issue.FromLinter + "\x00" + text + "\x00" + strings.Join(code, "\n")))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// fingerprints holds the fingerprints of the issues of the last run of each open document.
+type fingerprints struct {
+	mu   sync.Mutex
+	runs map[DocumentURI]map[string]struct{}
+}
+
+// update replaces the fingerprints of uri and reports which of them are new. Nothing is new
+// in the first run of a document.
+func (f *fingerprints) update(uri DocumentURI, current []string) []bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.runs == nil {
+		f.runs = make(map[DocumentURI]map[string]struct{})
+	}
+
+	previous, seen := f.runs[uri]
+	isNew := make([]bool, len(current))
+	set := make(map[string]struct{}, len(current))
+	for i, fp := range current {
+		if _, ok := previous[fp]; seen && !ok {
+			isNew[i] = true
+		}
+		set[fp] = struct{}{}
+	}
+	f.runs[uri] = set
+
+	return isNew
+}
+
+func (f *fingerprints) forget(uri DocumentURI) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.runs, uri)
+}
+
+// highlightNew marks the diagnostics of the issues of an open document that its previous
+// run didn't report, according to the highlightNewIssues option. diagnostics and issues
+// are parallel.
+func (h *langHandler) highlightNew(uri DocumentURI, issues []Issue, diagnostics []Diagnostic) {
+	mode := h.currentOptions().HighlightNewIssues
+	if mode == highlightNewOff || len(diagnostics) != len(issues) {
+		return
+	}
+	text, ok := h.documents.text(uri)
+	if !ok {
+		return
+	}
+
+	lines := strings.Split(text, "\n")
+	current := make([]string, len(issues))
+	for i := range issues {
+		current[i] = fingerprint(&issues[i], lines)
+	}
+
+	for i, isNew := range h.fingerprints.update(uri, current) {
+		if !isNew {
+			continue
+		}
+
+		switch mode {
+		case highlightNewMessage:
+			diagnostics[i].Message += " [new]"
+		case highlightNewSeverity:
+			if diagnostics[i].Severity > DSError {
+				diagnostics[i].Severity--
+			}
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// FolderOptions override the global options for the documents of a folder.
+type FolderOptions struct {
+	Command    []string          `json:"command"`
+	ConfigPath string            `json:"configPath"`
+	Env        map[string]string `json:"env"`
+}
+
+// folderCommand is the resolved command of a folder override.
+type folderCommand struct {
+	Dir     string   `json:"dir"`
+	Command []string `json:"command"`
+	Env     []string `json:"env,omitempty"`
+}
+
+// resolveFolders resolves the folder overrides of opts. Keys are folder URIs, translated by
+// paths, or paths relative to each of the workspace folders. The deepest folders come first.
+//
+// With nested workspace folders, several keys may resolve to the same directory; an absolute key
+// wins over relative ones, a key relative to a deeper workspace folder over one relative to its
+// parent, and otherwise the first key in sorted order, so that the result doesn't depend on the
+// order of the options.
+func resolveFolders(opts Options, paths *pathMapper, workspaceDirs []string, detect func(bin string) featureSet) []folderCommand {
+	keys := make([]string, 0, len(opts.Folders))
+	for key := range opts.Folders {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// rank orders the keys resolving to the same directory.
+	rank := make(map[string]int)
+	byDir := make(map[string]folderCommand)
+	for _, key := range keys {
+		override := opts.Folders[key]
+
+		dirs := make(map[string]int)
+		switch {
+		case strings.HasPrefix(key, "file://"):
+			dirs[filepath.Clean(paths.uriToPath(key))] = math.MaxInt32
+		case filepath.IsAbs(key):
+			dirs[filepath.Clean(key)] = math.MaxInt32
+		default:
+			for _, root := range workspaceDirs {
+				dirs[filepath.Join(root, filepath.FromSlash(key))] = len(root)
+			}
+		}
+
+		command := opts.Command
+		if len(override.Command) > 0 {
+			command = override.Command
+		}
+		command = resolveCommand(command, opts, detect(command[0]))
+
+		for dir, r := range dirs {
+			if previous, ok := rank[dir]; ok && previous >= r {
+				continue
+			}
+			rank[dir] = r
+
+			folder := folderCommand{Dir: dir, Command: command}
+			if override.ConfigPath != "" {
+				folder.Command = withConfig(command, absFrom(folder.Dir, override.ConfigPath))
+			}
+			for name, value := range override.Env {
+				folder.Env = append(folder.Env, name+"="+value)
+			}
+			sort.Strings(folder.Env)
+
+			byDir[dir] = folder
+		}
+	}
+
+	folders := make([]folderCommand, 0, len(byDir))
+	for _, folder := range byDir {
+		folders = append(folders, folder)
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		if len(folders[i].Dir) != len(folders[j].Dir) {
+			return len(folders[i].Dir) > len(folders[j].Dir)
+		}
+
+		return folders[i].Dir < folders[j].Dir
+	})
+
+	return folders
+}
+
+// resolveCommand applies the options that turn into flags to command.
+func resolveCommand(command []string, opts Options, features featureSet) []string {
+	return withBuildTags(withConcurrency(normalizeCommand(command, features), opts.concurrency()), opts.BuildTags)
+}
+
+// withConfig replaces any configuration file of command with path.
+func withConfig(command []string, path string) []string {
+	args := removeFlag(removeFlag(removeFlag(command[1:], "-c"), "--config"), "--no-config")
+
+	return append(append([]string{command[0]}, args...), "--config="+path)
+}
+
+// commandFor returns the command and additional environment variables for linting path,
+// from the deepest folder override containing it or else the global options.
+func (h *langHandler) commandFor(path string) ([]string, []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, folder := range h.folders {
+		if isSubdir(folder.Dir, path) {
+			return folder.Command, folder.Env
+		}
+	}
+
+	return h.command, nil
+}
+
+// folderDir returns the directory of the deepest folder override containing path, or "".
+// Everything under it is linted with its command, including from runs started above it.
+func (h *langHandler) folderDir(path string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, folder := range h.folders {
+		if isSubdir(folder.Dir, path) {
+			return folder.Dir
+		}
+	}
+
+	return ""
+}
+
+// folderDirsUnder returns the folder override directories strictly inside dir.
+func (h *langHandler) folderDirsUnder(dir string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var dirs []string
+	for _, folder := range h.folders {
+		if folder.Dir != dir && isSubdir(dir, folder.Dir) {
+			dirs = append(dirs, folder.Dir)
+		}
+	}
+	sort.Strings(dirs)
+
+	return dirs
+}
+
+// folderLintCommand returns the command linting target in dir with the command of dir's folder.
+func (h *langHandler) folderLintCommand(dir, target string) lintCommand {
+	return h.lintCommandFor(dir, dir, target)
+}
+
+// lintCommandFor returns the command linting target in dir with the command of the folder of path.
+func (h *langHandler) lintCommandFor(path, dir, target string) lintCommand {
+	command, env := h.commandFor(path)
+	lc := newLintCommand(command, dir, target)
+	lc.Env = env
+
+	return lc
+}
+
+// workspaceDirs returns the root and the workspace folders.
+func (h *langHandler) workspaceDirs() []string {
+	dirs := make([]string, 0, len(h.workspaceFolders)+1)
+	if h.root() != "" {
+		dirs = append(dirs, h.root())
+	}
+	for _, folder := range h.workspaceFolders {
+		if dir := h.paths.uriToPath(folder.URI); dir != h.root() {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+func (h *langHandler) handleWorkspaceDidChangeWorkspaceFolders(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params DidChangeWorkspaceFoldersParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	folders := h.workspaceFolders[:0]
+	for _, folder := range h.workspaceFolders {
+		removed := false
+		for _, r := range params.Event.Removed {
+			removed = removed || r.URI == folder.URI
+		}
+		if !removed {
+			folders = append(folders, folder)
+		}
+	}
+	h.workspaceFolders = append(folders, params.Event.Added...)
+	h.fsWatcher.watch(h.workspaceDirs()...)
+
+	if err := h.applyOptions(h.currentOptions()); err != nil {
+		h.notifyError(err.Error())
+
+		return nil, nil
+	}
+	h.invalidate("workspace folders changed")
+
+	return nil, nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// noFeatures detects nothing, leaving the commands as configured.
+func noFeatures(string) featureSet {
+	return featureSet{}
+}
+
+func TestCommandForNestedFolders(t *testing.T) {
+	root := t.TempDir()
+	path := func(name string) string {
+		return filepath.Join(root, filepath.FromSlash(name))
+	}
+
+	opts := Options{
+		Command: []string{"golangci-lint", "run"},
+		Folders: map[string]FolderOptions{
+			"services":                           {Command: []string{"services-lint", "run"}},
+			"services/api":                       {Command: []string{"api-lint", "run"}, Env: map[string]string{"GOFLAGS": "-mod=vendor"}},
+			path("tools"):                        {Command: []string{"tools-lint"}, ConfigPath: "lint.yml"},
+			string(pathToURI(path("legacy/v1"))): {Command: []string{"legacy-lint"}},
+		},
+	}
+	h := newLangHandler(&testLogger{}, false)
+	h.command = opts.Command
+	h.folders = resolveFolders(opts, nil, []string{root}, noFeatures)
+
+	tests := []struct {
+		name    string
+		file    string
+		command []string
+		env     []string
+		dir     string
+	}{
+		{name: "outside the folders", file: "main.go", command: []string{"golangci-lint", "run"}},
+		{name: "relative key", file: "services/web/web.go", command: []string{"services-lint", "run"}, dir: "services"},
+		{name: "deepest relative key", file: "services/api/api.go", command: []string{"api-lint", "run"}, env: []string{"GOFLAGS=-mod=vendor"}, dir: "services/api"},
+		{name: "deeper than the deepest key", file: "services/api/v2/handler/h.go", command: []string{"api-lint", "run"}, env: []string{"GOFLAGS=-mod=vendor"}, dir: "services/api"},
+		{name: "sibling sharing a prefix", file: "services-old/old.go", command: []string{"golangci-lint", "run"}},
+		{name: "sibling sharing a nested prefix", file: "services/apis/apis.go", command: []string{"services-lint", "run"}, dir: "services"},
+		{name: "absolute key with config", file: "tools/gen/gen.go", command: []string{"tools-lint", "--config=" + path("tools/lint.yml")}, dir: "tools"},
+		{name: "URI key", file: "legacy/v1/v1.go", command: []string{"legacy-lint"}, dir: "legacy/v1"},
+		{name: "parent of a URI key", file: "legacy/legacy.go", command: []string{"golangci-lint", "run"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, env := h.commandFor(path(tt.file))
+			if !reflect.DeepEqual(command, tt.command) {
+				t.Errorf("command %q, want %q", command, tt.command)
+			}
+			if !reflect.DeepEqual(env, tt.env) {
+				t.Errorf("env %q, want %q", env, tt.env)
+			}
+
+			want := ""
+			if tt.dir != "" {
+				want = path(tt.dir)
+			}
+			if got := h.folderDir(path(tt.file)); got != want {
+				t.Errorf("folder %q, want %q", got, want)
+			}
+		})
+	}
+
+	if got, want := h.folderDirsUnder(path("services")), []string{path("services/api")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("folders under services %q, want %q", got, want)
+	}
+	if got, want := h.folderDirsUnder(root), []string{path("legacy/v1"), path("services"), path("services/api"), path("tools")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("folders under the root %q, want %q", got, want)
+	}
+}
+
+func TestResolveFoldersDeepestFirst(t *testing.T) {
+	root := t.TempDir()
+	opts := Options{
+		Command: []string{"golangci-lint", "run"},
+		Folders: map[string]FolderOptions{
+			"a":     {},
+			"a/b/c": {},
+			"a/b":   {},
+			"z":     {},
+		},
+	}
+
+	var dirs []string
+	for _, folder := range resolveFolders(opts, nil, []string{root}, noFeatures) {
+		dirs = append(dirs, folder.Dir)
+		if want := opts.Command; !reflect.DeepEqual(folder.Command, want) {
+			t.Errorf("%s: command %q, want the global %q", folder.Dir, folder.Command, want)
+		}
+	}
+	want := []string{
+		filepath.Join(root, "a", "b", "c"),
+		filepath.Join(root, "a", "b"),
+		filepath.Join(root, "a"),
+		filepath.Join(root, "z"),
+	}
+	if !reflect.DeepEqual(dirs, want) {
+		t.Errorf("folders %q, want %q", dirs, want)
+	}
+}
+
+func TestResolveFoldersNestedWorkspaceFolders(t *testing.T) {
+	root := t.TempDir()
+	services := filepath.Join(root, "services")
+	opts := Options{
+		Command: []string{"golangci-lint", "run"},
+		Folders: map[string]FolderOptions{
+			"api":                          {Command: []string{"api-lint"}},
+			"services/api":                 {Command: []string{"services-api-lint"}},
+			"tools":                        {Command: []string{"tools-lint"}},
+			"services/gen":                 {Command: []string{"gen-lint"}},
+			"gen":                          {Command: []string{"relative-gen-lint"}},
+			filepath.Join(services, "gen"): {Command: []string{"absolute-gen-lint"}},
+		},
+	}
+
+	want := map[string]string{
+		// Relative to both workspace folders.
+		filepath.Join(root, "api"):                         "api-lint",
+		filepath.Join(services, "api"):                     "api-lint",
+		filepath.Join(root, "tools"):                       "tools-lint",
+		filepath.Join(services, "tools"):                   "tools-lint",
+		filepath.Join(root, "gen"):                         "relative-gen-lint",
+		filepath.Join(services, "gen"):                     "absolute-gen-lint",
+		filepath.Join(root, "services", "services", "api"): "services-api-lint",
+		filepath.Join(services, "services", "gen"):         "gen-lint",
+	}
+	// The resolution doesn't depend on the order of the workspace folders.
+	for _, dirs := range [][]string{{root, services}, {services, root}} {
+		got := make(map[string]string)
+		for _, folder := range resolveFolders(opts, nil, dirs, noFeatures) {
+			got[folder.Dir] = folder.Command[0]
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("workspace folders %q: resolved %q, want %q", dirs, got, want)
+		}
+	}
+}
+
+// TestNestedWorkspaceFolders checks that a document of a workspace folder nested in another is
+// linted once, with the command of the override relative to the deepest workspace folder, and
+// that a workspace run publishes the diagnostics of that command only.
+func TestNestedWorkspaceFolders(t *testing.T) {
+	var ts *testServer
+	runner := &fakeRunner{output: func(cmd *exec.Cmd) string {
+		filename, err := filepath.Rel(cmd.Dir, ts.path("services/api/api.go"))
+		if err != nil || strings.HasPrefix(filename, "..") {
+			return `{"Issues":[]}`
+		}
+
+		var issue Issue
+		issue.FromLinter = "fake"
+		issue.Text = "issue of " + filepath.Base(cmd.Args[0])
+		issue.Pos.Filename = filename
+		issue.Pos.Line = 1
+		issue.Pos.Column = 1
+
+		return issuesOutput(t, issue)
+	}}
+	ts = newTestServer(t, testConfig{
+		options: map[string]interface{}{"folders": map[string]interface{}{
+			"api":          map[string]interface{}{"command": []string{"api-lint", "run"}},
+			"services/api": map[string]interface{}{"command": []string{"services-api-lint", "run"}},
+		}},
+		files:            map[string]string{"services/api/api.go": "package api\n"},
+		runner:           runner,
+		workspaceFolders: []string{"services"},
+	})
+
+	ts.open("services/api/api.go")
+	diagnostics := ts.waitPublished("services/api/api.go")
+	runs := runner.Runs()
+	if len(runs) != 1 {
+		t.Fatalf("%d runs for one document, want 1", len(runs))
+	}
+	if got := runs[0].Args[0]; got != "api-lint" {
+		t.Errorf("linted with %s, want the override relative to the services folder", got)
+	}
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, "issue of api-lint") {
+		t.Errorf("diagnostics %+v, want the issue of api-lint", diagnostics)
+	}
+
+	ts.mu.Lock()
+	delete(ts.diagnostics, ts.uri("services/api/api.go"))
+	ts.mu.Unlock()
+	if err := ts.call("workspace/executeCommand", ExecuteCommandParams{Command: cmdRunWorkspace}, nil); err != nil {
+		t.Fatal(err)
+	}
+	diagnostics = ts.waitPublished("services/api/api.go")
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, "issue of api-lint") {
+		t.Errorf("workspace diagnostics %+v, want the issue of api-lint only", diagnostics)
+	}
+
+	var commands []string
+	for _, run := range runner.Runs()[1:] {
+		commands = append(commands, run.Args[0]+" in "+run.Dir)
+	}
+	sort.Strings(commands)
+	want := []string{
+		"api-lint in " + ts.path("services/api"),
+		"golangci-lint in " + ts.root,
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("workspace runs %q, want %q", commands, want)
+	}
+}
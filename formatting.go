@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	defaultFormattingSeverity = "hint"
+	formattingSeverityOff     = "off"
+)
+
+// formattingLinters only report code that differs from what a formatter would produce.
+var formattingLinters = []string{"gci", "gofmt", "gofumpt", "goimports", "golines", "whitespace"}
+
+var severityNames = []string{"error", "warning", "info", "hint"}
+
+// parseSeverity parses one of severityNames.
+func parseSeverity(name string) (DiagnosticSeverity, bool) {
+	for i, n := range severityNames {
+		if n == name {
+			return DiagnosticSeverity(i + 1), true
+		}
+	}
+
+	return 0, false
+}
+
+// isFormatting reports whether linter is a built-in or configured formatting linter.
+func (o Options) isFormatting(linter string) bool {
+	return containsFold(formattingLinters, linter) || containsFold(o.FormattingLinters, linter)
+}
+
+// dropFormatting removes the issues of formatting linters when their severity is "off",
+// returning them separately.
+func (o Options) dropFormatting(issues []Issue) (kept, dropped []Issue) {
+	if o.FormattingSeverity != formattingSeverityOff {
+		return issues, nil
+	}
+
+	kept = issues[:0]
+	for _, issue := range issues {
+		if o.isFormatting(issue.FromLinter) {
+			dropped = append(dropped, issue)
+		} else {
+			kept = append(kept, issue)
+		}
+	}
+
+	return kept, dropped
+}
+
+// maxDiffCells bounds the work of diffing a replacement against the lines it replaces.
+const maxDiffCells = 4 << 20
+
+// hunk is a run of changed lines: old, starting at the zero-based line start, becomes new.
+type hunk struct {
+	start int
+	old   []string
+	new   []string
+}
+
+// splitFormatting replaces the diagnostic of each formatting issue whose replacement spans
+// several lines by one diagnostic per hunk differing from the document at uri, positioned at its first line
+// and showing the hunk as a unified diff. diagnostics are those of issues, in the same order.
+// Issues whose hunks can't be computed keep their diagnostic.
+func (h *langHandler) splitFormatting(uri DocumentURI, issues []Issue, diagnostics []Diagnostic) []Diagnostic {
+	if len(issues) != len(diagnostics) {
+		return diagnostics
+	}
+
+	opts := h.currentOptions()
+	var lines []string
+
+	split := make([]Diagnostic, 0, len(diagnostics))
+	for i := range issues {
+		issue := &issues[i]
+		if !opts.isFormatting(issue.FromLinter) || issue.Replacement == nil || issue.Replacement.Inline != nil {
+			split = append(split, diagnostics[i])
+
+			continue
+		}
+		if lines == nil {
+			text, ok := h.documents.text(uri)
+			if !ok {
+				b, err := ioutil.ReadFile(h.paths.uriToPath(string(uri)))
+				if err != nil {
+					return diagnostics
+				}
+				text = stripBOM(string(b))
+			}
+			lines = strings.Split(text, "\n")
+		}
+
+		hunks, ok := formattingHunks(issue, lines)
+		if !ok || len(hunks) == 0 {
+			split = append(split, diagnostics[i])
+
+			continue
+		}
+
+		for _, hk := range hunks {
+			d := diagnostics[i]
+			d.Range = Range{Start: Position{Line: hk.start}, End: Position{Line: hk.start}}
+			if len(hk.old) > 0 {
+				last := strings.TrimRight(hk.old[len(hk.old)-1], "\r")
+				d.Range.End = Position{Line: hk.start + len(hk.old) - 1, Character: h.encoding.character(last, len(last))}
+			}
+			d.Message += "\n" + hk.unified()
+			split = append(split, d)
+		}
+	}
+
+	return split
+}
+
+// formattingHunks diffs the lines the replacement of issue replaces in lines with its new lines.
+func formattingHunks(issue *Issue, lines []string) ([]hunk, bool) {
+	from, to := issue.LineRange.From, issue.LineRange.To
+	if from <= 0 {
+		from, to = issue.Pos.Line, issue.Pos.Line
+	}
+	if from <= 0 || to < from || to > len(lines) {
+		return nil, false
+	}
+
+	old := make([]string, 0, to-from+1)
+	for _, line := range lines[from-1 : to] {
+		old = append(old, strings.TrimRight(line, "\r"))
+	}
+	var new []string
+	if !issue.Replacement.NeedOnlyDelete {
+		for _, line := range issue.Replacement.NewLines {
+			new = append(new, strings.TrimRight(line, "\r"))
+		}
+	}
+	if (len(old)+1)*(len(new)+1) > maxDiffCells {
+		return nil, false
+	}
+
+	return diffLines(old, new, from-1), true
+}
+
+// diffLines returns the hunks turning old, starting at the zero-based line start, into new,
+// from a longest common subsequence of their lines.
+func diffLines(old, new []string, start int) []hunk {
+	// lcs[i][j] is the length of the longest common subsequence of old[i:] and new[j:].
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			switch {
+			case old[i] == new[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var (
+		hunks   []hunk
+		current *hunk
+	)
+	flush := func() {
+		if current != nil {
+			hunks = append(hunks, *current)
+			current = nil
+		}
+	}
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			flush()
+			i++
+			j++
+		case j < len(new) && (i == len(old) || lcs[i][j+1] >= lcs[i+1][j]):
+			if current == nil {
+				current = &hunk{start: start + i}
+			}
+			current.new = append(current.new, new[j])
+			j++
+		default:
+			if current == nil {
+				current = &hunk{start: start + i}
+			}
+			current.old = append(current.old, old[i])
+			i++
+		}
+	}
+	flush()
+
+	return hunks
+}
+
+// unified formats h like a hunk of a unified diff without context lines.
+func (h hunk) unified() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@", h.start+1, len(h.old), h.start+1, len(h.new))
+	for _, line := range h.old {
+		b.WriteString("\n-" + line)
+	}
+	for _, line := range h.new {
+		b.WriteString("\n+" + line)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"path/filepath"
+	"time"
+)
+
+const (
+	watcherClient   = "client"
+	watcherInternal = "internal"
+	watcherOff      = "off"
+)
+
+var watcherModes = []string{watcherClient, watcherInternal, watcherOff}
+
+// fsEventsDelay coalesces the events of one save, e.g. the write of a temporary file and its
+// rename over the original.
+const fsEventsDelay = 100 * time.Millisecond
+
+// isWatchedConfig reports whether path is a file whose change affects every lint result:
+// a golangci-lint configuration file, go.mod or go.work.
+func isWatchedConfig(path string) bool {
+	base := filepath.Base(path)
+
+	return isLintConfig(path) || base == goModFile || base == goWorkFile
+}
+
+// startFileWatcher starts the internal watcher when the watcher option asks for it, or when it
+// is "client" and the client can't register file watchers.
+func (h *langHandler) startFileWatcher() {
+	switch h.currentOptions().Watcher {
+	case watcherOff:
+		return
+	case watcherClient:
+		if h.clientCaps.Workspace.DidChangeWatchedFiles.DynamicRegistration {
+			return
+		}
+	}
+
+	if err := h.fsWatcher.start(h.paths, h.watchedChanges); err != nil {
+		h.logger.Printf("golangci-lint-langserver: can't watch files: %s", err)
+
+		return
+	}
+	h.logger.Printf("golangci-lint-langserver: watching configuration files with the internal watcher")
+	h.fsWatcher.watch(h.workspaceDirs()...)
+}
+
+// watchDirsOf watches the directories whose configuration applies to the file at path: its
+// own and those above it up to its workspace folder.
+func (h *langHandler) watchDirsOf(path string) {
+	dir := filepath.Dir(path)
+	dirs := []string{dir}
+	for _, root := range h.workspaceDirs() {
+		if !isSubdir(root, dir) {
+			continue
+		}
+		for d := dir; d != root && filepath.Dir(d) != d; {
+			d = filepath.Dir(d)
+			dirs = append(dirs, d)
+		}
+	}
+	h.fsWatcher.watch(dirs...)
+}
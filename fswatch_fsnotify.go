@@ -0,0 +1,124 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || windows
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris windows
+
+package main
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// fileWatcher watches directories for changes of the files isWatchedConfig accepts, for
+// clients that can't watch files themselves. Directories rather than files are watched, so
+// that saves replacing a file by renaming another over it aren't missed.
+type fileWatcher struct {
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+	dirs    map[string]struct{}
+	changes map[string]FileChangeType
+	timer   *time.Timer
+}
+
+// start begins watching, handing the changes to changed once no event arrived for fsEventsDelay.
+// The URIs of the changes are translated with paths.
+func (w *fileWatcher) start(paths *pathMapper, changed func([]FileEvent)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.watcher = watcher
+	w.dirs = make(map[string]struct{})
+	w.mu.Unlock()
+
+	go func() {
+		for event := range watcher.Events {
+			if isWatchedConfig(event.Name) {
+				w.record(event, paths, changed)
+			}
+		}
+	}()
+	// Errors are drained, since fsnotify blocks until they are received.
+	go func() {
+		for range watcher.Errors {
+		}
+	}()
+
+	return nil
+}
+
+func (w *fileWatcher) record(event fsnotify.Event, paths *pathMapper, changed func([]FileEvent)) {
+	typ := FCTChanged
+	switch {
+	case event.Op&fsnotify.Create != 0:
+		typ = FCTCreated
+	case event.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+		typ = FCTDeleted
+	case event.Op&fsnotify.Write == 0:
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.changes == nil {
+		w.changes = make(map[string]FileChangeType)
+	}
+	w.changes[event.Name] = typ
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(fsEventsDelay, func() {
+		w.mu.Lock()
+		changes := w.changes
+		w.changes = nil
+		w.mu.Unlock()
+
+		events := make([]FileEvent, 0, len(changes))
+		for path, typ := range changes {
+			// A file renamed away and replaced before the delay elapsed still exists.
+			if _, err := os.Stat(path); err == nil && typ == FCTDeleted {
+				typ = FCTChanged
+			}
+			events = append(events, FileEvent{URI: paths.pathToURI(path), Type: typ})
+		}
+		changed(events)
+	})
+}
+
+// watch adds dirs, ignoring those already watched or that can't be watched.
+func (w *fileWatcher) watch(dirs ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.watcher == nil {
+		return
+	}
+	for _, dir := range dirs {
+		if _, ok := w.dirs[dir]; ok {
+			continue
+		}
+		if err := w.watcher.Add(dir); err == nil {
+			w.dirs[dir] = struct{}{}
+		}
+	}
+}
+
+// close stops watching.
+func (w *fileWatcher) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	if w.watcher != nil {
+		_ = w.watcher.Close()
+		w.watcher = nil
+	}
+}
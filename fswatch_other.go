@@ -0,0 +1,18 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package main
+
+import "errors"
+
+// fileWatcher can't watch: fsnotify doesn't support the platform, so only clients watching
+// files themselves relint on configuration changes.
+type fileWatcher struct{}
+
+func (*fileWatcher) start(*pathMapper, func([]FileEvent)) error {
+	return errors.New("files can't be watched on this platform")
+}
+
+func (*fileWatcher) watch(...string) {}
+
+func (*fileWatcher) close() {}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// gitChanges are the Go files of a repository changed in the working tree, or since the
+// merge base with a revision.
+type gitChanges struct {
+	// Changed holds the absolute paths of the files that exist, Deleted those that don't.
+	Changed []string
+	Deleted []string
+}
+
+func runGit(dir string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+		}
+
+		return nil, fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+
+	return out, nil
+}
+
+// changedGoFiles lists the Go files of the repository holding dir that `git status` reports,
+// including untracked ones, and, when rev is set, those changed since the merge base of rev and HEAD.
+func changedGoFiles(dir, rev string) (gitChanges, error) {
+	out, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return gitChanges{}, err
+	}
+	top := strings.TrimSpace(string(out))
+
+	out, err = runGit(top, "status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return gitChanges{}, err
+	}
+	paths := parseStatus(out)
+
+	if rev != "" {
+		base, err := runGit(top, "merge-base", rev, "HEAD")
+		if err != nil {
+			return gitChanges{}, err
+		}
+		out, err := runGit(top, "diff", "--name-only", "-z", "--no-renames", strings.TrimSpace(string(base)), "--")
+		if err != nil {
+			return gitChanges{}, err
+		}
+		for _, path := range strings.Split(string(out), "\x00") {
+			if path != "" {
+				paths[path] = struct{}{}
+			}
+		}
+	}
+
+	var changes gitChanges
+	for path := range paths {
+		if !strings.HasSuffix(path, ".go") {
+			continue
+		}
+
+		path = filepath.Join(top, filepath.FromSlash(path))
+		if _, err := os.Stat(path); err == nil {
+			changes.Changed = append(changes.Changed, path)
+		} else {
+			changes.Deleted = append(changes.Deleted, path)
+		}
+	}
+	sort.Strings(changes.Changed)
+	sort.Strings(changes.Deleted)
+
+	return changes, nil
+}
+
+// parseStatus returns the paths of `git status --porcelain -z`, both sides of renames and copies included.
+func parseStatus(out []byte) map[string]struct{} {
+	paths := make(map[string]struct{})
+
+	entries := strings.Split(string(out), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+
+		paths[entry[3:]] = struct{}{}
+		if entry[0] == 'R' || entry[0] == 'C' {
+			// The original path follows as its own entry.
+			if i+1 < len(entries) {
+				i++
+				paths[entries[i]] = struct{}{}
+			}
+		}
+	}
+
+	return paths
+}
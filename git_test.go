@@ -0,0 +1,150 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// gitRepo creates a repository in a temporary directory and returns its path, with the
+// symbolic links of the temporary directory resolved as git reports it, and a function
+// running git in it.
+func gitRepo(t *testing.T) (string, func(args ...string)) {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip(err)
+	}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	git := func(args ...string) {
+		t.Helper()
+
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+
+	return dir, git
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, text := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestChangedGoFiles(t *testing.T) {
+	dir, git := gitRepo(t)
+	writeFiles(t, dir, map[string]string{
+		"go.mod":       "module example.com/repo\n",
+		"a.go":         "package repo\n",
+		"b.go":         "package repo\n",
+		"notes.txt":    "notes\n",
+		"sub/old.go":   "package sub\n",
+		"sub/stay.go":  "package sub\n",
+		"other/far.go": "package other\n",
+	})
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	git("branch", "base")
+
+	writeFiles(t, dir, map[string]string{"other/far.go": "package other // committed\n"})
+	git("commit", "-q", "-a", "-m", "change")
+
+	writeFiles(t, dir, map[string]string{
+		"a.go":         "package repo // changed\n",
+		"notes.txt":    "changed\n",
+		"untracked.go": "package repo\n",
+		"new/n.go":     "package new\n",
+		"readme.md":    "untracked\n",
+	})
+	if err := os.Remove(filepath.Join(dir, "b.go")); err != nil {
+		t.Fatal(err)
+	}
+	git("mv", "sub/old.go", "sub/new.go")
+
+	path := func(name string) string { return filepath.Join(dir, filepath.FromSlash(name)) }
+	tests := []struct {
+		name string
+		dir  string
+		rev  string
+		want gitChanges
+	}{
+		{
+			name: "working tree",
+			dir:  dir,
+			want: gitChanges{
+				Changed: []string{path("a.go"), path("new/n.go"), path("sub/new.go"), path("untracked.go")},
+				Deleted: []string{path("b.go"), path("sub/old.go")},
+			},
+		},
+		{
+			name: "from a subdirectory",
+			dir:  path("sub"),
+			want: gitChanges{
+				Changed: []string{path("a.go"), path("new/n.go"), path("sub/new.go"), path("untracked.go")},
+				Deleted: []string{path("b.go"), path("sub/old.go")},
+			},
+		},
+		{
+			name: "since a revision",
+			dir:  dir,
+			rev:  "base",
+			want: gitChanges{
+				Changed: []string{path("a.go"), path("new/n.go"), path("other/far.go"), path("sub/new.go"), path("untracked.go")},
+				Deleted: []string{path("b.go"), path("sub/old.go")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := changedGoFiles(tt.dir, tt.rev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changedGoFiles() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := changedGoFiles(dir, "nonexistent"); err == nil {
+		t.Error("no error for an unknown revision")
+	}
+	if _, err := changedGoFiles(t.TempDir(), ""); err == nil {
+		t.Error("no error outside a repository")
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	out := " M a.go\x00?? dir/new.go\x00R  renamed.go\x00original.go\x00D  gone.go\x00"
+	want := map[string]struct{}{
+		"a.go":        {},
+		"dir/new.go":  {},
+		"renamed.go":  {},
+		"original.go": {},
+		"gone.go":     {},
+	}
+	if got := parseStatus([]byte(out)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseStatus() = %v, want %v", got, want)
+	}
+}
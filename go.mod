@@ -1,5 +1,10 @@
 module github.com/nametake/golangci-lint-langserver
 
-go 1.13
+go 1.16
 
-require github.com/sourcegraph/jsonrpc2 v0.0.0-20191222043438-96c4efab7ee2
+require (
+	github.com/fsnotify/fsnotify v1.5.1
+	github.com/sourcegraph/jsonrpc2 v0.0.0-20191222043438-96c4efab7ee2
+	golang.org/x/sys v0.7.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1
+)
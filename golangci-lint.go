@@ -1,55 +1,50 @@
 package main
 
-import "strings"
-
-type Issue struct {
-	FromLinter  string      `json:"FromLinter"`
-	Text        string      `json:"Text"`
-	Severity    string      `json:"Severity"`
-	SourceLines []string    `json:"SourceLines"`
-	Replacement interface{} `json:"Replacement"`
-	Pos         struct {
-		Filename string `json:"Filename"`
-		Offset   int    `json:"Offset"`
-		Line     int    `json:"Line"`
-		Column   int    `json:"Column"`
-	} `json:"Pos"`
-	ExpectNoLint         bool   `json:"ExpectNoLint"`
-	ExpectedNoLintLinter string `json:"ExpectedNoLintLinter"`
-	LineRange            struct {
-		From int `json:"From"`
-		To   int `json:"To"`
-	} `json:"LineRange,omitempty"`
+import (
+	"strings"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
+)
+
+// The golangci-lint model is shared with package lint.
+type (
+	Issue       = lint.Issue
+	Replacement = lint.Replacement
+	InlineFix   = lint.InlineFix
+)
+
+// severities maps the severity strings found in golangci-lint output, including the
+// Code Climate (blocker, critical, major, minor, info) and SARIF (error, warning, note, none)
+// vocabularies used by severity rules.
+var severities = map[string]DiagnosticSeverity{
+	"err":         DSError,
+	"error":       DSError,
+	"blocker":     DSError,
+	"critical":    DSError,
+	"major":       DSError,
+	"warn":        DSWarning,
+	"warning":     DSWarning,
+	"minor":       DSWarning,
+	"info":        DSInformation,
+	"information": DSInformation,
+	"note":        DSInformation,
+	"hint":        DSHint,
+	"none":        DSHint,
 }
 
-func (i Issue) DiagSeverity() DiagnosticSeverity {
-	if i.Severity == "" {
-		// TODO: How to get default-severity from .golangci.yml, if available?
-		i.Severity = defaultSeverity
-	}
+// SeverityFromString maps a severity string case-insensitively.
+func SeverityFromString(s string) (DiagnosticSeverity, bool) {
+	severity, ok := severities[strings.ToLower(s)]
 
-	switch strings.ToLower(i.Severity) {
-	case "err", "error":
-		return DSError
-	case "warn", "warning":
-		return DSWarning
-	case "info", "information":
-		return DSInformation
-	case "hint":
-		return DSHint
-	default:
-		return DSWarning
-	}
+	return severity, ok
 }
 
-//nolint:unused,deadcode
-type GolangCILintResult struct {
-	Issues []Issue `json:"Issues"`
-	Report struct {
-		Linters []struct {
-			Name             string `json:"Name"`
-			Enabled          bool   `json:"Enabled"`
-			EnabledByDefault bool   `json:"EnabledByDefault,omitempty"`
-		} `json:"Linters"`
-	} `json:"Report"`
+// severityName returns the severity of issue, or the default one.
+func severityName(issue *Issue) string {
+	if issue.Severity == "" {
+		// TODO: How to get default-severity from .golangci.yml, if available?
+		return defaultSeverity
+	}
+
+	return issue.Severity
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// goEnv holds the variables of go env deciding between module and GOPATH mode.
+type goEnv struct {
+	GO111MODULE string
+	GOPATH      string
+	GOMODCACHE  string
+	GOROOT      string
+}
+
+// goEnvCache caches go env per set of additional environment variables, since folder
+// overrides may set GO111MODULE or GOPATH.
+type goEnvCache struct {
+	mu     sync.Mutex
+	probed map[string]goEnv
+}
+
+// get returns go env run with env in addition to the server's environment.
+func (c *goEnvCache) get(env []string) goEnv {
+	key := strings.Join(env, "\x00")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.probed[key]; ok {
+		return e
+	}
+	if c.probed == nil {
+		c.probed = make(map[string]goEnv)
+	}
+
+	e := probeGoEnv(env)
+	c.probed[key] = e
+
+	return e
+}
+
+// probeGoEnv runs go env, falling back to the environment when go is not available.
+func probeGoEnv(env []string) goEnv {
+	e := goEnv{
+		GO111MODULE: lookupEnv(env, "GO111MODULE"),
+		GOPATH:      lookupEnv(env, "GOPATH"),
+		GOMODCACHE:  lookupEnv(env, "GOMODCACHE"),
+		GOROOT:      lookupEnv(env, "GOROOT"),
+	}
+
+	cmd := exec.Command("go", "env", "GO111MODULE", "GOPATH", "GOMODCACHE", "GOROOT")
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.Output()
+	if err != nil {
+		return e
+	}
+
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	if len(lines) == 4 {
+		e.GO111MODULE, e.GOPATH = strings.TrimSpace(lines[0]), strings.TrimSpace(lines[1])
+		e.GOMODCACHE, e.GOROOT = strings.TrimSpace(lines[2]), strings.TrimSpace(lines[3])
+	}
+
+	return e
+}
+
+// lookupEnv returns the last value of name in env, or else in the server's environment.
+func lookupEnv(env []string, name string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if strings.HasPrefix(env[i], name+"=") {
+			return strings.TrimPrefix(env[i], name+"=")
+		}
+	}
+
+	return os.Getenv(name)
+}
+
+// gopathMode reports whether the file at path builds in GOPATH mode: GO111MODULE is off,
+// or the file is under GOPATH/src with no go.mod above it and GO111MODULE is not on.
+func (e goEnv) gopathMode(path string) bool {
+	switch e.GO111MODULE {
+	case "off":
+		return true
+	case "on":
+		return false
+	}
+
+	dir := filepath.Dir(path)
+	if findModuleRoot(dir) != "" {
+		return false
+	}
+	for _, gopath := range filepath.SplitList(e.GOPATH) {
+		if gopath != "" && isSubdir(filepath.Join(gopath, "src"), dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withGOPATHMode makes lc lint the package of the file at path from its directory with
+// GO111MODULE=off, since no module root applies in GOPATH mode.
+func withGOPATHMode(lc lintCommand, e goEnv, path string) lintCommand {
+	if !e.gopathMode(path) {
+		return lc
+	}
+
+	dir := filepath.Dir(path)
+	args := append([]string{}, lc.Args[:len(lc.Args)-1]...)
+	lc.Args = append(args, ".")
+	lc.Dir = dir
+	lc.Env = append(append([]string{}, lc.Env...), "GO111MODULE=off")
+	if e.GOPATH != "" && lookupEnv(lc.Env, "GOPATH") != e.GOPATH {
+		lc.Env = append(lc.Env, "GOPATH="+e.GOPATH)
+	}
+
+	return lc
+}
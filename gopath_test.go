@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGOPATHMode(t *testing.T) {
+	gopath := t.TempDir()
+	other := t.TempDir()
+	writeFiles(t, gopath, map[string]string{
+		"src/example.com/legacy/svc/main.go":    "package main\n",
+		"src/example.com/modern/go.mod":         "module example.com/modern\n",
+		"src/example.com/modern/pkg/pkg.go":     "package pkg\n",
+		"pkg/mod/example.com/dep@v1.0.0/dep.go": "package dep\n",
+	})
+	writeFiles(t, other, map[string]string{
+		"src/example.com/second/second.go": "package second\n",
+		"work/work.go":                     "package work\n",
+	})
+	path := func(dir, name string) string {
+		return filepath.Join(dir, filepath.FromSlash(name))
+	}
+	list := strings.Join([]string{gopath, other}, string(filepath.ListSeparator))
+
+	tests := []struct {
+		name string
+		env  goEnv
+		file string
+		want bool
+	}{
+		{name: "under GOPATH/src", env: goEnv{GOPATH: gopath}, file: path(gopath, "src/example.com/legacy/svc/main.go"), want: true},
+		{name: "module under GOPATH/src", env: goEnv{GOPATH: gopath}, file: path(gopath, "src/example.com/modern/pkg/pkg.go")},
+		{name: "outside GOPATH/src", env: goEnv{GOPATH: gopath}, file: path(gopath, "pkg/mod/example.com/dep@v1.0.0/dep.go")},
+		{name: "second GOPATH entry", env: goEnv{GOPATH: list}, file: path(other, "src/example.com/second/second.go"), want: true},
+		{name: "outside every GOPATH", env: goEnv{GOPATH: list}, file: path(other, "work/work.go")},
+		{name: "GO111MODULE=auto", env: goEnv{GOPATH: gopath, GO111MODULE: "auto"}, file: path(gopath, "src/example.com/legacy/svc/main.go"), want: true},
+		{name: "GO111MODULE=on", env: goEnv{GOPATH: gopath, GO111MODULE: "on"}, file: path(gopath, "src/example.com/legacy/svc/main.go")},
+		{name: "GO111MODULE=off", env: goEnv{GOPATH: gopath, GO111MODULE: "off"}, file: path(other, "work/work.go"), want: true},
+		{name: "GO111MODULE=off in a module", env: goEnv{GOPATH: gopath, GO111MODULE: "off"}, file: path(gopath, "src/example.com/modern/pkg/pkg.go"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.env.gopathMode(tt.file); got != tt.want {
+				t.Errorf("gopathMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithGOPATHMode(t *testing.T) {
+	gopath := t.TempDir()
+	writeFiles(t, gopath, map[string]string{
+		"src/example.com/legacy/svc/main.go": "package main\n",
+	})
+	file := filepath.Join(gopath, "src", "example.com", "legacy", "svc", "main.go")
+	dir := filepath.Dir(file)
+	command := []string{"golangci-lint", "run"}
+
+	tests := []struct {
+		name  string
+		env   []string
+		goEnv goEnv
+		want  lintCommand
+	}{
+		{
+			name:  "GOPATH from go env",
+			env:   []string{"GOGC=50"},
+			goEnv: goEnv{GOPATH: gopath},
+			want:  lintCommand{Args: []string{"golangci-lint", "run", "."}, Dir: dir, Env: []string{"GOGC=50", "GO111MODULE=off", "GOPATH=" + gopath}, File: file},
+		},
+		{
+			name:  "GOPATH already set",
+			env:   []string{"GOPATH=" + gopath},
+			goEnv: goEnv{GOPATH: gopath},
+			want:  lintCommand{Args: []string{"golangci-lint", "run", "."}, Dir: dir, Env: []string{"GOPATH=" + gopath, "GO111MODULE=off"}, File: file},
+		},
+		{
+			name:  "module mode",
+			goEnv: goEnv{GOPATH: gopath, GO111MODULE: "on"},
+			want:  lintCommand{Args: []string{"golangci-lint", "run", dir + string(filepath.Separator)}, Dir: dir + string(filepath.Separator), File: file},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := resolveFileCommand(command, "", nil, file)
+			lc.Env = tt.env
+			if got := withGOPATHMode(lc, tt.goEnv, file); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withGOPATHMode() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoEnvCache(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip(err)
+	}
+	gopath := t.TempDir()
+
+	var c goEnvCache
+	env := []string{"GO111MODULE=off", "GOPATH=" + gopath}
+	e := c.get(env)
+	if e.GO111MODULE != "off" || e.GOPATH != gopath {
+		t.Errorf("go env with %q = %+v", env, e)
+	}
+	if again := c.get(append([]string{}, env...)); again != e {
+		t.Errorf("second go env with %q = %+v, want %+v", env, again, e)
+	}
+	if len(c.probed) != 1 {
+		t.Errorf("go env run for %d sets of variables, want 1", len(c.probed))
+	}
+
+	if e := c.get([]string{"GO111MODULE=on", "GOPATH=" + gopath}); e.GO111MODULE != "on" {
+		t.Errorf("go env with GO111MODULE=on = %+v", e)
+	}
+	if len(c.probed) != 2 {
+		t.Errorf("go env run for %d sets of variables, want 2", len(c.probed))
+	}
+}
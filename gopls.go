@@ -0,0 +1,20 @@
+package main
+
+// dropGoplsDuplicates removes the issues of GoplsDuplicatedLinters when
+// SuppressLintersDuplicatedByGopls is on, returning them separately.
+func (o Options) dropGoplsDuplicates(issues []Issue) (kept, dropped []Issue) {
+	if !o.SuppressLintersDuplicatedByGopls {
+		return issues, nil
+	}
+
+	kept = issues[:0]
+	for _, issue := range issues {
+		if containsFold(o.GoplsDuplicatedLinters, issue.FromLinter) {
+			dropped = append(dropped, issue)
+		} else {
+			kept = append(kept, issue)
+		}
+	}
+
+	return kept, dropped
+}
@@ -3,111 +3,627 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
+	"github.com/nametake/golangci-lint-langserver/messages"
 )
 
 func NewHandler(logger logger, noLinterName bool) jsonrpc2.Handler {
+	return newHandler(newLangHandler(logger, noLinterName))
+}
+
+// newHandler starts the goroutines of handler and wraps it for jsonrpc2.
+func newHandler(handler *langHandler) jsonrpc2.Handler {
+	go handler.scheduler.Drain(context.Background())
+	go handler.publisher.run()
+
+	return &lifecycleHandler{
+		Handler: &pullHandler{Handler: jsonrpc2.HandlerWithError(recoverHandler(handler, handler.handle)), h: handler},
+		h:       handler,
+	}
+}
+
+func newLangHandler(logger logger, noLinterName bool) *langHandler {
+	paths := &pathMapper{}
 	handler := &langHandler{
 		logger:       logger,
-		request:      make(chan DocumentURI),
 		noLinterName: noLinterName,
+		paths:        paths,
+		documents:    newDocumentStore(paths),
+		issues:       newIssueCache(),
+		linted:       newLintedTexts(),
+		runner:       lint.ExecRunner{},
+		trust:        newTrustStore(),
+		hidden:       newHiddenIssues(paths),
+		msgs:         messages.New("", nil),
+		reports:      newReportStore(),
+		published:    make(map[string]map[DocumentURI]struct{}),
+
+		maxConcurrencyFlag: -1,
+	}
+	handler.scheduler = &switchScheduler{
+		fifo:         newFIFOScheduler(handler.lintRequest),
+		coalescing:   newCoalescingScheduler(handler.lintRequest, handler.lintRequests),
+		singleFlight: handler.singleFlight,
 	}
-	go handler.linter()
+	handler.processes = newProcessLimit(handler.maxProcesses, handler.singleFlight, func() { atomic.AddInt64(&handler.stats.preemptions, 1) })
+	handler.saves = &saveBatcher{
+		config: handler.saveBatchConfig,
+		single: func(uri DocumentURI) { handler.enqueue(uri, TriggerSave) },
+		flush:  func(uris []DocumentURI) { handler.lintBatch(uris, TriggerSave) },
+	}
+	handler.throttle = &tokenBucket{config: handler.watchedFilesThrottle}
+	handler.watched = &dirBatcher{
+		delay: handler.watchedFilesDelay,
+		flush: handler.lintChangedDirs,
+	}
+	handler.notifier = newNotifier(handler.messageRepeatWindow, handler.sendNotice)
+	handler.publisher = newPublisher(logger, handler.notifyDiagnostics)
 
-	return jsonrpc2.HandlerWithError(handler.handle)
+	return handler
 }
 
 type langHandler struct {
 	logger       logger
 	conn         *jsonrpc2.Conn
-	request      chan DocumentURI
-	command      []string
+	scheduler    Scheduler
+	pending      pendingLints
+	processes    *processLimit
+	gate         initGate
 	noLinterName bool
+	// paths translates between the URIs of the client and the paths golangci-lint sees.
+	paths *pathMapper
+	// redactSources keeps the source lines of issues out of the logs.
+	redactSources bool
+	documents     *documentStore
+	issues        *issueCache
+	linted        *lintedTexts
+	runner        lint.Runner
+	trust         *trustStore
+	hidden        *hiddenIssues
+	overrides     linterOverrides
+	snoozes       snoozes
+	passes        twoPasses
+	provisionals  provisionals
+	fingerprints  fingerprints
+	deprecations  deprecations
+	stats         stats
+	cacheSize     cacheSize
+	reports       *reportStore
+	goWork        goWork
+	goEnv         goEnvCache
+	saves         *saveBatcher
+	notifier      *notifier
+	watched       *dirBatcher
+	fsWatcher     fileWatcher
+	parsed        parsedFiles
+	instanceLocks instanceLocks
+	throttle      *tokenBucket
+	publisher     *publisher
+	lints         recentLints
+	broken        brokenDirs
+	traffic       traffic
+	warmup        warmup
+	debugAddr     string
+	clientCaps    ClientCapabilities
+	locale        string
 
-	rootURI string
+	// maxConcurrencyFlag is the value of -max-concurrency, used unless the options set
+	// maxConcurrency, or -1 to use the option.
+	maxConcurrencyFlag int
+
+	// metrics is the file of -metrics-file.
+	metrics metricsFile
+
+	// commandPrefix is the prefix of the command names announced at initialize.
+	commandPrefix string
+
+	// encoding is the position encoding negotiated at initialize.
+	encoding positionEncoding
+
+	// workspaceFolders are the folders of the workspace besides the root, kept up to date by the handler goroutine.
+	workspaceFolders []WorkspaceFolder
+
+	// clientOptions are the last options sent by the client, applied over the settings file.
+	clientOptions json.RawMessage
+
+	// unknownSeverities holds the severity strings already logged as unknown.
+	unknownSeverities sync.Map
+
+	// mu guards the configuration, which DidChangeConfiguration may replace while the linter runs.
+	mu       sync.Mutex
+	options  Options
+	command  []string
+	features featureSet
+	msgs     *messages.Catalog
+	// folders are the resolved folder overrides, deepest first.
+	folders []folderCommand
+	// rootDir is the directory of rootURI, translated with the path mappings of the options.
 	rootDir string
+
+	// binaryModTime is the modification time of the golangci-lint executable seen by the last run.
+	binaryModTime time.Time
+
+	// published holds the URIs per package directory that currently have diagnostics.
+	published   map[string]map[DocumentURI]struct{}
+	publishedMu sync.Mutex
+
+	rootURI string
 }
 
-func (h *langHandler) errToDiagnostics(err error) []Diagnostic {
+// errToDiagnostics turns a failed run into a diagnostic. With the run, the message also states
+// what ran, unless stderr holds positioned compiler errors that speak for themselves, and the
+// full details are attached to the data of the diagnostic.
+func (h *langHandler) errToDiagnostics(err error, run *runInfo) []Diagnostic {
 	var message string
 	switch e := err.(type) {
 	case *exec.ExitError:
+		if killed(e) {
+			message = h.catalog().Sprintf(messages.LintKilled)
+
+			break
+		}
 		message = string(e.Stderr)
 	default:
+		if errors.Is(e, lint.ErrOutputTooLarge) {
+			message = h.catalog().Sprintf(messages.OutputTooLarge, h.currentOptions().MaxOutputSize)
+
+			break
+		}
+
 		h.logger.DebugJSON("golangci-lint-langserver: errToDiagnostics message", message)
 		message = e.Error()
 	}
-	return []Diagnostic{
-		{Severity: DSError, Message: message},
+
+	d := Diagnostic{Severity: DSError, Message: message}
+	if run != nil {
+		details := newLintErrorDetails(err, message, run)
+		d.Data = &DiagnosticData{Error: details}
+		if !positionedErrorRegexp.MatchString(message) {
+			d.Message = details.summary()
+		}
 	}
+
+	return []Diagnostic{d}
 }
 
-func (h *langHandler) lint(uri DocumentURI) ([]Diagnostic, error) {
-	diagnostics := make([]Diagnostic, 0)
+// runLint runs the resolved golangci-lint invocation.
+// A nil result without error means golangci-lint succeeded without output.
+func (h *langHandler) runLint(lc lintCommand) (*lint.Result, *runInfo, error) {
+	h.mu.Lock()
+	features := h.features
+	h.mu.Unlock()
 
-	path := uriToPath(string(uri))
-	dir, file := filepath.Split(path)
+	lc.Env = append(lc.Env, h.currentOptions().env()...)
+	lc.Priority = h.currentOptions().ProcessPriority
+	if !lc.Fast {
+		// --enable can't be combined with the --enable-only of the fast pass.
+		lc = h.overrides.apply(lc)
+	}
+	lc, missing := expandPlaceholders(lc)
+	if missing != "" {
+		return nil, &runInfo{Dir: lc.Dir, Args: lc.Args, Time: time.Now()}, h.catalog().Errorf(messages.UnresolvedPlaceholder, missing)
+	}
 
-	args := make([]string, 0, len(h.command))
-	args = append(args, h.command[1:]...)
-	args = append(args, dir)
-	//nolint:gosec
-	cmd := exec.Command(h.command[0], args...)
-	if strings.HasPrefix(path, h.rootDir) {
-		cmd.Dir = h.rootDir
-		file = path[len(h.rootDir)+1:]
-	} else {
-		cmd.Dir = dir
+	cmd := lc.cmd()
+	// The environment of cmd may hold credentials, so only the invocation is logged.
+	h.logger.DebugJSON("golangci-lint-langserver: golangci-lint command:", struct {
+		Dir  string   `json:"dir"`
+		Args []string `json:"args"`
+	}{lc.Dir, lc.Args})
+
+	h.checkBinary(lc.Args[0])
+	if !lc.Background {
+		h.warmup.preempt()
+	}
+
+	run := &runInfo{
+		Dir:        lc.Dir,
+		Args:       lc.Args,
+		ConfigPath: findConfigPath(lc.Dir, lc.Args[1:]),
+		Time:       time.Now(),
+		Fast:       lc.Fast,
+	}
+	if features.Detected {
+		run.Version = features.Version.String()
+	}
+
+	if !h.trusted(lc.Args[0], lc.Env) {
+		return nil, run, h.trustError(trustSubject(lc.Args[0], lc.Env), false)
 	}
-	h.logger.DebugJSON("golangci-lint-langserver: golingci-lint cmd", cmd)
 
-	b, err := cmd.Output()
+	result, err := h.execLint(cmd, lc.Background)
+	if result != nil {
+		var excluded, disabled, snoozed, duplicated []Issue
+		result.Issues = h.mapPathPrefixes(lc.Args, result.Issues)
+		result.Issues = mapBOMIssues(lc.Dir, result.Issues)
+		result.Issues = h.mapCgoIssues(lc.Dir, result.Issues)
+		result.Issues, disabled = h.overrides.filter(result.Issues)
+		result.Issues, snoozed = h.filterSnoozed(lc.Dir, result.Issues)
+		result.Issues, excluded = h.applyPathRules(lc.Dir, result.Issues)
+		result.Issues, duplicated = h.currentOptions().dropGoplsDuplicates(result.Issues)
+		result.Issues, result.Hidden = h.currentOptions().dropFormatting(result.Issues)
+		if opts := h.currentOptions(); opts.MergeConsecutiveIssues {
+			result.Issues = mergeConsecutive(result.Issues, opts.MergeConsecutiveExclude)
+		}
+		result.Hidden = append(append(append(append(result.Hidden, excluded...), disabled...), snoozed...), duplicated...)
+	}
+
+	timing := lintTiming{Dir: lc.Dir, Args: lc.Args, Profile: lc.Profile, Start: run.Time, Duration: time.Since(run.Time).Milliseconds()}
+	if result != nil {
+		timing.Issues = len(result.Issues)
+	}
+	if err != nil {
+		timing.Error = err.Error()
+	}
+	h.lints.add(timing)
+	h.countLint()
 	if err == nil {
+		h.logger.Printf("golangci-lint-langserver: linted %s in %dms: %d issues", lc.Dir, timing.Duration, timing.Issues)
+	}
+	h.reportWarmRun(time.Since(run.Time))
+
+	return result, run, err
+}
+
+// execLint runs cmd; background runs give way to the others, see processLimit.
+func (h *langHandler) execLint(cmd *exec.Cmd, background bool) (*lint.Result, error) {
+	h.processes.acquire(background)
+	defer h.processes.release(background)
+	unlock := h.lockModule(cmd.Dir)
+	defer unlock()
+
+	opts := lint.Options{MaxOutputSize: int64(h.currentOptions().MaxOutputSize)}
+	if background {
+		opts.Started = h.processes.track
+	}
+	result, out, err := lint.Run(h.runner, cmd, opts)
+	if background && h.processes.untrack(cmd) {
+		return nil, errPreempted
+	}
+	if preamble := strings.TrimSpace(out.Preamble); preamble != "" {
+		h.logger.Printf("golangci-lint-langserver: warn: ignoring output before the JSON document: %q", preamble)
+	}
+	h.reportDeprecations(out.Stderr, []byte(out.Preamble))
+	if err != nil {
+		return nil, err
+	}
+
+	h.reportPanics(result.Panics)
+
+	for _, err := range result.Skipped {
+		atomic.AddInt64(&h.stats.skippedIssues, 1)
+		h.logger.Printf("golangci-lint-langserver: warn: skipping issue: %s", err)
+	}
+
+	if h.redactSources {
+		h.logger.DebugJSON("golangci-lint-langserver: result:", result.WithoutSources())
+	} else {
+		h.logger.DebugJSON("golangci-lint-langserver: result:", result)
+	}
+
+	return result, nil
+}
+
+func (h *langHandler) lint(uri DocumentURI) (map[DocumentURI][]Diagnostic, error) {
+	return h.lintPass(context.Background(), uri, false)
+}
+
+// lintPass lints the package of uri, killing golangci-lint when ctx is done. The fast pass
+// of the twoPass option runs only the fast linters and doesn't count as a lint of the text.
+func (h *langHandler) lintPass(ctx context.Context, uri DocumentURI, fast bool) (map[DocumentURI][]Diagnostic, error) {
+	diagnostics := map[DocumentURI][]Diagnostic{uri: make([]Diagnostic, 0)}
+
+	path := h.paths.uriToPath(string(uri))
+	if isLintConfig(path) {
+		diagnostics[uri] = h.verifyConfig(uri)
+
 		return diagnostics, nil
-	} else if len(b) == 0 {
-		// golangci-lint would output critical error to stderr rather than stdout
-		// https://github.com/nametake/golangci-lint-langserver/issues/24
-		return h.errToDiagnostics(err), nil
 	}
+	if !h.included(filepath.Dir(path)) || h.readOnlyPath(path) {
+		h.issues.replace(uri, nil, nil)
 
-	var result GolangCILintResult
-	if err := json.Unmarshal(b, &result); err != nil {
-		return h.errToDiagnostics(err), nil
+		return diagnostics, nil
+	}
+
+	command, env := h.commandFor(path)
+	text, hasText := h.documents.text(uri)
+	if hasText {
+		tags, expr, ok := fileBuildTags(text, command)
+		if !ok || (len(tags) > 0 && h.currentOptions().BuildTagsMode == buildTagsModeSkip) {
+			diagnostics[uri] = []Diagnostic{{Severity: DSHint, Message: h.catalog().Sprintf(messages.BuildTagsSkipped, expr)}}
+			h.issues.replace(uri, nil, nil)
+
+			return diagnostics, nil
+		}
+		command = withBuildTags(command, tags)
+	}
+
+	lc := resolveFileCommand(command, h.root(), h.workModules(), path)
+	lc.Env = env
+	lc = withGOPATHMode(lc, h.goEnv.get(env), path)
+	lc.ctx = ctx
+	if fast {
+		lc = h.withFastLinters(lc)
 	}
+	cmdDir := lc.Dir
+
+	if isTestFile(path) && testsExcluded(lc.Dir, lc.Args) {
+		diagnostics[uri] = []Diagnostic{{Severity: DSHint, Message: h.catalog().Sprintf(messages.TestsExcluded)}}
+		h.issues.replace(uri, nil, nil)
 
-	h.logger.DebugJSON("golangci-lint-langserver: result:", result)
+		return diagnostics, nil
+	}
 
-	for _, issue := range result.Issues {
+	result, run, err := h.runProfiles(lc)
+	if noGoFiles(result, err) {
+		// The directory may only hold subpackages, or files excluded by build constraints.
+		h.logger.Printf("golangci-lint-langserver: no Go files to analyze in %s, linting its subpackages", filepath.Dir(path))
+		result, run, err = h.runProfiles(withSubpackages(lc))
+		if noGoFiles(result, err) {
+			result, err = nil, nil
+		}
+	}
+	if ctx.Err() != nil {
+		return diagnostics, ctx.Err()
+	}
+	if err != nil {
+		h.notifyLintError(err)
+	} else {
+		h.notifier.reset()
+	}
+	h.broken.set(filepath.Dir(path), result == nil && err != nil)
+	if result == nil && err != nil {
+		diagnostics[uri] = h.errToDiagnostics(err, run)
+		h.issues.replace(uri, nil, run)
+		h.linted.forget(uri)
+
+		return diagnostics, nil
+	}
+
+	diagnostics = h.packageDiagnostics(uri, cmdDir, result, run)
+	if hasText && !fast {
+		h.linted.record(uri, text, diagnostics[uri])
+	}
+
+	return diagnostics, nil
+}
+
+// packageDiagnostics picks the issues of the package of uri out of the result of a run from cmdDir.
+func (h *langHandler) packageDiagnostics(uri DocumentURI, cmdDir string, result *lint.Result, run *runInfo) map[DocumentURI][]Diagnostic {
+	diagnostics := map[DocumentURI][]Diagnostic{uri: make([]Diagnostic, 0)}
+	if result == nil {
+		result = &lint.Result{}
+	}
+
+	path := h.paths.uriToPath(string(uri))
+	dir, _ := filepath.Split(path)
+
+	// Module linters like gomoddirectives report issues in the go.mod of the module.
+	goMod := ""
+	if root := findModuleRoot(dir); root != "" {
+		goMod = filepath.Join(root, goModFile)
+	}
+
+	resolved := newSymlinkResolver()
+	casing := newCasingResolver()
+
+	issues := make(map[DocumentURI][]Issue)
+	hidden := make(map[DocumentURI]int)
+	for i, issue := range append(append([]Issue{}, result.Issues...), result.Hidden...) {
 		issue := issue
 
-		if file != issue.Pos.Filename {
+		issuePath := issueFilePath(cmdDir, &issue)
+		if issueDir := filepath.Dir(issuePath); !samePath(issueDir, dir) && samePath(resolved.path(issueDir), resolved.path(dir)) {
+			// golangci-lint reported the package through another path to it, e.g. /private/var for /var on macOS.
+			issuePath = filepath.Join(dir, filepath.Base(issuePath))
+		} else if goMod != "" && filepath.Base(issuePath) == goModFile && !samePath(issueDir, filepath.Dir(goMod)) &&
+			samePath(resolved.path(issueDir), resolved.path(filepath.Dir(goMod))) {
+			issuePath = goMod
+		}
+
+		target := uri
+		if !samePath(issuePath, path) {
+			// Issues of sibling files in the same package are published too; issues of
+			// other directories, which some versions report when linting from the root, are not.
+			if !samePath(filepath.Dir(issuePath), dir) && !samePath(issuePath, goMod) {
+				continue
+			}
+			target = h.paths.pathToURI(casing.path(issuePath))
+		}
+		if i >= len(result.Issues) {
+			hidden[target]++
+
 			continue
 		}
 
-		d := Diagnostic{
-			Range: Range{
-				Start: Position{
-					Line:      max(issue.Pos.Line-1, 0),
-					Character: max(issue.Pos.Column-1, 0),
-				},
-				End: Position{
-					Line:      max(issue.Pos.Line-1, 0),
-					Character: max(issue.Pos.Column-1, 0),
-				},
-			},
-			Severity: issue.DiagSeverity(),
-			Source:   &issue.FromLinter,
-			Message:  h.diagnosticMessage(&issue),
+		diagnostics[target] = append(diagnostics[target], h.fileDiagnostic(target, issuePath, &issue))
+		issues[target] = append(issues[target], issue)
+	}
+
+	if len(result.Panics) > 0 {
+		// Siblings whose only issues came from a panicked linter would be cleared otherwise.
+		for sibling := range h.issues.counts() {
+			if _, ok := diagnostics[sibling]; !ok && samePath(filepath.Dir(h.paths.uriToPath(string(sibling))), dir) {
+				diagnostics[sibling] = make([]Diagnostic, 0)
+			}
+		}
+	}
+	for target := range diagnostics {
+		for _, issue := range h.keepPanicked(target, result.Panics) {
+			issue := issue
+			diagnostics[target] = append(diagnostics[target], h.fileDiagnostic(target, h.paths.uriToPath(string(target)), &issue))
+			issues[target] = append(issues[target], issue)
 		}
-		diagnostics = append(diagnostics, d)
+		h.issues.replace(target, issues[target], run)
+		if !run.Fast {
+			// The issues of the other linters would all look new.
+			h.highlightNew(target, issues[target], diagnostics[target])
+		}
+		diagnostics[target] = h.splitFormatting(target, issues[target], diagnostics[target])
+		h.addRunFooter(diagnostics[target], run)
 	}
+	h.updateHidden(filepath.Clean(dir), hidden)
 
-	return diagnostics, nil
+	return diagnostics
+}
+
+// addRunFooter states the run directory in the first diagnostic when the showRunInfo option is on.
+func (h *langHandler) addRunFooter(diagnostics []Diagnostic, run *runInfo) {
+	if len(diagnostics) == 0 || !h.currentOptions().ShowRunInfo {
+		return
+	}
+
+	diagnostics[0].Message += fmt.Sprintf("\n(golangci-lint ran in %s)", run.Dir)
+}
+
+// fileDiagnostic converts an issue of the file at path published as uri.
+func (h *langHandler) fileDiagnostic(uri DocumentURI, path string, issue *Issue) Diagnostic {
+	d := h.issueToDiagnostic(issue)
+	if unplacedIssue(issue) {
+		d.Range = Range{End: Position{Character: h.lineLength(uri, 0, issue)}}
+	} else if isGoMod(path) && issue.Pos.Column == 0 {
+		// Module linters often report no column; underline the whole line instead.
+		d.Range.End.Character = h.lineLength(uri, d.Range.Start.Line, issue)
+	} else if d.Range.Start == d.Range.End {
+		if end, ok := h.nodeEnd(uri, issue); ok {
+			d.Range.End = end
+		}
+	}
+	d.Range = h.encoding.clampRange(d.Range, h.documentLines(uri))
+
+	return d
+}
+
+// lineLength returns the length of the given zero-based line of uri.
+func (h *langHandler) lineLength(uri DocumentURI, line int, issue *Issue) int {
+	if text, ok := h.documents.text(uri); ok {
+		lines := strings.Split(text, "\n")
+		if line < len(lines) {
+			text := strings.TrimRight(lines[line], "\r")
+
+			return h.encoding.character(text, len(text))
+		}
+	}
+
+	text := issueLine(issue)
+
+	return h.encoding.character(text, len(text))
+}
+
+// issueFilePath returns the absolute path of the file an issue of a run from dir points at.
+func issueFilePath(dir string, issue *Issue) string {
+	if filepath.IsAbs(issue.Pos.Filename) {
+		return issue.Pos.Filename
+	}
+
+	return filepath.Join(dir, issue.Pos.Filename)
+}
+
+func (h *langHandler) issueToDiagnostic(issue *Issue) Diagnostic {
+	severity := h.issueSeverity(issue)
+
+	opts := h.currentOptions()
+
+	var tags []DiagnosticTag
+	if opts.isFormatting(issue.FromLinter) {
+		// Formatting findings are a hint to run the formatter rather than a problem.
+		severity, _ = parseSeverity(opts.FormattingSeverity)
+		tags = []DiagnosticTag{DTUnnecessary}
+	}
+
+	code := ruleID(issue)
+	if code == "" && opts.SourceStyle == sourceStyleGolangciLint {
+		// The source no longer names the linter.
+		code = issue.FromLinter
+	}
+	custom, isCustom := opts.customLinter(issue.FromLinter)
+	if tag, ok := diagnosticTags[custom.Tag]; isCustom && ok {
+		tags = append(tags, tag)
+	}
+	if isCustom && custom.DocsURL != "" && code == "" {
+		code = issue.FromLinter
+	}
+
+	var description *CodeDescription
+	if code != "" {
+		if url, ok := h.docsURL(RuleDocsArgs{Code: code, Linter: issue.FromLinter}); ok {
+			description = &CodeDescription{Href: url}
+		}
+	}
+
+	style := opts.LargeRangeStyle
+	if issue.Merged > 0 {
+		// A merged issue spans all of its lines, however many.
+		style = largeRangeStyleFull
+	}
+
+	return Diagnostic{
+		Range:           issueRange(issue, h.encoding, style),
+		Severity:        severity,
+		Code:            formatCode(code),
+		CodeDescription: description,
+		Source:          issueSource(issue, opts.SourceStyle),
+		Message:         h.diagnosticMessage(issue),
+		Tags:            tags,
+		Data:            &DiagnosticData{IssueID: issueID(issue), Linter: issue.FromLinter},
+	}
+}
+
+const (
+	sourceStyleLinter       = "linter"
+	sourceStyleGolangciLint = "golangci-lint"
+	sourceStyleBoth         = "both"
+)
+
+var sourceStyles = []string{sourceStyleLinter, sourceStyleGolangciLint, sourceStyleBoth}
+
+// issueSource names the linter of issue in the given style, and its profile if any.
+func issueSource(issue *Issue, style string) *string {
+	var source string
+	switch style {
+	case sourceStyleGolangciLint:
+		source = "golangci-lint"
+	case sourceStyleBoth:
+		source = "golangci-lint(" + issue.FromLinter + ")"
+	default:
+		source = issue.FromLinter
+	}
+	if issue.Profile != "" {
+		source += " [" + issue.Profile + "]"
+	}
+
+	return &source
+}
+
+// diagnosticLinter returns the linter of d whatever the source style, or "" if d doesn't name it.
+func diagnosticLinter(d *Diagnostic) string {
+	if d.Data != nil && d.Data.Linter != "" {
+		return d.Data.Linter
+	}
+	if d.Source == nil {
+		return ""
+	}
+
+	source := strings.SplitN(*d.Source, " [", 2)[0]
+	switch {
+	case source == "golangci-lint":
+		return ""
+	case strings.HasPrefix(source, "golangci-lint(") && strings.HasSuffix(source, ")"):
+		return strings.TrimSuffix(strings.TrimPrefix(source, "golangci-lint("), ")")
+	}
+
+	return source
 }
 
 func max(a, b int) int {
@@ -118,37 +634,162 @@ func max(a, b int) int {
 }
 
 func (h *langHandler) diagnosticMessage(issue *Issue) string {
-	if h.noLinterName {
-		return issue.Text
+	message := issue.Text
+	if !h.noLinterName {
+		message = fmt.Sprintf("%s: %s", issue.FromLinter, issue.Text)
+	}
+	if issue.Merged > 0 {
+		message += " " + h.catalog().Sprintf(messages.MergedLines, issue.Merged)
+	}
+
+	if h.currentOptions().ShowSourceLine && len(issue.SourceLines) > 0 {
+		message += "\n" + sourceSnippet(issue.SourceLines[0], issue.Pos.Column)
 	}
 
-	return fmt.Sprintf("%s: %s", issue.FromLinter, issue.Text)
+	return message
 }
 
-func (h *langHandler) linter() {
-	for {
-		uri, ok := <-h.request
-		if !ok {
-			break
+func (h *langHandler) lintRequest(req Request) {
+	h.pending.done(req.URI, req.Trigger)
+	h.logger.DebugJSON("golangci-lint-langserver: lint request:", req)
+
+	if bin, env, ok := h.undecided(h.paths.uriToPath(string(req.URI))); ok {
+		h.park(bin, env, req)
+
+		return
+	}
+	if err := h.authorizeFor(h.paths.uriToPath(string(req.URI))); err != nil {
+		h.endProvisional(filepath.Dir(h.paths.uriToPath(string(req.URI))), nil)
+		h.notifyError(err.Error())
+
+		return
+	}
+
+	switch {
+	case req.Trigger == TriggerFullPass:
+		h.fullPass(req)
+	case h.twoPass(req.URI):
+		h.fastPass(req)
+	default:
+		h.lintAndPublish(req.URI)
+	}
+}
+
+// lintRequests lints the documents requested while a lint ran in single-flight mode
+// with one run per module covering their packages.
+func (h *langHandler) lintRequests(reqs []Request) {
+	var uris []DocumentURI
+	for _, req := range reqs {
+		if isLintConfig(h.paths.uriToPath(string(req.URI))) {
+			h.lintRequest(req)
+
+			continue
 		}
+		if bin, env, ok := h.undecided(h.paths.uriToPath(string(req.URI))); ok {
+			h.pending.done(req.URI, req.Trigger)
+			h.park(bin, env, req)
 
-		diagnostics, err := h.lint(uri)
-		if err != nil {
-			h.logger.Printf("%s", err)
+			continue
+		}
+		h.pending.done(req.URI, req.Trigger)
+		uris = append(uris, req.URI)
+	}
+	if len(uris) == 0 {
+		return
+	}
+
+	h.logger.Printf("golangci-lint-langserver: single-flight: linting %d documents requested during the last run together", len(uris))
+	h.lintTogether(uris, true)
+}
+
+// maxConcurrency returns the number of golangci-lint processes that may run at once,
+// 0 meaning one with the requests made meanwhile coalesced.
+func (h *langHandler) maxConcurrency() int {
+	opts := h.currentOptions()
+	if h.maxConcurrencyFlag >= 0 && !opts.isSet("maxConcurrency") {
+		return h.maxConcurrencyFlag
+	}
+
+	return opts.MaxConcurrency
+}
+
+func (h *langHandler) singleFlight() bool {
+	return h.maxConcurrency() == 0
+}
+
+func (h *langHandler) maxProcesses() int {
+	return max(h.maxConcurrency(), 1)
+}
+
+func (h *langHandler) lintAndPublish(uri DocumentURI) {
+	defer h.recoverPanic("lint " + string(uri))
+
+	diagnostics, err := h.lint(uri)
+	h.publishLint(uri, diagnostics, err)
+}
+
+// publishLint publishes the result of a lint of uri.
+func (h *langHandler) publishLint(uri DocumentURI, diagnostics map[DocumentURI][]Diagnostic, err error) {
+	if isLintConfig(h.paths.uriToPath(string(uri))) {
+		// Not a package: publishPackage would clear the Go files next to it.
+		h.publishDiagnostics(uri, diagnostics[uri])
+
+		return
+	}
+	h.endProvisional(filepath.Dir(h.paths.uriToPath(string(uri))), diagnostics)
+	if err != nil {
+		h.logger.Printf("%s", err)
+
+		return
+	}
+
+	h.publishPackage(uri, diagnostics)
+}
+
+// publishPackage publishes the diagnostics of a package run and clears the siblings
+// of uri the run covered that had diagnostics in the previous run but have none now.
+func (h *langHandler) publishPackage(uri DocumentURI, diagnostics map[DocumentURI][]Diagnostic) {
+	dir := filepath.Dir(h.paths.uriToPath(string(uri)))
+
+	testsExcluded := h.fileTestsExcluded(h.paths.uriToPath(string(uri)))
+
+	h.publishedMu.Lock()
+	defer h.publishedMu.Unlock()
+
+	published := make(map[DocumentURI]struct{}, len(diagnostics))
+	for sibling := range h.published[dir] {
+		if _, ok := diagnostics[sibling]; ok {
+			continue
+		}
+		if !runCovers(uri, sibling, testsExcluded) {
+			// The run didn't look at sibling, so its diagnostics still hold.
+			published[sibling] = struct{}{}
 
 			continue
 		}
+		diagnostics[sibling] = []Diagnostic{}
+		h.issues.replace(sibling, nil, nil)
+	}
 
-		if err := h.conn.Notify(
-			context.Background(),
-			"textDocument/publishDiagnostics",
-			&PublishDiagnosticsParams{
-				URI:         uri,
-				Diagnostics: diagnostics,
-			}); err != nil {
-			h.logger.Printf("%s", err)
+	for target, ds := range diagnostics {
+		if len(ds) > 0 {
+			published[target] = struct{}{}
+		}
+		if target != uri {
+			// The diagnostics of a sibling may now differ from those of its last lint.
+			h.linted.forget(target)
 		}
+
+		h.publishDiagnostics(target, ds)
 	}
+	h.published[dir] = published
+}
+
+// publishDiagnostics hands the diagnostics to the publisher goroutine, so that a client
+// that stops reading never blocks linting.
+func (h *langHandler) publishDiagnostics(uri DocumentURI, diagnostics []Diagnostic) {
+	h.stats.linters.published(diagnostics)
+	h.publisher.publish(uri, diagnostics)
 }
 
 func (h *langHandler) handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
@@ -158,7 +799,7 @@ func (h *langHandler) handle(ctx context.Context, conn *jsonrpc2.Conn, req *json
 	case "initialize":
 		return h.handleInitialize(ctx, conn, req)
 	case "initialized":
-		return
+		return h.handleInitialized(ctx, conn, req)
 	case "shutdown":
 		return h.handleShutdown(ctx, conn, req)
 	case "textDocument/didOpen":
@@ -171,11 +812,56 @@ func (h *langHandler) handle(ctx context.Context, conn *jsonrpc2.Conn, req *json
 		return h.handleTextDocumentDidSave(ctx, conn, req)
 	case "workspace/didChangeConfiguration":
 		return h.handlerWorkspaceDidChangeConfiguration(ctx, conn, req)
+	case "workspace/didChangeWorkspaceFolders":
+		return h.handleWorkspaceDidChangeWorkspaceFolders(ctx, conn, req)
+	case "workspace/didChangeWatchedFiles":
+		return h.handleWorkspaceDidChangeWatchedFiles(ctx, conn, req)
+	case "workspace/didRenameFiles":
+		return h.handleWorkspaceDidRenameFiles(ctx, conn, req)
+	case "textDocument/codeAction":
+		return h.handleTextDocumentCodeAction(ctx, conn, req)
+	case "codeAction/resolve":
+		return h.handleCodeActionResolve(ctx, conn, req)
+	case "workspace/executeCommand":
+		return h.handleWorkspaceExecuteCommand(ctx, conn, req)
+	case "golangci-lint/configuration":
+		return h.handleConfiguration(ctx, conn, req)
+	case "golangci-lint/lastRun":
+		return h.handleLastRun(ctx, conn, req)
+	case "golangci-lint/stats":
+		return h.handleStats(ctx, conn, req)
+	case "textDocument/inlayHint":
+		return h.handleTextDocumentInlayHint(ctx, conn, req)
+	case "textDocument/documentLink":
+		return h.handleTextDocumentDocumentLink(ctx, conn, req)
+	case "golangci-lint/dumpState":
+		return h.handleDumpState(ctx, conn, req)
+	case "golangci-lint/features":
+		return h.handleFeatures(ctx, conn, req)
+	case "golangci-lint/exportSarif":
+		return h.handleExportSarif(ctx, conn, req)
+	case "golangci-lint/setLogLevel":
+		return h.handleSetLogLevel(ctx, conn, req)
 	}
 
 	return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: fmt.Sprintf("method not supported: %s", req.Method)}
 }
 
+func (h *langHandler) handleInitialized(_ context.Context, _ *jsonrpc2.Conn, _ *jsonrpc2.Request) (result interface{}, err error) {
+	h.gate.open()
+
+	go h.restoreDiagnostics()
+
+	if h.currentOptions().Warmup && h.root() != "" {
+		h.scheduler.Go(h.warmUp)
+	}
+
+	h.registerWatchers()
+	h.startFileWatcher()
+
+	return nil, nil
+}
+
 func (h *langHandler) handleInitialize(_ context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
 	var params InitializeParams
 	if err := json.Unmarshal(*req.Params, &params); err != nil {
@@ -183,43 +869,313 @@ func (h *langHandler) handleInitialize(_ context.Context, conn *jsonrpc2.Conn, r
 	}
 
 	h.rootURI = params.RootURI
-	h.rootDir = uriToPath(params.RootURI)
+	h.setRoot(h.paths.uriToPath(params.RootURI))
 	h.conn = conn
-	h.command = params.InitializationOptions.Command
+	h.clientCaps = params.Capabilities
+	h.encoding = negotiatePositionEncoding(params.Capabilities)
+	h.workspaceFolders = params.WorkspaceFolders
+	h.locale = params.Locale
+	h.msgs = messages.New(h.locale, nil)
+
+	opts, err := h.decodeClientOptions(params.InitializationOptions)
+	if err != nil {
+		err = h.msgs.Errorf(messages.InvalidInitOptions, err)
+		h.notifyError(err.Error())
+
+		return nil, err
+	}
+
+	if err := h.applyOptions(opts); err != nil {
+		h.notifyError(err.Error())
+
+		return nil, err
+	}
+	h.commandPrefix = opts.CommandPrefix
+
+	h.gate.close(initializedTimeout, func(pending []Request) {
+		// Enqueue blocks until the worker takes the request, and the worker may wait for the handler goroutine.
+		go func() {
+			for _, req := range pending {
+				if !h.scheduler.Enqueue(req) {
+					h.logger.Printf("golangci-lint-langserver: not linting %s after shutdown", req.URI)
+				}
+			}
+		}()
+	})
+
+	h.mu.Lock()
+	features := h.features
+	h.mu.Unlock()
 
 	return InitializeResult{
-		Capabilities: ServerCapabilities{
-			TextDocumentSync: TextDocumentSyncOptions{
-				Change:    TDSKNone,
-				OpenClose: true,
-				Save:      true,
-			},
+		ServerInfo: &ServerInfo{
+			Name:      "golangci-lint-langserver",
+			Version:   serverVersion() + " (" + featureMatrix(features).summary() + ")",
+			DebugAddr: h.debugAddr,
 		},
+		Capabilities: serverCapabilities(h.currentOptions(), h.clientCaps, features),
 	}, nil
 }
 
+// applyOptions detects the features of the configured golangci-lint and makes opts the effective configuration.
+// Executables not trusted yet aren't run, and their command is used as is until authorize allows them.
+func (h *langHandler) applyOptions(opts Options) error {
+	var features featureSet
+	if h.trusted(opts.Command[0], nil) {
+		var err error
+		if features, err = detectFeatures(opts.Command[0]); err != nil {
+			h.logger.Printf("golangci-lint-langserver: failed to detect golangci-lint features: %s", err)
+		}
+	}
+
+	msgs := messages.New(h.locale, opts.Messages)
+	if err := features.checkSupported(msgs); err != nil {
+		return err
+	}
+
+	h.paths.set(opts.PathMappings)
+	if h.rootURI != "" {
+		h.setRoot(h.paths.uriToPath(h.rootURI))
+	}
+
+	folders := resolveFolders(opts, h.paths, h.workspaceDirs(), func(bin string) featureSet {
+		if bin == opts.Command[0] {
+			return features
+		}
+		if !h.trusted(bin, nil) {
+			return featureSet{}
+		}
+
+		f, err := detectFeatures(bin)
+		if err != nil {
+			h.logger.Printf("golangci-lint-langserver: failed to detect golangci-lint features of %s: %s", bin, err)
+		}
+
+		return f
+	})
+
+	h.mu.Lock()
+	h.msgs = msgs
+	h.options = opts
+	h.features = features
+	h.command = resolveCommand(opts.Command, opts, features)
+	h.folders = folders
+	h.mu.Unlock()
+
+	h.logger.DebugJSON("golangci-lint-langserver: configuration:", h.configuration())
+
+	return nil
+}
+
+// configured reports whether options were applied, which makes initialize succeed.
+func (h *langHandler) configured() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.options.Command) > 0
+}
+
+func (h *langHandler) catalog() *messages.Catalog {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.msgs
+}
+
+func (h *langHandler) currentOptions() Options {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.options
+}
+
+// root returns the directory of the workspace root, or "" without one.
+func (h *langHandler) root() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.rootDir
+}
+
+// setRoot changes the directory of the workspace root, which the path mappings may move.
+func (h *langHandler) setRoot(dir string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.rootDir = dir
+}
+
+// Configuration is the response of the golangci-lint/configuration request.
+type Configuration struct {
+	Options         Options         `json:"options"`
+	Command         []string        `json:"command"`
+	Folders         []folderCommand `json:"folders,omitempty"`
+	CacheDir        string          `json:"cacheDir"`
+	Features        featureSet      `json:"features"`
+	NoLinterName    bool            `json:"noLinterName"`
+	DefaultSeverity string          `json:"defaultSeverity"`
+	RootDir         string          `json:"rootDir"`
+	LinterOverrides LinterOverrides `json:"linterOverrides"`
+	Deprecations    []string        `json:"deprecations"`
+}
+
+func (h *langHandler) configuration() Configuration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return Configuration{
+		Options:         h.options,
+		Command:         h.command,
+		Folders:         h.folders,
+		CacheDir:        cacheDir(h.options),
+		Features:        h.features,
+		NoLinterName:    h.noLinterName,
+		DefaultSeverity: defaultSeverity,
+		RootDir:         h.rootDir,
+		LinterOverrides: h.overrides.list(),
+		Deprecations:    h.deprecations.all(),
+	}
+}
+
+// callAsync sends the request method to the client without waiting for the response, which
+// is logged if it is an error. Calls must not be made from the handler goroutine, which
+// reads the responses.
+func (h *langHandler) callAsync(method string, params, result interface{}) {
+	go func() {
+		if err := h.conn.Call(context.Background(), method, params, result); err != nil {
+			h.logger.Printf("golangci-lint-langserver: %s", err)
+		}
+	}()
+}
+
+func (h *langHandler) showMessage(typ MessageType, message string) {
+	if h.conn == nil {
+		// Linting once from the command line.
+		h.logger.Printf("golangci-lint-langserver: %s", message)
+
+		return
+	}
+
+	if err := h.conn.Notify(
+		context.Background(),
+		"window/showMessage",
+		&ShowMessageParams{
+			Type:    typ,
+			Message: message,
+		}); err != nil {
+		h.logger.Printf("%s", err)
+	}
+}
+
 func (h *langHandler) handleShutdown(_ context.Context, _ *jsonrpc2.Conn, _ *jsonrpc2.Request) (result interface{}, err error) {
-	close(h.request)
+	h.scheduler.Close()
+	h.fsWatcher.close()
+	h.saveDiagnostics()
+	h.writeMetrics()
 
 	return nil, nil
 }
 
+// enqueue requests a lint of uri unless the file has unresolved merge conflicts,
+// in which case the conflicted regions are reported instead.
+func (h *langHandler) enqueue(uri DocumentURI, trigger Trigger) {
+	if h.isPullMode() {
+		// The client asks for diagnostics itself.
+		return
+	}
+
+	if h.publishConflicts(uri) {
+		return
+	}
+
+	h.schedule(uri, trigger)
+}
+
+// schedule hands a lint of uri to the scheduler.
+func (h *langHandler) schedule(uri DocumentURI, trigger Trigger) {
+	doc, _ := h.documents.get(uri)
+	req := Request{URI: uri, Trigger: trigger, Version: doc.Version, EnqueuedAt: time.Now()}
+	// The full pass of an earlier lint would publish outdated diagnostics.
+	h.passes.supersede(uri)
+	if h.gate.hold(req) {
+		h.logger.Printf("golangci-lint-langserver: holding the lint of %s until the client sends initialized", uri)
+
+		return
+	}
+	if !h.pending.add(uri, trigger) {
+		h.logger.DebugJSON("golangci-lint-langserver: lint already pending:", req)
+
+		return
+	}
+	if !trigger.background() {
+		// In single-flight mode the request would otherwise wait for the whole warm-up.
+		h.warmup.preempt()
+	}
+	if !h.scheduler.Enqueue(req) {
+		h.pending.done(uri, trigger)
+		h.logger.Printf("golangci-lint-langserver: not linting %s after shutdown", uri)
+	}
+}
+
+// publishConflicts reports the merge conflicts of uri and whether it has any. Documents the
+// client hasn't opened, such as those linted for watched files, are read from disk.
+func (h *langHandler) publishConflicts(uri DocumentURI) bool {
+	text, ok := h.documents.text(uri)
+	if !ok {
+		return false
+	}
+
+	diagnostics := conflictDiagnostics(text, h.catalog().Sprintf(messages.MergeConflict), h.encoding)
+	if len(diagnostics) == 0 {
+		return false
+	}
+	h.publishDiagnostics(uri, diagnostics)
+
+	return true
+}
+
 func (h *langHandler) handleTextDocumentDidOpen(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
 	var params DidOpenTextDocumentParams
 	if err := json.Unmarshal(*req.Params, &params); err != nil {
 		return nil, err
 	}
 
-	h.request <- params.TextDocument.URI
+	h.documents.open(params.TextDocument.URI, params.TextDocument.Text, params.TextDocument.Version)
+	h.watchDirsOf(h.paths.uriToPath(string(params.TextDocument.URI)))
+	// Registered first, so that the lint can't land before it.
+	h.startProvisional(params.TextDocument.URI)
+	h.enqueue(params.TextDocument.URI, TriggerOpen)
 
 	return nil, nil
 }
 
-func (h *langHandler) handleTextDocumentDidClose(_ context.Context, _ *jsonrpc2.Conn, _ *jsonrpc2.Request) (result interface{}, err error) {
+func (h *langHandler) handleTextDocumentDidClose(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params DidCloseTextDocumentParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	h.documents.close(params.TextDocument.URI)
+	h.linted.forget(params.TextDocument.URI)
+	h.fingerprints.forget(params.TextDocument.URI)
+	h.parsed.forget(params.TextDocument.URI)
+
 	return nil, nil
 }
 
-func (h *langHandler) handleTextDocumentDidChange(_ context.Context, _ *jsonrpc2.Conn, _ *jsonrpc2.Request) (result interface{}, err error) {
+func (h *langHandler) handleTextDocumentDidChange(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params DidChangeTextDocumentParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+	if len(params.ContentChanges) == 0 {
+		return nil, nil
+	}
+
+	// With full sync the last change holds the whole text; the lint itself still waits for the save.
+	last := params.ContentChanges[len(params.ContentChanges)-1]
+	h.documents.change(params.TextDocument.URI, last.Text, params.TextDocument.Version)
+
 	return nil, nil
 }
 
@@ -229,11 +1185,133 @@ func (h *langHandler) handleTextDocumentDidSave(_ context.Context, _ *jsonrpc2.C
 		return nil, err
 	}
 
-	h.request <- params.TextDocument.URI
+	if params.Text != nil {
+		h.documents.update(params.TextDocument.URI, *params.Text)
+
+		if diagnostics, ok := h.linted.unchanged(params.TextDocument.URI, *params.Text); ok && !h.isPullMode() {
+			atomic.AddInt64(&h.stats.skippedRuns, 1)
+			h.logger.Printf("golangci-lint-langserver: %s is unchanged since it was linted, skipping the run", params.TextDocument.URI)
+			h.publishDiagnostics(params.TextDocument.URI, diagnostics)
+			h.lintCompanions(params.TextDocument.URI)
+
+			return nil, nil
+		}
+	} else {
+		h.documents.reload(params.TextDocument.URI)
+	}
+	switch {
+	case h.isPullMode():
+	case isLintConfig(h.paths.uriToPath(string(params.TextDocument.URI))):
+		// Verified on its own rather than linted with a burst of saves.
+		h.enqueue(params.TextDocument.URI, TriggerSave)
+	default:
+		h.saves.add(params.TextDocument.URI)
+		h.lintCompanions(params.TextDocument.URI)
+	}
 
 	return nil, nil
 }
 
 func (h *langHandler) handlerWorkspaceDidChangeConfiguration(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params DidChangeConfigurationParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	raw, ok := params.Settings["golangci-lint"]
+	if !ok {
+		return nil, nil
+	}
+
+	opts, err := h.decodeClientOptions(raw)
+	if err == nil {
+		err = h.applyOptions(opts)
+	}
+	if err != nil {
+		h.notifyError(h.catalog().Sprintf(messages.InvalidSettings, err))
+
+		return nil, nil
+	}
+
+	h.invalidate("configuration changed")
+
 	return nil, nil
 }
+
+// decodeCustomParams decodes the params of a golangci-lint/* request into v, leaving it alone
+// when they are omitted or null, which JSON-RPC allows, and failing with InvalidParams when they
+// don't decode.
+func decodeCustomParams(req *jsonrpc2.Request, v interface{}) error {
+	if req.Params == nil || string(*req.Params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(*req.Params, v); err != nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: fmt.Sprintf("invalid params of %s: %s", req.Method, err)}
+	}
+
+	return nil
+}
+
+func (h *langHandler) handleLastRun(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params TextDocumentIdentifier
+	if err := decodeCustomParams(req, &params); err != nil {
+		return nil, err
+	}
+
+	return h.issues.run(params.URI), nil
+}
+
+func (h *langHandler) handleConfiguration(_ context.Context, _ *jsonrpc2.Conn, _ *jsonrpc2.Request) (result interface{}, err error) {
+	return h.configuration(), nil
+}
+
+func (h *langHandler) handleWorkspaceExecuteCommand(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params ExecuteCommandParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	// Unknown commands resolve to "" and fall through to the error.
+	cmd, _ := resolveCommandID(h.commandID(""), params.Command)
+	switch cmd {
+	case cmdRunWorkspace:
+		stream := len(params.WorkDoneToken) > 0 || len(params.PartialResultToken) > 0
+		h.scheduler.Go(func() { h.lintWorkspace(stream, params.WorkDoneToken) })
+
+		return nil, nil
+	case cmdOpenRuleDocs:
+		return nil, h.executeOpenRuleDocs(params.Arguments)
+	case cmdCopyIssue:
+		return h.executeCopyIssue(params.Arguments)
+	case cmdCleanCache:
+		go h.executeCleanCache()
+
+		return nil, nil
+	case cmdRunChanged:
+		return nil, h.executeRunChanged(params.Arguments, params.WorkDoneToken)
+	case cmdEnableLinter, cmdDisableLinter:
+		return h.executeToggleLinter(params.Arguments, cmd == cmdEnableLinter)
+	case cmdCaptureBundle:
+		return h.executeCaptureBundle(params.Arguments)
+	case cmdSnoozeIssue:
+		return nil, h.executeSnoozeIssue(params.Arguments)
+	case cmdClearSnoozed:
+		h.executeClearSnoozed()
+
+		return nil, nil
+	case cmdOpenLocation:
+		return nil, h.executeOpenLocation(params.Arguments)
+	}
+
+	return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: h.catalog().Sprintf(messages.CommandNotSupported, params.Command, strings.Join(commandIDs(h.commandID("")), ", "))}
+}
+
+// commandID returns the name of cmd announced to the client, under the commandPrefix
+// option in effect at initialize. commandID("") is the prefix itself.
+func (h *langHandler) commandID(cmd string) string {
+	if h.commandPrefix == "" {
+		return commandID(defaultCommandPrefix, cmd)
+	}
+
+	return commandID(h.commandPrefix, cmd)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
+)
+
+// rawClient speaks JSON-RPC to a server over a pipe byte by byte, so that requests can carry
+// any ID.
+type rawClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *textproto.Reader
+}
+
+func newRawClient(t *testing.T) *rawClient {
+	t.Helper()
+
+	h := newLangHandler(&testLogger{}, false)
+	h.runner = &fakeRunner{}
+	h.trust.path = ""
+	h.trust.all = true
+
+	serverSide, clientSide := net.Pipe()
+	server := jsonrpc2.NewConn(context.Background(), jsonrpc2.NewBufferedStream(serverSide, jsonrpc2.VSCodeObjectCodec{}), newHandler(h))
+	t.Cleanup(func() {
+		serverSide.Close()
+		clientSide.Close()
+		server.Close()
+	})
+
+	return &rawClient{t: t, conn: clientSide, r: textproto.NewReader(bufio.NewReader(clientSide))}
+}
+
+// call sends a request with the raw id and params and returns the response to it.
+func (c *rawClient) call(id, method, params string) (rawID json.RawMessage, result json.RawMessage, rpcErr *jsonrpc2.Error) {
+	c.t.Helper()
+
+	body := fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"method":%q`, id, method)
+	if params != "" {
+		body += `,"params":` + params
+	}
+	body += "}"
+	if err := c.conn.SetDeadline(time.Now().Add(testTimeout)); err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := fmt.Fprintf(c.conn, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		c.t.Fatal(err)
+	}
+
+	for {
+		header, err := c.r.ReadMIMEHeader()
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		n, err := strconv.Atoi(header.Get("Content-Length"))
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(c.r.R, b); err != nil {
+			c.t.Fatal(err)
+		}
+
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Result json.RawMessage `json:"result"`
+			Error  *jsonrpc2.Error `json:"error"`
+		}
+		if err := json.Unmarshal(b, &msg); err != nil {
+			c.t.Fatalf("%s: %s", b, err)
+		}
+		if msg.Method != "" {
+			// A notification or request of the server.
+			continue
+		}
+
+		return msg.ID, msg.Result, msg.Error
+	}
+}
+
+// TestCustomRequestIDs drives the golangci-lint/* requests through a jsonrpc2 pipe with string
+// and number IDs, which the responses must carry unchanged.
+func TestCustomRequestIDs(t *testing.T) {
+	c := newRawClient(t)
+	options := `{"warmup":false,"watcher":"off","instanceLockWait":0}`
+	if _, _, err := c.call(`"init"`, "initialize", `{"rootUri":"file:///nonexistent","initializationOptions":`+options+`}`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id       string
+		method   string
+		params   string
+		wantCode int64
+	}{
+		{id: `1`, method: "golangci-lint/configuration"},
+		{id: `"configuration"`, method: "golangci-lint/configuration", params: `null`},
+		{id: `9007199254740993`, method: "golangci-lint/stats"},
+		{id: `"stats-1"`, method: "golangci-lint/stats", params: `{}`},
+		{id: `2`, method: "golangci-lint/lastRun", params: `{"uri":"file:///nonexistent/a.go"}`},
+		{id: `"lastRun"`, method: "golangci-lint/lastRun"},
+		{id: `3`, method: "golangci-lint/lastRun", params: `{"uri":1}`, wantCode: jsonrpc2.CodeInvalidParams},
+		{id: `"features"`, method: "golangci-lint/features"},
+		{id: `4`, method: "golangci-lint/unknown", wantCode: jsonrpc2.CodeMethodNotFound},
+		{id: `"unknown"`, method: "golangci-lint/unknown", wantCode: jsonrpc2.CodeMethodNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.id, func(t *testing.T) {
+			id, result, err := c.call(tt.id, tt.method, tt.params)
+			if string(id) != tt.id {
+				t.Errorf("response id %s, want %s", id, tt.id)
+			}
+			switch {
+			case tt.wantCode != 0 && (err == nil || err.Code != tt.wantCode):
+				t.Errorf("error %v, want code %d", err, tt.wantCode)
+			case tt.wantCode == 0 && err != nil:
+				t.Errorf("error %v", err)
+			case tt.wantCode == 0 && len(result) == 0:
+				t.Error("no result")
+			}
+		})
+	}
+}
+
+// TestPackageDiagnosticsOtherDirectories checks that of a run from the root reporting issues
+// of several directories, only those of the linted package and of its go.mod are published.
+func TestPackageDiagnosticsOtherDirectories(t *testing.T) {
+	files := make(map[string]string)
+	for _, name := range []string{"sub/b.go", "sub/c.go", "sub/deep/d.go", "other/e.go", "subpkg/s.go"} {
+		pkg := filepath.Base(filepath.Dir(name))
+		files[name] = "package " + pkg + "\n\nfunc " + strings.ToUpper(strings.TrimSuffix(filepath.Base(name), ".go")) + "() {}\n"
+	}
+	ts := newTestServer(t, testConfig{files: files})
+
+	f, err := os.Open(filepath.Join("testdata", "multidir", "result.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	result, err := lint.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(t *testing.T, root, cmdDir string) {
+		uri := func(name string) DocumentURI {
+			return pathToURI(filepath.Join(root, filepath.FromSlash(name)))
+		}
+
+		diagnostics := ts.h.packageDiagnostics(uri("sub/b.go"), cmdDir, result, &runInfo{Dir: cmdDir})
+		got := make(map[DocumentURI][]string)
+		for uri, ds := range diagnostics {
+			for _, d := range ds {
+				got[uri] = append(got[uri], d.Message)
+			}
+		}
+		want := map[DocumentURI][]string{
+			uri("sub/b.go"): {"func `B` is unused"},
+			uri("sub/c.go"): {"func `C` is unused"},
+			uri("go.mod"):   {"replacement are not allowed: example.com/x"},
+		}
+		if len(got) != len(want) {
+			t.Errorf("diagnostics published for %d files, want %d: %q", len(got), len(want), got)
+		}
+		for uri, messages := range want {
+			if len(got[uri]) != len(messages) || !strings.Contains(got[uri][0], messages[0]) {
+				t.Errorf("%s: diagnostics %q, want %q", uri, got[uri], messages)
+			}
+		}
+	}
+
+	t.Run("root", func(t *testing.T) {
+		check(t, ts.root, ts.root)
+	})
+	t.Run("symlinked root", func(t *testing.T) {
+		// The document is open through a symlink, and golangci-lint reports the real paths.
+		link := filepath.Join(t.TempDir(), "link")
+		if err := os.Symlink(ts.root, link); err != nil {
+			t.Skip(err)
+		}
+		check(t, link, ts.root)
+	})
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// parseIncludePattern splits a pattern of the include option into a slash-separated directory
+// relative to the workspace root and whether its subdirectories are included, as with the
+// "/..." suffix of Go package patterns.
+func parseIncludePattern(pattern string) (dir string, recursive, ok bool) {
+	p := filepath.ToSlash(strings.TrimSpace(pattern))
+	if p == "..." {
+		return ".", true, true
+	}
+	if strings.HasSuffix(p, "/...") {
+		p, recursive = strings.TrimSuffix(p, "/..."), true
+	}
+	if p == "" || path.IsAbs(p) || filepath.IsAbs(pattern) || strings.Contains(p, "...") {
+		return "", false, false
+	}
+
+	p = path.Clean(p)
+	if p == ".." || strings.HasPrefix(p, "../") {
+		return "", false, false
+	}
+
+	return p, recursive, true
+}
+
+// includes reports whether the include option restricts what is linted.
+func (h *langHandler) includes() bool {
+	return h.root() != "" && len(h.currentOptions().Include) > 0
+}
+
+// included reports whether the package in dir is in the include option; everything is when
+// the option is empty.
+func (h *langHandler) included(dir string) bool {
+	if !h.includes() {
+		return true
+	}
+
+	rel, err := filepath.Rel(h.root(), dir)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return false
+	}
+
+	for _, pattern := range h.currentOptions().Include {
+		base, recursive, ok := parseIncludePattern(pattern)
+		if !ok {
+			continue
+		}
+		if rel == base || (recursive && (base == "." || strings.HasPrefix(rel, base+"/"))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// includePattern is a parsed pattern of the include option.
+type includePattern struct {
+	base      string
+	recursive bool
+}
+
+// covers reports whether every package of q is also one of p.
+func (p includePattern) covers(q includePattern) bool {
+	if !p.recursive {
+		return !q.recursive && p.base == q.base
+	}
+
+	return p.base == "." || q.base == p.base || strings.HasPrefix(q.base, p.base+"/")
+}
+
+// includePatterns parses the valid patterns of include, leaving out those another one covers,
+// so that no package is linted twice by overlapping patterns.
+func includePatterns(include []string) []includePattern {
+	var parsed []includePattern
+	for _, pattern := range include {
+		if base, recursive, ok := parseIncludePattern(pattern); ok {
+			parsed = append(parsed, includePattern{base: base, recursive: recursive})
+		}
+	}
+
+	var patterns []includePattern
+	for i, p := range parsed {
+		covered := false
+		for j, q := range parsed {
+			// Of two equal patterns, the first is kept.
+			if i != j && q.covers(p) && (!p.covers(q) || j < i) {
+				covered = true
+
+				break
+			}
+		}
+		if !covered {
+			patterns = append(patterns, p)
+		}
+	}
+
+	return patterns
+}
+
+// includedTargets returns the package patterns, relative to root, of the include option
+// under root, or all when the option doesn't restrict root.
+func (h *langHandler) includedTargets(root string) (targets []string, all bool) {
+	if !h.includes() {
+		return nil, true
+	}
+
+	for _, pattern := range includePatterns(h.currentOptions().Include) {
+		base, recursive := pattern.base, pattern.recursive
+		dir := filepath.Join(h.root(), filepath.FromSlash(base))
+		if recursive && isSubdir(dir, root) {
+			return nil, true
+		}
+		if !isSubdir(root, dir) {
+			continue
+		}
+
+		rel, _ := filepath.Rel(root, dir)
+		target := "./" + filepath.ToSlash(rel)
+		if rel == "." {
+			target = "."
+		}
+		if recursive {
+			target = strings.TrimSuffix(target, "/.") + "/..."
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, false
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+func TestParseIncludePattern(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		dir       string
+		recursive bool
+		ok        bool
+	}{
+		{pattern: "...", dir: ".", recursive: true, ok: true},
+		{pattern: "./...", dir: ".", recursive: true, ok: true},
+		{pattern: ".", dir: ".", ok: true},
+		{pattern: "services/foo/...", dir: "services/foo", recursive: true, ok: true},
+		{pattern: "./services/foo/", dir: "services/foo", ok: true},
+		{pattern: " libs/bar ", dir: "libs/bar", ok: true},
+		{pattern: "libs/../services/...", dir: "services", recursive: true, ok: true},
+		{pattern: ""},
+		{pattern: "/abs/..."},
+		{pattern: "../outside/..."},
+		{pattern: "libs/../../outside"},
+		{pattern: "services/.../foo"},
+		{pattern: "services..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			dir, recursive, ok := parseIncludePattern(tt.pattern)
+			if dir != tt.dir || recursive != tt.recursive || ok != tt.ok {
+				t.Errorf("parseIncludePattern() = %q, %v, %v, want %q, %v, %v", dir, recursive, ok, tt.dir, tt.recursive, tt.ok)
+			}
+		})
+	}
+}
+
+func TestIncludeOptionValidation(t *testing.T) {
+	msgs := messages.New("", nil)
+	for _, include := range [][]string{{"/abs/..."}, {"services/...", "../outside"}} {
+		raw, err := json.Marshal(map[string]interface{}{"include": include})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := decodeOptions(raw, msgs); err == nil {
+			t.Errorf("include %q accepted", include)
+		}
+	}
+
+	raw := json.RawMessage(`{"include": ["services/foo/...", "libs/bar"]}`)
+	if _, err := decodeOptions(raw, msgs); err != nil {
+		t.Errorf("valid patterns refused: %s", err)
+	}
+}
+
+func TestIncludePatternsOverlap(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		want    []includePattern
+	}{
+		{
+			name:    "disjoint",
+			include: []string{"services/foo/...", "libs/bar"},
+			want:    []includePattern{{base: "services/foo", recursive: true}, {base: "libs/bar"}},
+		},
+		{
+			name:    "nested recursive",
+			include: []string{"services/foo/...", "services/..."},
+			want:    []includePattern{{base: "services", recursive: true}},
+		},
+		{
+			name:    "package of a recursive pattern",
+			include: []string{"services/foo", "services/foo/..."},
+			want:    []includePattern{{base: "services/foo", recursive: true}},
+		},
+		{
+			name:    "package under a package",
+			include: []string{"services", "services/foo"},
+			want:    []includePattern{{base: "services"}, {base: "services/foo"}},
+		},
+		{
+			name:    "sibling sharing a prefix",
+			include: []string{"services/...", "services2/..."},
+			want:    []includePattern{{base: "services", recursive: true}, {base: "services2", recursive: true}},
+		},
+		{
+			name:    "same pattern written twice",
+			include: []string{"./libs/bar/...", "libs/bar/..."},
+			want:    []includePattern{{base: "libs/bar", recursive: true}},
+		},
+		{
+			name:    "everything",
+			include: []string{"libs/bar", "...", "services/..."},
+			want:    []includePattern{{base: ".", recursive: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := includePatterns(tt.include); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("includePatterns(%q) = %+v, want %+v", tt.include, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncluded(t *testing.T) {
+	root := t.TempDir()
+	path := func(name string) string {
+		return filepath.Join(root, filepath.FromSlash(name))
+	}
+	h := newLangHandler(&testLogger{}, false)
+	h.rootDir = root
+	h.options = Options{Include: []string{"services/foo/...", "libs/bar", "services/foo/api/..."}}
+
+	for dir, want := range map[string]bool{
+		"services/foo":         true,
+		"services/foo/api/v1":  true,
+		"services/foobar":      false,
+		"services":             false,
+		"libs/bar":             true,
+		"libs/bar/internal":    false,
+		"tools":                false,
+		"../elsewhere/project": false,
+	} {
+		if got := h.included(path(dir)); got != want {
+			t.Errorf("included(%s) = %v, want %v", dir, got, want)
+		}
+	}
+
+	tests := []struct {
+		root    string
+		targets []string
+		all     bool
+	}{
+		{root: root, targets: []string{"./services/foo/...", "./libs/bar"}},
+		{root: path("services"), targets: []string{"./foo/..."}},
+		{root: path("services/foo/api"), all: true},
+		{root: path("tools")},
+	}
+	for _, tt := range tests {
+		targets, all := h.includedTargets(tt.root)
+		if !reflect.DeepEqual(targets, tt.targets) || all != tt.all {
+			t.Errorf("includedTargets(%s) = %q, %v, want %q, %v", tt.root, targets, all, tt.targets, tt.all)
+		}
+	}
+}
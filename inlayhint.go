@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// hiddenIssues counts per file the issues that golangci-lint reported but the options hide.
+type hiddenIssues struct {
+	mu     sync.Mutex
+	counts map[DocumentURI]int
+	paths  *pathMapper
+}
+
+func newHiddenIssues(paths *pathMapper) *hiddenIssues {
+	return &hiddenIssues{
+		counts: make(map[DocumentURI]int),
+		paths:  paths,
+	}
+}
+
+// replaceDir replaces the counts of the files in dir and reports whether any changed.
+func (s *hiddenIssues) replaceDir(dir string, counts map[DocumentURI]int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	for uri, n := range s.counts {
+		if samePath(filepath.Dir(s.paths.uriToPath(string(uri))), dir) && counts[uri] != n {
+			delete(s.counts, uri)
+			changed = true
+		}
+	}
+	for uri, n := range counts {
+		if n > 0 && s.counts[uri] != n {
+			s.counts[uri] = n
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+func (s *hiddenIssues) get(uri DocumentURI) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.counts[uri]
+}
+
+// updateHidden records the hidden issues of the files in dir and asks the client to
+// request the inlay hints again when their counts changed.
+func (h *langHandler) updateHidden(dir string, counts map[DocumentURI]int) {
+	if !h.hidden.replaceDir(dir, counts) || !h.currentOptions().HiddenIssuesHint || !h.clientCaps.Workspace.InlayHint.RefreshSupport {
+		return
+	}
+
+	h.callAsync("workspace/inlayHint/refresh", nil, nil)
+}
+
+// handleTextDocumentInlayHint shows the number of hidden issues at the end of the package clause.
+func (h *langHandler) handleTextDocumentInlayHint(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params InlayHintParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	hints := make([]InlayHint, 0)
+
+	n := h.hidden.get(params.TextDocument.URI)
+	if n == 0 || !h.currentOptions().HiddenIssuesHint {
+		return hints, nil
+	}
+
+	text, ok := h.documents.text(params.TextDocument.URI)
+	if !ok {
+		return hints, nil
+	}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strings.HasPrefix(line, "package ") {
+			continue
+		}
+
+		hints = append(hints, InlayHint{
+			Position:    Position{Line: i, Character: h.encoding.character(line, len(line))},
+			Label:       h.catalog().Sprintf(messages.HiddenIssues, n),
+			PaddingLeft: true,
+		})
+
+		break
+	}
+
+	return hints, nil
+}
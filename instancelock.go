@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const defaultInstanceLockWait = 5000 // milliseconds
+
+// instanceLockPoll is how often a busy lock is tried again.
+const instanceLockPoll = 100 * time.Millisecond
+
+// errLockBusy is returned by tryLock when another process holds the lock.
+var errLockBusy = errors.New("lock held by another process")
+
+// instanceLocks serializes the golangci-lint runs of the servers of all editors open on the
+// same module, which would otherwise run at the same time and fight over the analysis cache,
+// with an advisory lock file per module root.
+type instanceLocks struct {
+	mu sync.Mutex
+	// held counts the runs of this server per module root whose lock it holds, which share it.
+	held map[string]*heldLock
+	// unsupported is set once locking failed for another reason than a busy lock, e.g. on a
+	// filesystem without flock; runs then go ahead unlocked.
+	unsupported bool
+}
+
+// heldLock is a lock file this server holds.
+type heldLock struct {
+	f    *os.File
+	runs int
+}
+
+// share joins a lock this server already holds for root, returning its release function.
+func (l *instanceLocks) share(root string) (func(), bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	held, ok := l.held[root]
+	if !ok {
+		return nil, false
+	}
+	held.runs++
+
+	return func() { l.release(root) }, true
+}
+
+// hold records the lock on f for root as held by one run, and returns its release function.
+func (l *instanceLocks) hold(root string, f *os.File) func() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held == nil {
+		l.held = make(map[string]*heldLock)
+	}
+	if held, ok := l.held[root]; ok {
+		// Another run of this server took it meanwhile through another file.
+		held.runs++
+		f.Close()
+	} else {
+		l.held[root] = &heldLock{f: f, runs: 1}
+	}
+
+	return func() { l.release(root) }
+}
+
+func (l *instanceLocks) release(root string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	held := l.held[root]
+	held.runs--
+	if held.runs == 0 {
+		// Closing the file releases the lock.
+		held.f.Close()
+		delete(l.held, root)
+	}
+}
+
+// instanceLockPath returns the lock file of the module at root, under os.UserCacheDir.
+func instanceLockPath(root string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(root))
+
+	return filepath.Join(dir, "golangci-lint-langserver", "locks", hex.EncodeToString(sum[:8])+".lock"), nil
+}
+
+// lockModule takes the lock of the module of dir, waiting up to the instanceLockWait option
+// for another server to release it, and returns the function releasing it. The run goes ahead
+// without the lock when the wait is over or locking isn't possible.
+func (h *langHandler) lockModule(dir string) func() {
+	wait := time.Duration(h.currentOptions().InstanceLockWait) * time.Millisecond
+	if wait <= 0 {
+		return func() {}
+	}
+
+	h.instanceLocks.mu.Lock()
+	unsupported := h.instanceLocks.unsupported
+	h.instanceLocks.mu.Unlock()
+	if unsupported {
+		return func() {}
+	}
+
+	root := findModuleRoot(dir)
+	if root == "" {
+		root = dir
+	}
+	if release, ok := h.instanceLocks.share(root); ok {
+		return release
+	}
+
+	path, err := instanceLockPath(root)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o700)
+	}
+	var f *os.File
+	if err == nil {
+		f, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	}
+	if err != nil {
+		h.lockUnsupported(err)
+
+		return func() {}
+	}
+
+	deadline := time.Now().Add(wait)
+	waited := false
+	for {
+		err = tryLock(f)
+		if !errors.Is(err, errLockBusy) || time.Now().After(deadline) {
+			break
+		}
+		if release, ok := h.instanceLocks.share(root); ok {
+			// Another run of this server got the lock.
+			f.Close()
+
+			return release
+		}
+		if !waited {
+			waited = true
+			h.logger.Printf("golangci-lint-langserver: another server is linting %s, waiting up to %s", root, wait)
+		}
+		time.Sleep(instanceLockPoll)
+	}
+
+	switch {
+	case errors.Is(err, errLockBusy):
+		h.logger.Printf("golangci-lint-langserver: %s is still locked by another server, linting anyway", root)
+		f.Close()
+
+		return func() {}
+	case err != nil:
+		h.lockUnsupported(err)
+		f.Close()
+
+		return func() {}
+	}
+
+	return h.instanceLocks.hold(root, f)
+}
+
+// lockUnsupported stops locking for the session after err.
+func (h *langHandler) lockUnsupported(err error) {
+	h.instanceLocks.mu.Lock()
+	defer h.instanceLocks.mu.Unlock()
+
+	if !h.instanceLocks.unsupported {
+		h.instanceLocks.unsupported = true
+		h.logger.Printf("golangci-lint-langserver: can't lock modules against other servers, running unlocked: %s", err)
+	}
+}
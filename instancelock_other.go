@@ -0,0 +1,14 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLock fails: flock isn't available, so runs aren't locked against other servers.
+func tryLock(*os.File) error {
+	return errors.New("flock is not supported on this platform")
+}
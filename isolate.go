@@ -0,0 +1,83 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// brokenDirs holds the package directories whose last run of their own failed.
+type brokenDirs struct {
+	mu   sync.Mutex
+	dirs map[string]struct{}
+}
+
+func (b *brokenDirs) set(dir string, broken bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !broken {
+		delete(b.dirs, dir)
+
+		return
+	}
+	if b.dirs == nil {
+		b.dirs = make(map[string]struct{})
+	}
+	b.dirs[dir] = struct{}{}
+}
+
+func (b *brokenDirs) has(dir string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	_, ok := b.dirs[dir]
+
+	return ok
+}
+
+// isolateFailure falls back to one run per package after a run of every package under dir
+// failed, so that healthy packages still get their diagnostics and only broken ones show the
+// failure. Packages of open documents and of the saved files are linted, except those known
+// to be broken, which are only retried when one of their files was saved.
+// The results are keyed by the document each package was linted for.
+func (h *langHandler) isolateFailure(dir string, saved []DocumentURI) map[DocumentURI]map[DocumentURI][]Diagnostic {
+	retry := make(map[string]struct{})
+	for _, uri := range saved {
+		retry[filepath.Dir(h.paths.uriToPath(string(uri)))] = struct{}{}
+	}
+
+	candidates := append(h.documents.uris(), saved...)
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i] < candidates[j] })
+
+	packages := make(map[string]DocumentURI)
+	for _, uri := range candidates {
+		path := h.paths.uriToPath(string(uri))
+		pkg := filepath.Dir(path)
+		if _, ok := packages[pkg]; ok || !strings.HasSuffix(path, ".go") || !isSubdir(dir, pkg) {
+			continue
+		}
+		if _, ok := retry[pkg]; !ok && h.broken.has(pkg) {
+			h.logger.Printf("golangci-lint-langserver: not retrying %s, which failed before, until one of its files is saved", pkg)
+
+			continue
+		}
+		packages[pkg] = uri
+	}
+
+	h.logger.Printf("golangci-lint-langserver: run of %s failed, linting %d packages on their own", dir, len(packages))
+
+	results := make(map[DocumentURI]map[DocumentURI][]Diagnostic, len(packages))
+	for _, uri := range packages {
+		diagnostics, err := h.lint(uri)
+		if err != nil {
+			h.logger.Printf("%s", err)
+
+			continue
+		}
+		results[uri] = diagnostics
+	}
+
+	return results
+}
@@ -0,0 +1,13 @@
+package main
+
+import "os/exec"
+
+// killed reports false: Plan 9 exit statuses are strings that don't tell a kill apart.
+func killed(*exec.ExitError) bool {
+	return false
+}
+
+// exitSignal reports false: Plan 9 has notes rather than signals.
+func exitSignal(*exec.ExitError) (string, bool) {
+	return "", false
+}
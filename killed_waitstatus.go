@@ -0,0 +1,26 @@
+//go:build !plan9
+// +build !plan9
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killed reports whether golangci-lint was killed with SIGKILL, as the OOM killer does.
+func killed(err *exec.ExitError) bool {
+	status, ok := err.Sys().(syscall.WaitStatus)
+
+	return ok && status.Signaled() && status.Signal() == syscall.SIGKILL
+}
+
+// exitSignal returns the name of the signal that ended golangci-lint, if one did.
+func exitSignal(err *exec.ExitError) (string, bool) {
+	status, ok := err.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return "", false
+	}
+
+	return status.Signal().String(), true
+}
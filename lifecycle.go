@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// codeServerNotInitialized is the LSP error code for requests sent before initialize.
+const codeServerNotInitialized = -32002
+
+type lifecycleState int
+
+const (
+	stateUninitialized lifecycleState = iota
+	stateInitialized
+	stateShutDown
+)
+
+// lifecycleHandler enforces the lifecycle of the protocol in front of every other handler:
+// before initialize requests fail with ServerNotInitialized and notifications are dropped,
+// initialize can only succeed once, and after shutdown only exit is accepted.
+type lifecycleHandler struct {
+	jsonrpc2.Handler
+	h *langHandler
+
+	// state is only used by the goroutine of the connection, which handles messages one at a time.
+	state lifecycleState
+}
+
+func (l *lifecycleHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	if req.Method == "exit" {
+		if err := conn.Close(); err != nil {
+			l.h.logger.Printf("golangci-lint-langserver: %s", err)
+		}
+
+		return
+	}
+
+	if rpcErr := l.check(req); rpcErr != nil {
+		if req.Notif {
+			l.h.logger.Printf("golangci-lint-langserver: dropping %s: %s", req.Method, rpcErr.Message)
+
+			return
+		}
+		if err := conn.ReplyWithError(ctx, req.ID, rpcErr); err != nil {
+			l.h.logger.Printf("golangci-lint-langserver: %s", err)
+		}
+
+		return
+	}
+
+	l.Handler.Handle(ctx, conn, req)
+
+	switch {
+	case req.Method == "initialize" && l.h.configured():
+		l.state = stateInitialized
+	case req.Method == "shutdown":
+		l.state = stateShutDown
+	}
+}
+
+// initializedTimeout is how long lint requests wait for the initialized notification,
+// which some clients never send.
+const initializedTimeout = 2 * time.Second
+
+// initGate holds the lint requests made between the initialize response and the initialized
+// notification. Clients may open documents in between, before they are ready for the progress
+// and registration requests a lint can make.
+type initGate struct {
+	mu     sync.Mutex
+	closed bool
+	// pending keeps the latest request per document, so a held open followed by a save lints once.
+	pending []Request
+	timer   *time.Timer
+	release func([]Request)
+}
+
+// close holds the requests from now on until open is called or timeout elapses.
+func (g *initGate) close(timeout time.Duration, release func([]Request)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.closed = true
+	g.release = release
+	g.timer = time.AfterFunc(timeout, g.open)
+}
+
+// hold keeps req for later and reports true while the gate is closed.
+func (g *initGate) hold(req Request) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.closed {
+		return false
+	}
+
+	for i, pending := range g.pending {
+		if pending.URI == req.URI {
+			g.pending = append(g.pending[:i], g.pending[i+1:]...)
+
+			break
+		}
+	}
+	g.pending = append(g.pending, req)
+
+	return true
+}
+
+// open hands the held requests to release in their order. Only the first call releases them.
+func (g *initGate) open() {
+	g.mu.Lock()
+	if !g.closed {
+		g.mu.Unlock()
+
+		return
+	}
+	g.closed = false
+	g.timer.Stop()
+	pending := g.pending
+	g.pending = nil
+	g.mu.Unlock()
+
+	if len(pending) > 0 {
+		g.release(pending)
+	}
+}
+
+// check returns the error for req in the current state, or nil if req may be handled.
+func (l *lifecycleHandler) check(req *jsonrpc2.Request) *jsonrpc2.Error {
+	switch l.state {
+	case stateUninitialized:
+		if req.Method != "initialize" {
+			return &jsonrpc2.Error{Code: codeServerNotInitialized, Message: fmt.Sprintf("%s before initialize", req.Method)}
+		}
+	case stateInitialized:
+		if req.Method == "initialize" {
+			return &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidRequest, Message: "initialize was already received"}
+		}
+	case stateShutDown:
+		return &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidRequest, Message: fmt.Sprintf("%s after shutdown", req.Method)}
+	}
+
+	return nil
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// lifecycleStep is a message sent to the server and, for a request expected to fail, the error
+// code of the reply.
+type lifecycleStep struct {
+	method string
+	params interface{}
+	notif  bool
+	fails  bool
+	code   int64
+}
+
+func TestLifecycleHandler(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod": "module example.com/test\n\ngo 1.16\n",
+		"a.go":   "package test\n",
+	})
+	options := json.RawMessage(`{"warmup": false, "watcher": "off", "instanceLockWait": 0}`)
+	initialize := lifecycleStep{method: "initialize", params: InitializeParams{RootURI: string(pathToURI(root)), InitializationOptions: options}}
+	uri := pathToURI(filepath.Join(root, "a.go"))
+	didOpen := lifecycleStep{method: "textDocument/didOpen", notif: true, params: DidOpenTextDocumentParams{TextDocument: TextDocumentItem{
+		URI: uri, LanguageID: "go", Version: 1, Text: "package test\n",
+	}}}
+	initialized := lifecycleStep{method: "initialized", notif: true, params: struct{}{}}
+	shutdown := lifecycleStep{method: "shutdown"}
+	executeCommand := lifecycleStep{method: "workspace/executeCommand", params: ExecuteCommandParams{Command: cmdClearSnoozed}}
+
+	fails := func(step lifecycleStep, code int64) lifecycleStep {
+		step.fails, step.code = true, code
+
+		return step
+	}
+
+	tests := []struct {
+		name  string
+		steps []lifecycleStep
+		// opened tells whether the document of didOpen ends up open.
+		opened bool
+		// exited is set when the server closes the connection.
+		exited bool
+	}{
+		{
+			name:  "request before initialize",
+			steps: []lifecycleStep{fails(executeCommand, codeServerNotInitialized), fails(shutdown, codeServerNotInitialized), initialize, executeCommand},
+		},
+		{
+			name:  "notification before initialize",
+			steps: []lifecycleStep{didOpen, initialize, initialized},
+		},
+		{
+			name:   "notification after initialize",
+			steps:  []lifecycleStep{initialize, initialized, didOpen},
+			opened: true,
+		},
+		{
+			name:  "duplicate initialize",
+			steps: []lifecycleStep{initialize, fails(initialize, jsonrpc2.CodeInvalidRequest), initialized, executeCommand},
+		},
+		{
+			name: "initialize failing then succeeding",
+			steps: []lifecycleStep{
+				fails(lifecycleStep{method: "initialize", params: InitializeParams{RootURI: string(pathToURI(root)), InitializationOptions: json.RawMessage(`{"command": []}`)}}, 0),
+				fails(executeCommand, codeServerNotInitialized),
+				initialize,
+				executeCommand,
+			},
+		},
+		{
+			name: "after shutdown",
+			steps: []lifecycleStep{
+				initialize, initialized, shutdown,
+				fails(executeCommand, jsonrpc2.CodeInvalidRequest),
+				fails(shutdown, jsonrpc2.CodeInvalidRequest),
+				fails(initialize, jsonrpc2.CodeInvalidRequest),
+				didOpen,
+			},
+		},
+		{
+			name:   "exit before initialize",
+			steps:  []lifecycleStep{{method: "exit", notif: true}},
+			exited: true,
+		},
+		{
+			name:   "exit after shutdown",
+			steps:  []lifecycleStep{initialize, initialized, shutdown, {method: "exit", notif: true}},
+			exited: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &testLogger{}
+			h := newLangHandler(logger, false)
+			h.runner = &fakeRunner{}
+			h.trust.path = ""
+			h.trust.all = true
+
+			ctx := context.Background()
+			serverSide, clientSide := net.Pipe()
+			server := jsonrpc2.NewConn(ctx, jsonrpc2.NewBufferedStream(serverSide, jsonrpc2.VSCodeObjectCodec{}), newHandler(h))
+			client := jsonrpc2.NewConn(ctx, jsonrpc2.NewBufferedStream(clientSide, jsonrpc2.VSCodeObjectCodec{}), jsonrpc2.HandlerWithError(
+				func(context.Context, *jsonrpc2.Conn, *jsonrpc2.Request) (interface{}, error) { return nil, nil },
+			))
+			t.Cleanup(func() {
+				serverSide.Close()
+				clientSide.Close()
+				client.Close()
+				server.Close()
+				if t.Failed() {
+					t.Logf("server log:\n%s", logger)
+				}
+			})
+
+			for i, step := range tt.steps {
+				if step.notif {
+					if err := client.Notify(ctx, step.method, step.params); err != nil {
+						t.Fatalf("step %d: %s: %s", i, step.method, err)
+					}
+
+					continue
+				}
+
+				callCtx, cancel := context.WithTimeout(ctx, testTimeout)
+				err := client.Call(callCtx, step.method, step.params, nil)
+				cancel()
+				var rpcErr *jsonrpc2.Error
+				switch {
+				case !step.fails && err != nil:
+					t.Errorf("step %d: %s failed: %s", i, step.method, err)
+				case step.fails && !errors.As(err, &rpcErr):
+					t.Errorf("step %d: %s returned %v, want error code %d", i, step.method, err, step.code)
+				case step.fails && rpcErr.Code != step.code:
+					t.Errorf("step %d: %s failed with code %d (%s), want %d", i, step.method, rpcErr.Code, rpcErr.Message, step.code)
+				}
+			}
+
+			if tt.exited {
+				select {
+				case <-server.DisconnectNotify():
+				case <-time.After(testTimeout):
+					t.Error("the server didn't close the connection on exit")
+				}
+
+				return
+			}
+
+			// A request handled after the notifications tells they were all taken in.
+			callCtx, cancel := context.WithTimeout(ctx, testTimeout)
+			defer cancel()
+			_ = client.Call(callCtx, "shutdown", nil, nil)
+			if _, ok := h.documents.get(uri); ok != tt.opened {
+				t.Errorf("document open: %v, want %v", ok, tt.opened)
+			}
+		})
+	}
+}
+
+func TestInitGate(t *testing.T) {
+	var gate initGate
+	if gate.hold(Request{URI: "file:///a.go"}) {
+		t.Fatal("a request was held before the gate was closed")
+	}
+
+	released := make(chan []Request, 2)
+	gate.close(time.Hour, func(pending []Request) { released <- pending })
+	for _, req := range []Request{
+		{URI: "file:///a.go", Trigger: TriggerOpen},
+		{URI: "file:///b.go", Trigger: TriggerOpen},
+		{URI: "file:///a.go", Trigger: TriggerSave},
+	} {
+		if !gate.hold(req) {
+			t.Fatalf("%s wasn't held by the closed gate", req.URI)
+		}
+	}
+
+	gate.open()
+	gate.open()
+	got := <-released
+	want := []Request{{URI: "file:///b.go", Trigger: TriggerOpen}, {URI: "file:///a.go", Trigger: TriggerSave}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("released %+v, want %+v", got, want)
+	}
+	select {
+	case pending := <-released:
+		t.Errorf("released %+v again", pending)
+	default:
+	}
+	if gate.hold(Request{URI: "file:///a.go"}) {
+		t.Error("a request was held after the gate was opened")
+	}
+
+	// Without initialized, the timeout releases the requests.
+	gate.close(10*time.Millisecond, func(pending []Request) { released <- pending })
+	gate.hold(Request{URI: "file:///c.go"})
+	select {
+	case pending := <-released:
+		if len(pending) != 1 || pending[0].URI != "file:///c.go" {
+			t.Errorf("released %+v after the timeout, want c.go", pending)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("the requests weren't released after the timeout")
+	}
+}
+
+// TestInitGateDidOpen checks that a document opened before the initialized notification is
+// linted once it arrives, as one opened after it, and only once however often it was saved.
+func TestInitGateDidOpen(t *testing.T) {
+	for _, beforeInitialized := range []bool{true, false} {
+		t.Run(fmt.Sprintf("before initialized %v", beforeInitialized), func(t *testing.T) {
+			ts := newTestServer(t, testConfig{noInitialized: true})
+			initialized := func() {
+				t.Helper()
+
+				if err := ts.client.Notify(context.Background(), "initialized", struct{}{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !beforeInitialized {
+				initialized()
+			}
+			ts.open("a.go")
+			params := DidSaveTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: ts.uri("a.go")}}
+			if err := ts.client.Notify(context.Background(), "textDocument/didSave", params); err != nil {
+				t.Fatal(err)
+			}
+			if beforeInitialized {
+				// A request handled after the notifications tells they were all taken in.
+				if err := ts.call("workspace/executeCommand", ExecuteCommandParams{Command: cmdClearSnoozed}, nil); err != nil {
+					t.Fatal(err)
+				}
+				if n := len(ts.runner.Runs()); n != 0 {
+					t.Fatalf("%d runs before initialized, want 0", n)
+				}
+				initialized()
+			}
+
+			ts.waitPublished("a.go")
+			time.Sleep(50 * time.Millisecond)
+			runs := ts.runner.Runs()
+			if beforeInitialized && len(runs) != 1 {
+				t.Errorf("%d runs, want 1", len(runs))
+			}
+			if !beforeInitialized && (len(runs) == 0 || len(runs) > 2) {
+				t.Errorf("%d runs, want the open and at most the save", len(runs))
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+// utf8BOM is the byte order mark some Windows editors start UTF-8 files with.
+const utf8BOM = "\ufeff"
+
+// stripBOM removes the byte order mark text starts with, which clients leave out of the
+// document text.
+func stripBOM(text string) string {
+	return strings.TrimPrefix(text, utf8BOM)
+}
+
+// hasBOM reports whether the file at path starts with a byte order mark.
+func hasBOM(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	head := make([]byte, len(utf8BOM))
+	if _, err := io.ReadFull(f, head); err != nil {
+		return false
+	}
+
+	return bytes.Equal(head, []byte(utf8BOM))
+}
+
+// lineEnding returns the line ending of text: "\r\n" when its first line ends with one,
+// "\n" otherwise.
+func lineEnding(text string) string {
+	if i := strings.IndexByte(text, '\n'); i > 0 && text[i-1] == '\r' {
+		return "\r\n"
+	}
+
+	return "\n"
+}
+
+// withLineEnding makes the line endings of s eol.
+func withLineEnding(s, eol string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if eol == "\n" {
+		return s
+	}
+
+	return strings.ReplaceAll(s, "\n", eol)
+}
+
+// lineEnding returns the line ending of the text of uri, for the lines edits insert.
+func (h *langHandler) lineEnding(uri DocumentURI) string {
+	text, _ := h.documents.text(uri)
+
+	return lineEnding(text)
+}
+
+// mapBOMIssues moves the issues of the files of a run from dir that start with a byte order
+// mark to the document text clients see: golangci-lint counts the mark in the columns and
+// the source of the first line, and in every offset.
+func mapBOMIssues(dir string, issues []Issue) []Issue {
+	boms := make(map[string]bool)
+	for i := range issues {
+		issue := &issues[i]
+		path := issueFilePath(dir, issue)
+		bom, ok := boms[path]
+		if !ok {
+			bom = hasBOM(path)
+			boms[path] = bom
+		}
+		if !bom {
+			continue
+		}
+
+		issue.Pos.Offset = max(issue.Pos.Offset-len(utf8BOM), 0)
+		if issue.Pos.Line != 1 {
+			continue
+		}
+		if issue.Pos.Column > 0 {
+			issue.Pos.Column = max(issue.Pos.Column-len(utf8BOM), 1)
+		}
+		if len(issue.SourceLines) > 0 {
+			issue.SourceLines[0] = stripBOM(issue.SourceLines[0])
+		}
+		if r := issue.Replacement; r != nil {
+			if r.Inline != nil {
+				r.Inline.StartCol = max(r.Inline.StartCol-len(utf8BOM), 0)
+			} else if len(r.NewLines) > 0 {
+				r.NewLines[0] = stripBOM(r.NewLines[0])
+			}
+		}
+	}
+
+	return issues
+}
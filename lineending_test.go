@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lineEndingFixtures are the same file with and without a byte order mark and CRLF line
+// endings. They are built here rather than kept in testdata, where git may convert the
+// line endings.
+var lineEndingFixtures = []struct {
+	name string
+	bom  bool
+	eol  string
+}{
+	{name: "lf", eol: "\n"},
+	{name: "crlf", eol: "\r\n"},
+	{name: "bom lf", bom: true, eol: "\n"},
+	{name: "bom crlf", bom: true, eol: "\r\n"},
+}
+
+// fixtureText returns the text of a line ending fixture as stored on disk.
+func fixtureText(bom bool, eol string) string {
+	text := strings.Join([]string{"package a", "", "func F() { foo() }", ""}, eol)
+	if bom {
+		text = utf8BOM + text
+	}
+
+	return text
+}
+
+func TestLineEnding(t *testing.T) {
+	for _, fixture := range lineEndingFixtures {
+		if got := lineEnding(fixtureText(fixture.bom, fixture.eol)); got != fixture.eol {
+			t.Errorf("%s: lineEnding() = %q, want %q", fixture.name, got, fixture.eol)
+		}
+	}
+}
+
+func TestMapBOMIssues(t *testing.T) {
+	for _, fixture := range lineEndingFixtures {
+		t.Run(fixture.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte(fixtureText(fixture.bom, fixture.eol)), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			// golangci-lint counts the byte order mark in the columns and offsets it reports.
+			shift, source := 0, "package a"
+			if fixture.bom {
+				shift, source = len(utf8BOM), utf8BOM+source
+			}
+			var first, third Issue
+			first.Pos.Filename = "a.go"
+			first.Pos.Line = 1
+			first.Pos.Column = 9 + shift
+			first.Pos.Offset = 8 + shift
+			first.SourceLines = []string{source}
+			first.Replacement = &Replacement{Inline: &InlineFix{StartCol: 8 + shift, Length: 1, NewString: "b"}}
+			third.Pos.Filename = "a.go"
+			third.Pos.Line = 3
+			third.Pos.Column = 12
+			third.Pos.Offset = 11 + 2*len(fixture.eol) + shift
+
+			issues := mapBOMIssues(dir, []Issue{first, third})
+
+			if got := issues[0].Pos; got.Column != 9 || got.Offset != 8 {
+				t.Errorf("line 1: column %d, offset %d, want 9, 8", got.Column, got.Offset)
+			}
+			if got := issues[0].SourceLines[0]; got != "package a" {
+				t.Errorf("line 1: source %q", got)
+			}
+			if got := issues[0].Replacement.Inline.StartCol; got != 8 {
+				t.Errorf("line 1: inline fix at %d, want 8", got)
+			}
+			if got, want := issues[1].Pos.Offset, 11+2*len(fixture.eol); issues[1].Pos.Column != 12 || got != want {
+				t.Errorf("line 3: column %d, offset %d, want 12, %d", issues[1].Pos.Column, got, want)
+			}
+		})
+	}
+}
+
+func TestLineEndingEdits(t *testing.T) {
+	for _, fixture := range lineEndingFixtures {
+		t.Run(fixture.name, func(t *testing.T) {
+			h := newLangHandler(&testLogger{}, false)
+			uri := DocumentURI("file:///work/a.go")
+			h.documents.open(uri, fixtureText(fixture.bom, fixture.eol), 1)
+
+			issue := &Issue{FromLinter: "errcheck", SourceLines: []string{"func F() { foo() }"}}
+			issue.Pos.Line = 3
+
+			edit, err := h.noLintEdit(uri, issue)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := applyEdit("func F() { foo() }", edit, h.encoding), "func F() { foo() } //nolint:errcheck"; got != want {
+				t.Errorf("nolint: got %q, want %q", got, want)
+			}
+
+			issue.Replacement = &Replacement{NewLines: []string{"func F() {", "\t_ = foo()", "}"}}
+			edit = replacementEdit(issue, h.encoding, h.lineEnding(uri))
+			if want := strings.Join([]string{"func F() {", "\t_ = foo()", "}", ""}, fixture.eol); edit.NewText != want {
+				t.Errorf("replacement: got %q, want %q", edit.NewText, want)
+			}
+		})
+	}
+}
+
+// TestBOMDiagnostics checks the column of an issue on the first line of a file starting with
+// a byte order mark, which clients leave out of the document.
+func TestBOMDiagnostics(t *testing.T) {
+	for _, fixture := range lineEndingFixtures {
+		t.Run(fixture.name, func(t *testing.T) {
+			text := fixtureText(fixture.bom, fixture.eol)
+			var issue Issue
+			issue.FromLinter = "stylecheck"
+			issue.Text = "package name"
+			issue.Severity = "warning"
+			issue.Pos.Filename = "bom.go"
+			issue.Pos.Line = 1
+			issue.Pos.Column = 9
+			issue.SourceLines = []string{"package a"}
+			if fixture.bom {
+				issue.Pos.Column += len(utf8BOM)
+				issue.SourceLines[0] = utf8BOM + issue.SourceLines[0]
+			}
+
+			ts := newTestServer(t, testConfig{
+				files:  map[string]string{"bom.go": text},
+				runner: &fakeRunner{output: func(*exec.Cmd) string { return issuesOutput(t, issue) }},
+			})
+			ts.open("bom.go")
+			diagnostics := ts.waitPublished("bom.go")
+			if len(diagnostics) != 1 {
+				t.Fatalf("diagnostics %+v, want one", diagnostics)
+			}
+			if got := diagnostics[0].Range.Start; got != (Position{Line: 0, Character: 8}) {
+				t.Errorf("start %+v, want 0:8", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Package lint runs golangci-lint and decodes its output: the part of the language server's
+// lint pipeline that doesn't depend on LSP, for tools reusing it.
+package lint
+
+// Issue is an issue golangci-lint reported.
+type Issue struct {
+	FromLinter  string       `json:"FromLinter"`
+	Text        string       `json:"Text"`
+	Severity    string       `json:"Severity"`
+	SourceLines []string     `json:"SourceLines"`
+	Replacement *Replacement `json:"Replacement"`
+	Pos         struct {
+		Filename string `json:"Filename"`
+		Offset   int    `json:"Offset"`
+		Line     int    `json:"Line"`
+		Column   int    `json:"Column"`
+	} `json:"Pos"`
+	ExpectNoLint         bool   `json:"ExpectNoLint"`
+	ExpectedNoLintLinter string `json:"ExpectedNoLintLinter"`
+	LineRange            struct {
+		From int `json:"From"`
+		To   int `json:"To"`
+	} `json:"LineRange,omitempty"`
+
+	// The fields below are set while the issue is processed rather than decoded.
+
+	// Profile names the profile whose run reported the issue, if any.
+	Profile string `json:"-"`
+	// SeverityOverride is the severity a rule of the pathRules option sets, if any.
+	SeverityOverride string `json:"-"`
+	// Merged is the number of lines of the issues the mergeConsecutiveIssues option merged
+	// into this one, spanning its LineRange, if any.
+	Merged int `json:"-"`
+}
+
+// Replacement is the suggested fix of an issue.
+type Replacement struct {
+	NeedOnlyDelete bool       `json:"NeedOnlyDelete"`
+	NewLines       []string   `json:"NewLines"`
+	Inline         *InlineFix `json:"Inline"`
+}
+
+// InlineFix replaces part of a line.
+type InlineFix struct {
+	StartCol  int    `json:"StartCol"` // zero-based
+	Length    int    `json:"Length"`
+	NewString string `json:"NewString"`
+}
+
+// Result is the JSON output of golangci-lint, of which only the issues are decoded.
+type Result struct {
+	Issues []Issue `json:"Issues"`
+	// Skipped holds the decode errors of the issues that were left out.
+	Skipped []error `json:"-"`
+	// Hidden holds the issues the options hide from the editor.
+	Hidden []Issue `json:"-"`
+	// Panics holds the linters golangci-lint logged a panic of, whose issues are missing.
+	Panics []Panic `json:"-"`
+	Report struct {
+		Linters []struct {
+			Name             string `json:"Name"`
+			Enabled          bool   `json:"Enabled"`
+			EnabledByDefault bool   `json:"EnabledByDefault,omitempty"`
+		} `json:"Linters"`
+		// Error is the error of a failed run, e.g. "no go files to analyze".
+		Error string `json:"Error,omitempty"`
+	} `json:"Report"`
+}
+
+// WithoutSources returns a copy of r whose issues carry no SourceLines, for logging.
+func (r *Result) WithoutSources() *Result {
+	redacted := *r
+	redacted.Issues = make([]Issue, len(r.Issues))
+	for i, issue := range r.Issues {
+		issue.SourceLines = nil
+		redacted.Issues[i] = issue
+	}
+
+	return &redacted
+}
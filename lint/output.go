@@ -0,0 +1,286 @@
+package lint
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// DefaultMaxOutputSize is the default limit of the output read from golangci-lint.
+const DefaultMaxOutputSize = 64 << 20
+
+// ErrOutputTooLarge is the error of an output exceeding its size limit.
+var ErrOutputTooLarge = errors.New("golangci-lint output exceeds the size limit")
+
+// limitReader fails with ErrOutputTooLarge instead of io.EOF once more than n bytes were read.
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrOutputTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrOutputTooLarge
+	}
+
+	return n, err
+}
+
+// StripANSI returns a reader dropping the ANSI escape sequences of r.
+func StripANSI(r io.Reader) io.Reader {
+	return &ansiStripper{r: r}
+}
+
+// ansiStripper drops ANSI escape sequences, which wrapper scripts forcing color leave in
+// stdout. Valid JSON never holds a raw ESC, so the document itself is left untouched.
+type ansiStripper struct {
+	r     io.Reader
+	state int // 0: text, 1: after ESC, 2: inside a CSI sequence
+}
+
+func (a *ansiStripper) Read(p []byte) (int, error) {
+	for {
+		n, err := a.r.Read(p)
+
+		kept := 0
+		for _, b := range p[:n] {
+			switch {
+			case a.state == 0 && b == 0x1b:
+				a.state = 1
+			case a.state == 0:
+				p[kept] = b
+				kept++
+			case a.state == 1 && b == '[':
+				a.state = 2
+			case a.state == 1, b >= 0x40 && b <= 0x7e:
+				a.state = 0
+			}
+		}
+
+		if kept > 0 || err != nil {
+			return kept, err
+		}
+	}
+}
+
+// maxPreamble bounds how much of the text before the JSON document is kept for the logs.
+const maxPreamble = 1024
+
+// skipToJSON discards the lines before the first one starting with '{', such as warnings
+// printed to stdout, and returns the reader positioned at the JSON document together with
+// what was skipped. It returns io.EOF when r holds nothing but blank lines.
+func skipToJSON(r io.Reader) (io.Reader, string, error) {
+	br := bufio.NewReader(r)
+
+	var preamble bytes.Buffer
+	lineStart := true
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(bytes.TrimSpace(preamble.Bytes())) > 0 {
+				return nil, preamble.String(), fmt.Errorf("no JSON in golangci-lint output: %q", bytes.TrimSpace(preamble.Bytes()))
+			}
+
+			return nil, preamble.String(), err
+		}
+
+		switch {
+		case lineStart && b == '{':
+			_ = br.UnreadByte()
+
+			return br, preamble.String(), nil
+		case b == '\n':
+			lineStart = true
+		case b != ' ' && b != '\t' && b != '\r':
+			lineStart = false
+		}
+
+		if preamble.Len() < maxPreamble {
+			preamble.WriteByte(b)
+		}
+	}
+}
+
+// ReadOutput decodes the JSON document golangci-lint printed to stdout, failing with
+// ErrOutputTooLarge after limit bytes. Lines before the document, such as warnings, are
+// skipped and returned; the output after it, such as a stats footer, is drained so that
+// golangci-lint doesn't block on a full pipe. It returns io.EOF when stdout is empty.
+func ReadOutput(stdout io.Reader, limit int64) (*Result, string, error) {
+	body, preamble, err := skipToJSON(StripANSI(&limitReader{r: stdout, n: limit}))
+	if err != nil {
+		return nil, preamble, err
+	}
+
+	result, err := Decode(body)
+	if err == nil {
+		_, err = io.Copy(ioutil.Discard, body)
+	}
+	if err != nil {
+		return nil, preamble, err
+	}
+
+	return result, preamble, nil
+}
+
+// Decode streams the JSON output of golangci-lint and keeps only the Issues,
+// so the Report part never has to be held in memory.
+// It returns io.EOF when r is empty, and io.ErrUnexpectedEOF when the document is cut off.
+func Decode(r io.Reader) (*Result, error) {
+	dec := json.NewDecoder(r)
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	result, err := decodeResult(dec)
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return result, err
+}
+
+// decodeResult decodes the document after its opening brace.
+func decodeResult(dec *json.Decoder) (*Result, error) {
+	var result Result
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		if key, _ := tok.(string); key != "Issues" {
+			if err := skipValue(dec); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if err := decodeIssues(dec, &result); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func decodeIssues(dec *json.Decoder, result *Result) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return fmt.Errorf("unexpected %v for Issues", tok)
+	}
+
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+
+		issue, err := decodeIssue(raw)
+		if err != nil {
+			result.Skipped = append(result.Skipped, err)
+
+			continue
+		}
+		result.Issues = append(result.Issues, issue)
+	}
+
+	return expectDelim(dec, ']')
+}
+
+// optionalIssueFields are the Issue fields that changed shape across golangci-lint releases.
+// An issue whose value of one of them doesn't fit is kept without it.
+var optionalIssueFields = []string{"Severity", "SourceLines", "Replacement", "LineRange", "ExpectNoLint", "ExpectedNoLintLinter"}
+
+// decodeIssue decodes one issue leniently: unknown fields are ignored and optional fields
+// that don't decode are dropped. It fails only when the position, text or linter is malformed.
+func decodeIssue(raw json.RawMessage) (Issue, error) {
+	var issue Issue
+	err := json.Unmarshal(raw, &issue)
+	if err == nil {
+		return issue, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) != nil {
+		return Issue{}, fmt.Errorf("malformed issue %s: %w", raw, err)
+	}
+
+	for _, name := range optionalIssueFields {
+		value, ok := fields[name]
+		if !ok {
+			continue
+		}
+
+		probe, _ := json.Marshal(map[string]json.RawMessage{name: value})
+		if json.Unmarshal(probe, &Issue{}) != nil {
+			delete(fields, name)
+		}
+	}
+
+	trimmed, _ := json.Marshal(fields)
+	issue = Issue{}
+	if err := json.Unmarshal(trimmed, &issue); err != nil {
+		return Issue{}, fmt.Errorf("malformed issue %s: %w", raw, err)
+	}
+
+	return issue, nil
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("unexpected %v in golangci-lint output, expected %v", tok, want)
+	}
+
+	return nil
+}
+
+// skipValue consumes the next value token by token.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package lint
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// normalizedIssue is the issue every fixture of testdata/output reports.
+func normalizedIssue() Issue {
+	var issue Issue
+	issue.FromLinter = "errcheck"
+	issue.Text = "Error return value of `f` is not checked"
+	issue.SourceLines = []string{"\tf()"}
+	issue.Pos.Filename = "pkg/a.go"
+	issue.Pos.Offset = 42
+	issue.Pos.Line = 5
+	issue.Pos.Column = 3
+	issue.LineRange.From = 5
+	issue.LineRange.To = 5
+
+	return issue
+}
+
+func TestDecodeReleases(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		wantSkipped int
+	}{
+		{fixture: "v1.50"},
+		{fixture: "v1.55"},
+		{fixture: "v1.60"},
+		{fixture: "v2.1"},
+		// Optional fields of another shape are dropped, an issue without a position is skipped.
+		{fixture: "drift", wantSkipped: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", "output", tt.fixture+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			result, err := Decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []Issue{normalizedIssue()}; !reflect.DeepEqual(result.Issues, want) {
+				t.Errorf("Issues = %+v, want %+v", result.Issues, want)
+			}
+			if len(result.Skipped) != tt.wantSkipped {
+				t.Errorf("skipped %v, want %d issues", result.Skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestReadOutput(t *testing.T) {
+	tests := []struct {
+		fixture      string
+		wantPreamble string
+	}{
+		{fixture: "v1.60.json"},
+		{
+			fixture: "colored.txt",
+			wantPreamble: "WARN [config_reader] The configuration option `run.skip-dirs` is deprecated, please use `issues.exclude-dirs`.\n" +
+				"WARN The linter 'exportloopref' is deprecated (since v1.60.2) due to: Since Go1.22 (loopvar) this linter is no longer relevant.\n",
+		},
+		{fixture: "footer.txt"},
+		{fixture: "colored-footer.txt", wantPreamble: "WARN [runner] Can't run linter goanalysis_metalinter\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", "output", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			result, preamble, err := ReadOutput(f, DefaultMaxOutputSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []Issue{normalizedIssue()}; !reflect.DeepEqual(result.Issues, want) {
+				t.Errorf("Issues = %+v, want %+v", result.Issues, want)
+			}
+			if preamble != tt.wantPreamble {
+				t.Errorf("preamble %q, want %q", preamble, tt.wantPreamble)
+			}
+			if n, _ := f.Read(make([]byte, 1)); n != 0 {
+				t.Error("the output after the document wasn't drained")
+			}
+		})
+	}
+}
+
+func TestReadOutputErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		limit   int64
+		wantErr string
+	}{
+		{name: "empty", output: "", wantErr: io.EOF.Error()},
+		{name: "blank lines", output: "\n  \n", wantErr: io.EOF.Error()},
+		{name: "no JSON", output: "\x1b[31mERRO\x1b[0m Running error: context loading failed\n", wantErr: `no JSON in golangci-lint output: "ERRO Running error: context loading failed"`},
+		{name: "too large", output: `{"Issues":[]}` + "\n" + strings.Repeat("* errcheck: 1\n", 10), limit: 32, wantErr: ErrOutputTooLarge.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := tt.limit
+			if limit == 0 {
+				limit = DefaultMaxOutputSize
+			}
+			_, _, err := ReadOutput(strings.NewReader(tt.output), limit)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ReadOutput() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestDecodeCutOff checks that a document cut off after its opening brace fails, rather than
+// passing for the empty output of a critical error.
+func TestDecodeCutOff(t *testing.T) {
+	for _, output := range []string{"{", "{\n", `{"Issues"`, `{"Issues":[{"FromLinter":"errcheck"},`, `{"Issues":[]`} {
+		_, err := Decode(strings.NewReader(output))
+		if err == nil || errors.Is(err, io.EOF) {
+			t.Errorf("Decode(%q) error = %v, want an unexpected end", output, err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package lint
+
+import (
+	"bytes"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// Panic is a linter that panicked while golangci-lint still printed a result.
+type Panic struct {
+	Linter string `json:"linter"`
+	// Package is the import path of the package being analyzed, if known.
+	Package string `json:"package,omitempty"`
+}
+
+var (
+	// runnerPanic matches the panics the runner of golangci-lint recovers:
+	// ERRO [runner] Panic: gocritic: package "example.com/foo" (isInitialPkg: true, needAnalyzeSource: true): runtime error: ...
+	// level=error msg="[runner] Panic: unused: package \"example.com/foo\" (isInitialPkg: true, needAnalyzeSource: true): ..."
+	runnerPanic = regexp.MustCompile(`\[runner\] Panic: ([\w-]+): package \\?"([^"\\]+)\\?"`)
+	// analyzerPanic matches the panics of the analyzers of a linter, named after "Can't run linter":
+	// WARN [runner] Can't run linter staticcheck: buildir: package "example.com/foo" (...): in example.com/foo.F: panic: ...
+	analyzerPanic = regexp.MustCompile(`Can't run linter ([\w-]+): (?:[\w-]+: )*package \\?"([^"\\]+)\\?".*\bpanic\b`)
+	// linterRunPanic matches the panics of linters run without the analysis framework:
+	// WARN [runner] Can't run linter gocyclo: panic occurred: runtime error: ...
+	linterRunPanic = regexp.MustCompile(`Can't run linter ([\w-]+): .*\bpanic\b`)
+)
+
+// metalinter is the linter golangci-lint runs the analyzers of all the analysis linters in.
+const metalinter = "goanalysis_metalinter"
+
+// ParsePanics returns the linters whose panic golangci-lint logged to stderr, each once.
+func ParsePanics(stderr []byte) []Panic {
+	stripped, err := ioutil.ReadAll(StripANSI(bytes.NewReader(stderr)))
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[Panic]struct{})
+	var panics []Panic
+	for _, line := range strings.Split(string(stripped), "\n") {
+		var p Panic
+		if m := runnerPanic.FindStringSubmatch(line); m != nil {
+			p = Panic{Linter: m[1], Package: m[2]}
+		} else if m := analyzerPanic.FindStringSubmatch(line); m != nil {
+			p = Panic{Linter: m[1], Package: m[2]}
+		} else if m := linterRunPanic.FindStringSubmatch(line); m != nil {
+			p = Panic{Linter: m[1]}
+		} else {
+			continue
+		}
+		if p.Linter == metalinter {
+			// The Panic line of the runner names the analyzer that panicked.
+			continue
+		}
+
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		panics = append(panics, p)
+	}
+
+	return panics
+}
@@ -0,0 +1,37 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParsePanics(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []Panic
+	}{
+		// An analysis linter panicking inside the metalinter.
+		{fixture: "gocritic.txt", want: []Panic{{Linter: "gocritic", Package: "foo"}}},
+		// A linter failing on the panic of one of its analyzers, in logfmt.
+		{fixture: "staticcheck.txt", want: []Panic{{Linter: "staticcheck", Package: "example.com/foo/internal/store"}}},
+		// A linter run without the analysis framework.
+		{fixture: "gocyclo.txt", want: []Panic{{Linter: "gocyclo"}}},
+		{fixture: "colored.txt", want: []Panic{{Linter: "unused", Package: "example.com/foo"}}},
+		// A failure to load a package isn't a panic.
+		{fixture: "load-error.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			stderr, err := os.ReadFile(filepath.Join("testdata", "panics", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := ParsePanics(stderr); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePanics() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
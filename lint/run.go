@@ -0,0 +1,103 @@
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os/exec"
+)
+
+// Runner starts golangci-lint, so that tests and recordings can substitute their own.
+type Runner interface {
+	// Start starts cmd and returns its output and a function waiting for it to exit.
+	Start(cmd *exec.Cmd) (stdout io.Reader, wait func() error, err error)
+}
+
+// ExecRunner runs the real golangci-lint.
+type ExecRunner struct{}
+
+// Start implements Runner.
+func (ExecRunner) Start(cmd *exec.Cmd) (io.Reader, func() error, error) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	return stdout, cmd.Wait, nil
+}
+
+// Options configures Run.
+type Options struct {
+	// MaxOutputSize is the limit of the output read, DefaultMaxOutputSize if 0.
+	MaxOutputSize int64
+	// Started, if set, is called once cmd started.
+	Started func(cmd *exec.Cmd)
+}
+
+// Output is what golangci-lint printed besides the result.
+type Output struct {
+	// Preamble is the output skipped before the JSON document.
+	Preamble string
+	// Stderr is the standard error of golangci-lint.
+	Stderr []byte
+}
+
+// Run runs cmd with runner and decodes its JSON output, killing it when the output
+// exceeds the size limit or doesn't decode. An *exec.ExitError it returns carries the standard error.
+//
+// golangci-lint exits with a non-zero code when it reports issues, so the exit code is
+// ignored once a result was decoded; when golangci-lint printed nothing, which it does on
+// critical errors, the error of the process is returned instead.
+func Run(runner Runner, cmd *exec.Cmd, opts Options) (*Result, Output, error) {
+	var stderr bytes.Buffer
+	if cmd.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, &stderr)
+	} else {
+		cmd.Stderr = &stderr
+	}
+
+	stdout, wait, err := runner.Start(cmd)
+	if err != nil {
+		return nil, Output{}, err
+	}
+	if opts.Started != nil {
+		opts.Started(cmd)
+	}
+
+	limit := opts.MaxOutputSize
+	if limit <= 0 {
+		limit = DefaultMaxOutputSize
+	}
+	result, preamble, decodeErr := ReadOutput(stdout, limit)
+	if decodeErr != nil && decodeErr != io.EOF {
+		// golangci-lint may still be writing, and never exits while blocked on a full pipe.
+		if cmd.Process != nil {
+			_ = cmd.Process.Kill()
+		}
+		_, _ = io.Copy(ioutil.Discard, stdout)
+	}
+
+	err = wait()
+	out := Output{Preamble: preamble, Stderr: stderr.Bytes()}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitErr.Stderr = out.Stderr
+	}
+
+	switch {
+	case decodeErr == io.EOF:
+		// golangci-lint would output critical error to stderr rather than stdout
+		// https://github.com/nametake/golangci-lint-langserver/issues/24
+		return nil, out, err
+	case decodeErr != nil:
+		return nil, out, decodeErr
+	}
+
+	result.Panics = ParsePanics(out.Stderr)
+
+	return result, out, nil
+}
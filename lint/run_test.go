@@ -0,0 +1,158 @@
+package lint
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRunner prints stdout and stderr instead of running golangci-lint and exits with err.
+type fakeRunner struct {
+	stdout string
+	stderr string
+	err    error
+}
+
+func (r fakeRunner) Start(cmd *exec.Cmd) (io.Reader, func() error, error) {
+	if _, err := io.WriteString(cmd.Stderr, r.stderr); err != nil {
+		return nil, nil, err
+	}
+
+	return strings.NewReader(r.stdout), func() error { return r.err }, nil
+}
+
+func TestRun(t *testing.T) {
+	errExit := errors.New("exit status 3")
+	tests := []struct {
+		name         string
+		runner       fakeRunner
+		limit        int64
+		wantIssues   []string
+		wantPanics   []Panic
+		wantPreamble string
+		wantErr      error
+	}{
+		{
+			name:       "issues",
+			runner:     fakeRunner{stdout: `{"Issues":[{"FromLinter":"errcheck","Text":"unchecked"}]}`, err: errExit},
+			wantIssues: []string{"errcheck: unchecked"},
+		},
+		{
+			name: "warnings and footer",
+			runner: fakeRunner{
+				stdout: "level=warning msg=\"[config_reader] deprecated\"\n" + `{"Issues":[]}` + "\n1 issues:\n* errcheck: 1\n",
+			},
+			wantPreamble: "level=warning msg=\"[config_reader] deprecated\"\n",
+		},
+		{
+			name: "panic",
+			runner: fakeRunner{
+				stdout: `{"Issues":[]}`,
+				stderr: "ERRO [runner] Panic: gocritic: package \"example.com/foo\" (isInitialPkg: true, needAnalyzeSource: true): runtime error\n",
+			},
+			wantPanics: []Panic{{Linter: "gocritic", Package: "example.com/foo"}},
+		},
+		{
+			name:    "nothing on stdout",
+			runner:  fakeRunner{stderr: "level=error msg=\"Running error: context loading failed\"\n", err: errExit},
+			wantErr: errExit,
+		},
+		{
+			name:    "too large",
+			runner:  fakeRunner{stdout: `{"Issues":[{"FromLinter":"errcheck","Text":"unchecked"}]}`},
+			limit:   8,
+			wantErr: ErrOutputTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, out, err := Run(tt.runner, exec.Command("golangci-lint"), Options{MaxOutputSize: tt.limit})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Run() error = %v, want %v", err, tt.wantErr)
+			}
+			if string(out.Stderr) != tt.runner.stderr {
+				t.Errorf("Stderr = %q, want %q", out.Stderr, tt.runner.stderr)
+			}
+			if err != nil {
+				return
+			}
+
+			var issues []string
+			for _, issue := range result.Issues {
+				issues = append(issues, issue.FromLinter+": "+issue.Text)
+			}
+			if !reflect.DeepEqual(issues, tt.wantIssues) {
+				t.Errorf("issues %q, want %q", issues, tt.wantIssues)
+			}
+			if !reflect.DeepEqual(result.Panics, tt.wantPanics) {
+				t.Errorf("Panics = %v, want %v", result.Panics, tt.wantPanics)
+			}
+			if out.Preamble != tt.wantPreamble {
+				t.Errorf("Preamble = %q, want %q", out.Preamble, tt.wantPreamble)
+			}
+		})
+	}
+}
+
+func TestRunStarted(t *testing.T) {
+	cmd := exec.Command("golangci-lint")
+	var started *exec.Cmd
+	_, _, err := Run(fakeRunner{stdout: `{"Issues":[]}`}, cmd, Options{Started: func(c *exec.Cmd) { started = c }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started != cmd {
+		t.Error("Started wasn't called with the command")
+	}
+}
+
+// pipeRunner writes stdout to a pipe, like golangci-lint, and doesn't exit before all of it
+// was read.
+type pipeRunner struct {
+	stdout string
+}
+
+func (r pipeRunner) Start(*exec.Cmd) (io.Reader, func() error, error) {
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = io.WriteString(pw, r.stdout)
+		pw.Close()
+	}()
+
+	return pr, func() error {
+		<-done
+
+		return nil
+	}, nil
+}
+
+func TestRunCutOff(t *testing.T) {
+	result, _, err := Run(fakeRunner{stdout: "{"}, exec.Command("golangci-lint"), Options{})
+	if err == nil || result != nil {
+		t.Errorf("Run() = %v, %v, want an error for the output cut off", result, err)
+	}
+}
+
+func TestRunDrainsAfterDecodeError(t *testing.T) {
+	stdout := `{"Issues":[}` + strings.Repeat("\n", 1<<20)
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := Run(pipeRunner{stdout: stdout}, exec.Command("golangci-lint"), Options{})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Run() succeeded on malformed output")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run() didn't return: the output after the decode error wasn't read")
+	}
+}
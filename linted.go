@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// lintedTexts remembers the text each document had when it was last linted, with the
+// diagnostics that run published for it, so that saves without changes need no run.
+type lintedTexts struct {
+	mu    sync.Mutex
+	texts map[DocumentURI]lintedText
+}
+
+type lintedText struct {
+	hash        [sha256.Size]byte
+	diagnostics []Diagnostic
+}
+
+func newLintedTexts() *lintedTexts {
+	return &lintedTexts{
+		texts: make(map[DocumentURI]lintedText),
+	}
+}
+
+func (l *lintedTexts) record(uri DocumentURI, text string, diagnostics []Diagnostic) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.texts[uri] = lintedText{hash: sha256.Sum256([]byte(text)), diagnostics: diagnostics}
+}
+
+// unchanged returns the diagnostics of the last lint of uri if it linted text.
+func (l *lintedTexts) unchanged(uri DocumentURI, text string) ([]Diagnostic, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	linted, ok := l.texts[uri]
+	if !ok || linted.hash != sha256.Sum256([]byte(text)) {
+		return nil, false
+	}
+
+	return linted.diagnostics, true
+}
+
+func (l *lintedTexts) forget(uri DocumentURI) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.texts, uri)
+}
+
+func (l *lintedTexts) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.texts = make(map[DocumentURI]lintedText)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxErrorSummaryLines and maxErrorMessage bound the message of a failed run; the diagnostic
+	// data keeps everything.
+	maxErrorSummaryLines = 5
+	maxErrorMessage      = 2000
+)
+
+// positionedErrorRegexp matches a compiler error positioned in a Go file, e.g.
+// "foo/bar.go:12:3: undefined: x", which is clearer without the context of the run.
+var positionedErrorRegexp = regexp.MustCompile(`(?m)^\S+\.go:\d+:\d+: `)
+
+// LintErrorDetails describes a failed golangci-lint run.
+type LintErrorDetails struct {
+	Message string   `json:"message"`
+	Args    []string `json:"args"`
+	Dir     string   `json:"dir"`
+	// Exit is the exit status or the signal that ended golangci-lint, if it ran.
+	Exit string `json:"exit,omitempty"`
+}
+
+func newLintErrorDetails(err error, message string, run *runInfo) *LintErrorDetails {
+	details := &LintErrorDetails{Message: message, Args: run.Args, Dir: run.Dir}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if signal, ok := exitSignal(exitErr); ok {
+			details.Exit = "signal " + signal
+		} else {
+			details.Exit = strconv.Itoa(exitErr.ExitCode())
+		}
+	}
+
+	return details
+}
+
+// summary returns the first lines of the message followed by the command, its directory and
+// how it exited, cut to maxErrorMessage.
+func (d *LintErrorDetails) summary() string {
+	lines := strings.Split(strings.TrimSpace(d.Message), "\n")
+	if len(lines) > maxErrorSummaryLines {
+		lines = append(lines[:maxErrorSummaryLines], "…")
+	}
+
+	args := make([]string, len(d.Args))
+	for i, arg := range d.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			arg = strconv.Quote(arg)
+		}
+		args[i] = arg
+	}
+
+	summary := strings.Join(lines, "\n") + fmt.Sprintf("\ncommand: %s\ndir: %s", strings.Join(args, " "), d.Dir)
+	if d.Exit != "" {
+		summary += "\nexit: " + d.Exit
+	}
+	if len(summary) > maxErrorMessage {
+		summary = truncateUTF8(summary, maxErrorMessage) + "…"
+	}
+
+	return summary
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character.
+func truncateUTF8(s string, n int) string {
+	for n > 0 && n < len(s) && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
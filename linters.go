@@ -0,0 +1,36 @@
+package main
+
+import "strings"
+
+// CustomLinter describes a linter unknown to the server, such as a module plugin built
+// with `golangci-lint custom`.
+type CustomLinter struct {
+	DocsURL         string `json:"docsUrl"`
+	DefaultSeverity string `json:"defaultSeverity"`
+	Tag             string `json:"tag"`
+}
+
+var diagnosticTags = map[string]DiagnosticTag{
+	"unnecessary": DTUnnecessary,
+	"deprecated":  DTDeprecated,
+}
+
+// customLinter returns the metadata configured for the linter.
+func (o Options) customLinter(name string) (CustomLinter, bool) {
+	for linter, custom := range o.CustomLinters {
+		if strings.EqualFold(linter, name) {
+			return custom, true
+		}
+	}
+
+	return CustomLinter{}, false
+}
+
+// docsURL returns the documentation page of a rule or linter, preferring the one configured for custom linters.
+func (h *langHandler) docsURL(args RuleDocsArgs) (string, bool) {
+	if custom, ok := h.currentOptions().customLinter(args.Linter); ok && custom.DocsURL != "" {
+		return custom.DocsURL, true
+	}
+
+	return ruleDocsURL(args)
+}
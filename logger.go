@@ -2,8 +2,12 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 var _ logger = (*stdLogger)(nil)
@@ -13,24 +17,43 @@ type logger interface {
 	DebugJSON(label string, arg interface{})
 }
 
+// recentLogLines is the number of log lines kept for golangci-lint.captureDiagnosticsBundle.
+const recentLogLines = 200
+
+const (
+	logLevelDebug = "debug"
+	logLevelInfo  = "info"
+)
+
+// levelLogger is implemented by loggers whose level can change at runtime.
+type levelLogger interface {
+	setLevel(level string) bool
+	level() string
+}
+
 type stdLogger struct {
-	debug  bool
+	// debug is 1 when DebugJSON logs, changed at runtime by golangci-lint/setLogLevel and SIGUSR1.
+	debug  int32
 	stderr *log.Logger
+	recent logRing
 }
 
 func newStdLogger(debug bool) *stdLogger {
-	return &stdLogger{
-		debug:  debug,
-		stderr: log.New(os.Stderr, "", 0),
+	l := &stdLogger{stderr: log.New(os.Stderr, "", 0)}
+	if debug {
+		l.debug = 1
 	}
+
+	return l
 }
 
 func (l *stdLogger) Printf(format string, args ...interface{}) {
+	l.recent.add(fmt.Sprintf(format, args...))
 	l.stderr.Printf(format, args...)
 }
 
 func (l *stdLogger) DebugJSON(label string, arg interface{}) {
-	if !l.debug {
+	if atomic.LoadInt32(&l.debug) == 0 {
 		return
 	}
 
@@ -39,5 +62,58 @@ func (l *stdLogger) DebugJSON(label string, arg interface{}) {
 		l.stderr.Println(err)
 	}
 
+	l.recent.add(label + " " + string(b))
 	l.stderr.Println(label, string(b))
 }
+
+// setLevel sets the level to debug or info, reporting false for any other level.
+func (l *stdLogger) setLevel(level string) bool {
+	switch level {
+	case logLevelDebug:
+		atomic.StoreInt32(&l.debug, 1)
+	case logLevelInfo:
+		atomic.StoreInt32(&l.debug, 0)
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (l *stdLogger) level() string {
+	if atomic.LoadInt32(&l.debug) == 1 {
+		return logLevelDebug
+	}
+
+	return logLevelInfo
+}
+
+// lines returns the last recentLogLines lines written.
+func (l *stdLogger) lines() []string {
+	return l.recent.list()
+}
+
+// logRing keeps the last recentLogLines lines of the log.
+type logRing struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (r *logRing) add(s string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		if len(r.lines) == recentLogLines {
+			r.lines = r.lines[1:]
+		}
+		r.lines = append(r.lines, line)
+	}
+}
+
+func (r *logRing) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string{}, r.lines...)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+// SetLogLevelParams is the params of the golangci-lint/setLogLevel notification.
+type SetLogLevelParams struct {
+	// Level is "debug" or "info".
+	Level string `json:"level"`
+}
+
+func (h *langHandler) handleSetLogLevel(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params SetLogLevelParams
+	if err := decodeCustomParams(req, &params); err != nil {
+		return nil, err
+	}
+
+	if err := h.setLogLevel(params.Level); err != nil {
+		h.notifyError(err.Error())
+
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// setLogLevel changes the level of the log without restarting the server, so that debugging
+// keeps the state that reproduced a problem.
+func (h *langHandler) setLogLevel(level string) error {
+	l, ok := h.logger.(levelLogger)
+	if !ok || !l.setLevel(level) {
+		return &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: h.catalog().Sprintf(messages.InvalidLogLevel, level)}
+	}
+
+	h.logger.Printf("golangci-lint-langserver: log level set to %s", level)
+	h.showMessage(MTInfo, h.catalog().Sprintf(messages.LogLevelChanged, level))
+
+	return nil
+}
+
+// toggleLogLevel switches the level between debug and info.
+func (h *langHandler) toggleLogLevel() {
+	l, ok := h.logger.(levelLogger)
+	if !ok {
+		return
+	}
+
+	level := logLevelDebug
+	if l.level() == logLevelDebug {
+		level = logLevelInfo
+	}
+	_ = h.setLogLevel(level)
+}
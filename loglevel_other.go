@@ -0,0 +1,7 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package main
+
+// watchLogLevelSignal does nothing: Windows and Plan 9 have no SIGUSR1, golangci-lint/setLogLevel remains.
+func watchLogLevelSignal(*langHandler) {}
@@ -1,18 +1,81 @@
 package main
 
+import "encoding/json"
+
 type DocumentURI string
 
 type InitializeParams struct {
-	RootURI               string                `json:"rootUri,omitempty"`
-	InitializationOptions InitializationOptions `json:"initializationOptions,omitempty"`
+	RootURI               string             `json:"rootUri,omitempty"`
+	InitializationOptions json.RawMessage    `json:"initializationOptions,omitempty"`
+	Capabilities          ClientCapabilities `json:"capabilities,omitempty"`
+	Locale                string             `json:"locale,omitempty"`
+	WorkspaceFolders      []WorkspaceFolder  `json:"workspaceFolders,omitempty"`
+}
+
+type WorkspaceFolder struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+type DidChangeWorkspaceFoldersParams struct {
+	Event struct {
+		Added   []WorkspaceFolder `json:"added"`
+		Removed []WorkspaceFolder `json:"removed"`
+	} `json:"event"`
+}
+
+type ClientCapabilities struct {
+	General      GeneralClientCapabilities      `json:"general,omitempty"`
+	TextDocument TextDocumentClientCapabilities `json:"textDocument,omitempty"`
+	Workspace    WorkspaceClientCapabilities    `json:"workspace,omitempty"`
+	Window       WindowClientCapabilities       `json:"window,omitempty"`
+}
+
+type GeneralClientCapabilities struct {
+	PositionEncodings []string `json:"positionEncodings,omitempty"`
+}
+
+type WindowClientCapabilities struct {
+	WorkDoneProgress bool `json:"workDoneProgress,omitempty"`
+	ShowDocument     struct {
+		Support bool `json:"support,omitempty"`
+	} `json:"showDocument,omitempty"`
+}
+
+type TextDocumentClientCapabilities struct {
+	CodeAction CodeActionClientCapabilities `json:"codeAction,omitempty"`
+	Diagnostic *struct{}                    `json:"diagnostic,omitempty"`
+}
+
+type WorkspaceClientCapabilities struct {
+	InlayHint struct {
+		RefreshSupport bool `json:"refreshSupport,omitempty"`
+	} `json:"inlayHint,omitempty"`
+	Diagnostics struct {
+		RefreshSupport bool `json:"refreshSupport,omitempty"`
+	} `json:"diagnostics,omitempty"`
+	DidChangeWatchedFiles struct {
+		DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+	} `json:"didChangeWatchedFiles,omitempty"`
 }
 
-type InitializationOptions struct {
-	Command []string
+type CodeActionClientCapabilities struct {
+	DataSupport    bool `json:"dataSupport,omitempty"`
+	ResolveSupport *struct {
+		Properties []string `json:"properties"`
+	} `json:"resolveSupport,omitempty"`
 }
 
 type InitializeResult struct {
 	Capabilities ServerCapabilities `json:"capabilities,omitempty"`
+	ServerInfo   *ServerInfo        `json:"serverInfo,omitempty"`
+}
+
+type ServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	// DebugAddr is the address of the -debug-addr HTTP server, if any.
+	DebugAddr string `json:"debugAddr,omitempty"`
 }
 
 type TextDocumentSyncKind int
@@ -34,17 +97,166 @@ type TextDocumentSyncOptions struct {
 	Change            TextDocumentSyncKind `json:"change,omitempty"`
 	WillSave          bool                 `json:"willSave,omitempty"`
 	WillSaveWaitUntil bool                 `json:"willSaveWaitUntil,omitempty"`
-	Save              bool                 `json:"save,omitempty"`
+	Save              *SaveOptions         `json:"save,omitempty"`
+}
+
+type SaveOptions struct {
+	IncludeText bool `json:"includeText,omitempty"`
 }
 
 type ServerCapabilities struct {
-	TextDocumentSync           TextDocumentSyncOptions `json:"textDocumentSync,omitempty"`
-	CompletionProvider         *CompletionProvider     `json:"completionProvider,omitempty"`
-	DocumentSymbolProvider     bool                    `json:"documentSymbolProvider,omitempty"`
-	DefinitionProvider         bool                    `json:"definitionProvider,omitempty"`
-	DocumentFormattingProvider bool                    `json:"documentFormattingProvider,omitempty"`
-	HoverProvider              bool                    `json:"hoverProvider,omitempty"`
-	CodeActionProvider         bool                    `json:"codeActionProvider,omitempty"`
+	PositionEncoding           string                       `json:"positionEncoding,omitempty"`
+	TextDocumentSync           TextDocumentSyncOptions      `json:"textDocumentSync,omitempty"`
+	CompletionProvider         *CompletionProvider          `json:"completionProvider,omitempty"`
+	DocumentSymbolProvider     bool                         `json:"documentSymbolProvider,omitempty"`
+	DefinitionProvider         bool                         `json:"definitionProvider,omitempty"`
+	DocumentFormattingProvider bool                         `json:"documentFormattingProvider,omitempty"`
+	HoverProvider              bool                         `json:"hoverProvider,omitempty"`
+	CodeActionProvider         *CodeActionOptions           `json:"codeActionProvider,omitempty"`
+	ExecuteCommandProvider     *ExecuteCommandOptions       `json:"executeCommandProvider,omitempty"`
+	DiagnosticProvider         *DiagnosticOptions           `json:"diagnosticProvider,omitempty"`
+	Workspace                  *WorkspaceServerCapabilities `json:"workspace,omitempty"`
+	InlayHintProvider          bool                         `json:"inlayHintProvider,omitempty"`
+	DocumentLinkProvider       *DocumentLinkOptions         `json:"documentLinkProvider,omitempty"`
+	Experimental               *ExperimentalCapabilities    `json:"experimental,omitempty"`
+}
+
+// ExperimentalCapabilities announces the behaviour specific to this server.
+type ExperimentalCapabilities struct {
+	// ConfigVerify tells that golangci-lint configuration files get the errors of `config verify`.
+	ConfigVerify bool `json:"golangciLintConfigVerify,omitempty"`
+}
+
+type WorkspaceServerCapabilities struct {
+	WorkspaceFolders struct {
+		Supported           bool `json:"supported"`
+		ChangeNotifications bool `json:"changeNotifications"`
+	} `json:"workspaceFolders"`
+	FileOperations *FileOperationsServerCapabilities `json:"fileOperations,omitempty"`
+}
+
+type FileOperationsServerCapabilities struct {
+	DidRename *FileOperationRegistrationOptions `json:"didRename,omitempty"`
+}
+
+type FileOperationRegistrationOptions struct {
+	Filters []FileOperationFilter `json:"filters"`
+}
+
+type FileOperationFilter struct {
+	Scheme  string               `json:"scheme,omitempty"`
+	Pattern FileOperationPattern `json:"pattern"`
+}
+
+type FileOperationPatternKind string
+
+const (
+	FOPKFile   FileOperationPatternKind = "file"
+	FOPKFolder FileOperationPatternKind = "folder"
+)
+
+type FileOperationPattern struct {
+	Glob    string                   `json:"glob"`
+	Matches FileOperationPatternKind `json:"matches,omitempty"`
+}
+
+type RenameFilesParams struct {
+	Files []FileRename `json:"files"`
+}
+
+type FileRename struct {
+	OldURI DocumentURI `json:"oldUri"`
+	NewURI DocumentURI `json:"newUri"`
+}
+
+type InlayHintParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+type InlayHint struct {
+	Position    Position `json:"position"`
+	Label       string   `json:"label"`
+	PaddingLeft bool     `json:"paddingLeft,omitempty"`
+}
+
+type DocumentLinkOptions struct {
+	ResolveProvider bool `json:"resolveProvider"`
+}
+
+type DocumentLinkParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type DocumentLink struct {
+	Range   Range  `json:"range"`
+	Target  string `json:"target,omitempty"`
+	Tooltip string `json:"tooltip,omitempty"`
+}
+
+type DiagnosticOptions struct {
+	InterFileDependencies bool `json:"interFileDependencies"`
+	WorkspaceDiagnostics  bool `json:"workspaceDiagnostics"`
+}
+
+type DocumentDiagnosticParams struct {
+	TextDocument     TextDocumentIdentifier `json:"textDocument"`
+	PreviousResultID string                 `json:"previousResultId,omitempty"`
+}
+
+type FullDocumentDiagnosticReport struct {
+	Kind     string       `json:"kind"`
+	ResultID string       `json:"resultId,omitempty"`
+	Items    []Diagnostic `json:"items"`
+}
+
+type UnchangedDocumentDiagnosticReport struct {
+	Kind     string `json:"kind"`
+	ResultID string `json:"resultId"`
+}
+
+type WorkspaceDiagnosticParams struct {
+	PreviousResultIDs  []PreviousResultID `json:"previousResultIds"`
+	WorkDoneToken      ProgressToken      `json:"workDoneToken,omitempty"`
+	PartialResultToken ProgressToken      `json:"partialResultToken,omitempty"`
+}
+
+type PreviousResultID struct {
+	URI   DocumentURI `json:"uri"`
+	Value string      `json:"value"`
+}
+
+// WorkspaceDiagnosticReport is also the value of its partial results.
+type WorkspaceDiagnosticReport struct {
+	Items []interface{} `json:"items"`
+}
+
+type WorkspaceFullDocumentDiagnosticReport struct {
+	FullDocumentDiagnosticReport
+	URI     DocumentURI `json:"uri"`
+	Version *int        `json:"version"`
+}
+
+type WorkspaceUnchangedDocumentDiagnosticReport struct {
+	UnchangedDocumentDiagnosticReport
+	URI     DocumentURI `json:"uri"`
+	Version *int        `json:"version"`
+}
+
+type CodeActionOptions struct {
+	CodeActionKinds []CodeActionKind `json:"codeActionKinds,omitempty"`
+	ResolveProvider bool             `json:"resolveProvider,omitempty"`
+}
+
+type ExecuteCommandOptions struct {
+	Commands []string `json:"commands"`
+}
+
+type ExecuteCommandParams struct {
+	Command            string            `json:"command"`
+	Arguments          []json.RawMessage `json:"arguments,omitempty"`
+	WorkDoneToken      ProgressToken     `json:"workDoneToken,omitempty"`
+	PartialResultToken ProgressToken     `json:"partialResultToken,omitempty"`
 }
 
 type TextDocumentItem struct {
@@ -62,6 +274,25 @@ type DidOpenTextDocumentParams struct {
 	TextDocument TextDocumentItem `json:"textDocument"`
 }
 
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type VersionedTextDocumentIdentifier struct {
+	URI     DocumentURI `json:"uri"`
+	Version int         `json:"version"`
+}
+
+// TextDocumentContentChangeEvent carries the whole text of the document, as negotiated by TDSKFull.
+type TextDocumentContentChangeEvent struct {
+	Text string `json:"text"`
+}
+
+type DidChangeTextDocumentParams struct {
+	TextDocument   VersionedTextDocumentIdentifier  `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
 type DidSaveTextDocumentParams struct {
 	Text         *string                `json:"text"`
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
@@ -97,16 +328,191 @@ const (
 	DSHint
 )
 
+type DiagnosticTag int
+
+//nolint:unused,deadcode
+const (
+	DTUnnecessary DiagnosticTag = iota + 1
+	DTDeprecated
+)
+
 type Diagnostic struct {
 	Range              Range                          `json:"range"`
 	Severity           DiagnosticSeverity             `json:"severity,omitempty"`
 	Code               *string                        `json:"code,omitempty"`
+	CodeDescription    *CodeDescription               `json:"codeDescription,omitempty"`
 	Source             *string                        `json:"source,omitempty"`
 	Message            string                         `json:"message"`
+	Tags               []DiagnosticTag                `json:"tags,omitempty"`
 	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
+	Data               *DiagnosticData                `json:"data,omitempty"`
+}
+
+type CodeDescription struct {
+	Href string `json:"href"`
+}
+
+// DiagnosticData is round-tripped by the client so code actions can find the issue behind a diagnostic.
+type DiagnosticData struct {
+	IssueID string `json:"issueId"`
+	// Linter names the linter of the issue whatever the source style.
+	Linter string `json:"linter,omitempty"`
+	// Error holds the details of a failed run, for diagnostics reporting one.
+	Error *LintErrorDetails `json:"error,omitempty"`
 }
 
 type PublishDiagnosticsParams struct {
 	URI         DocumentURI  `json:"uri"`
 	Diagnostics []Diagnostic `json:"diagnostics"`
 }
+
+type MessageType int
+
+//nolint:unused,deadcode
+const (
+	MTError MessageType = iota + 1
+	MTWarning
+	MTInfo
+	MTLog
+)
+
+type ShowMessageParams struct {
+	Type    MessageType `json:"type"`
+	Message string      `json:"message"`
+}
+
+type ShowMessageRequestParams struct {
+	Type    MessageType         `json:"type"`
+	Message string              `json:"message"`
+	Actions []MessageActionItem `json:"actions,omitempty"`
+}
+
+type MessageActionItem struct {
+	Title string `json:"title"`
+}
+
+type DidChangeConfigurationParams struct {
+	Settings map[string]json.RawMessage `json:"settings"`
+}
+
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type WorkspaceEdit struct {
+	Changes map[DocumentURI][]TextEdit `json:"changes,omitempty"`
+}
+
+type CodeActionKind string
+
+const (
+	CAKQuickFix CodeActionKind = "quickfix"
+)
+
+type CodeActionContext struct {
+	Diagnostics []Diagnostic     `json:"diagnostics"`
+	Only        []CodeActionKind `json:"only,omitempty"`
+}
+
+type CodeActionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Context      CodeActionContext      `json:"context"`
+}
+
+type Command struct {
+	Title     string        `json:"title"`
+	Command   string        `json:"command"`
+	Arguments []interface{} `json:"arguments,omitempty"`
+}
+
+type CodeAction struct {
+	Title       string          `json:"title"`
+	Kind        CodeActionKind  `json:"kind,omitempty"`
+	Diagnostics []Diagnostic    `json:"diagnostics,omitempty"`
+	IsPreferred bool            `json:"isPreferred,omitempty"`
+	Edit        *WorkspaceEdit  `json:"edit,omitempty"`
+	Command     *Command        `json:"command,omitempty"`
+	Data        *CodeActionData `json:"data,omitempty"`
+}
+
+// CodeActionData identifies the action to compute in codeAction/resolve.
+type CodeActionData struct {
+	URI     DocumentURI `json:"uri"`
+	IssueID string      `json:"issueId"`
+	Action  string      `json:"action"`
+}
+
+type ShowDocumentParams struct {
+	URI       string `json:"uri"`
+	External  bool   `json:"external,omitempty"`
+	TakeFocus bool   `json:"takeFocus,omitempty"`
+	Selection *Range `json:"selection,omitempty"`
+}
+
+type ShowDocumentResult struct {
+	Success bool `json:"success"`
+}
+
+// ProgressToken is an integer or a string chosen by the client.
+type ProgressToken = json.RawMessage
+
+type ProgressParams struct {
+	Token ProgressToken `json:"token"`
+	Value interface{}   `json:"value"`
+}
+
+type WorkDoneProgressBegin struct {
+	Kind        string `json:"kind"`
+	Title       string `json:"title"`
+	Message     string `json:"message,omitempty"`
+	Percentage  int    `json:"percentage"`
+	Cancellable bool   `json:"cancellable"`
+}
+
+type WorkDoneProgressReport struct {
+	Kind       string `json:"kind"`
+	Message    string `json:"message,omitempty"`
+	Percentage int    `json:"percentage"`
+}
+
+type WorkDoneProgressEnd struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message,omitempty"`
+}
+
+type Registration struct {
+	ID              string      `json:"id"`
+	Method          string      `json:"method"`
+	RegisterOptions interface{} `json:"registerOptions,omitempty"`
+}
+
+type RegistrationParams struct {
+	Registrations []Registration `json:"registrations"`
+}
+
+type FileSystemWatcher struct {
+	GlobPattern string `json:"globPattern"`
+}
+
+type DidChangeWatchedFilesRegistrationOptions struct {
+	Watchers []FileSystemWatcher `json:"watchers"`
+}
+
+type FileChangeType int
+
+const (
+	FCTCreated FileChangeType = iota + 1
+	FCTChanged
+	FCTDeleted
+)
+
+type FileEvent struct {
+	URI  DocumentURI    `json:"uri"`
+	Type FileChangeType `json:"type"`
+}
+
+type DidChangeWatchedFilesParams struct {
+	Changes []FileEvent `json:"changes"`
+}
@@ -3,9 +3,12 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
 
 	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
 )
 
 var defaultSeverity = "Warn"
@@ -14,20 +17,108 @@ func main() {
 	debug := flag.Bool("debug", false, "output debug log")
 	noLinterName := flag.Bool("nolintername", false, "don't show a linter name in message")
 	flag.StringVar(&defaultSeverity, "severity", defaultSeverity, "Default severity to use. Choices are: Err(or), Warn(ing), Info(rmation) or Hint")
+	printCommandPath := flag.String("print-command", "", "print the golangci-lint command run for the given file and exit")
+	root := flag.String("root", "", "workspace root used with -print-command and -once")
+	initOptions := flag.String("init-options", "", "initializationOptions as JSON used with -print-command and -once")
+	debugAddr := flag.String("debug-addr", "", "serve pprof, /state and /healthz over HTTP on this loopback address, e.g. 127.0.0.1:0")
+	oncePath := flag.String("once", "", "lint the given file or directory, print the diagnostics as JSON and exit with 1 if any is an error")
+	recordPath := flag.String("record", "", "record the session and the golangci-lint runs to this file for a bug report")
+	redactSources := flag.Bool("redact-sources", false, "keep the source lines of issues out of the logs and -record files")
+	recordPaths := flag.Bool("record-paths", false, "keep file paths readable in the -record file instead of hashing them")
+	trustAll := flag.Bool("trust-all", false, "run any configured command without asking the user to allow it")
+	maxConcurrency := flag.Int("max-concurrency", -1, "number of golangci-lint processes that may run at once unless initializationOptions set maxConcurrency; 0 runs one and coalesces the requests made meanwhile")
+	metricsPath := flag.String("metrics-file", "", "write the golangci-lint/stats counters as JSON to this file every -metrics-every lints and at shutdown")
+	metricsEvery := flag.Int("metrics-every", defaultMetricsEvery, "number of lints between two writes of -metrics-file")
+	replayPath := flag.String("replay", "", "replay a -record file without golangci-lint, print the published diagnostics as JSON and exit")
+	printConfigFlag := flag.Bool("print-config", false, "print the effective value of every flag and whether it comes from its default, the config file, the command line or -init-options, and exit")
 
 	flag.Parse()
 
+	configPath := serverConfigPath()
+	fromFile, err := loadServerConfig(flag.CommandLine, configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	if *printConfigFlag {
+		printConfig(os.Stdout, configPath, resolveFlags(flag.CommandLine, fromFile, *initOptions))
+
+		return
+	}
+
+	if *printCommandPath != "" {
+		if err := printCommand(os.Stdout, *printCommandPath, *root, *initOptions); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	logger := newStdLogger(*debug)
 
-	handler := NewHandler(logger, *noLinterName)
+	if *oncePath != "" {
+		hasErrors, err := runOnce(os.Stdout, logger, lint.ExecRunner{}, *noLinterName, *oncePath, *root, *initOptions)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if hasErrors {
+			os.Exit(1)
+		}
+
+		return
+	}
 
-	var connOpt []jsonrpc2.ConnOpt
+	if *replayPath != "" {
+		if err := runReplay(os.Stdout, logger, *noLinterName, *replayPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	h := newLangHandler(logger, *noLinterName)
+	h.trust.all = *trustAll
+	h.redactSources = *redactSources
+	h.maxConcurrencyFlag = *maxConcurrency
+	h.metrics.path = *metricsPath
+	h.metrics.every = int64(*metricsEvery)
+	if *debugAddr != "" {
+		addr, err := serveDebug(*debugAddr, h)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		h.debugAddr = addr
+		logger.Printf("golangci-lint-langserver: debug server listening on http://%s", addr)
+	}
+
+	handler := newHandler(h)
+	watchLogLevelSignal(h)
+
+	connOpt := h.traffic.connOpts()
+
+	if *recordPath != "" {
+		f, err := os.Create(*recordPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+
+		rec := newRecorder(f, *recordPaths, *redactSources)
+		h.runner = recordingRunner{next: h.runner, rec: rec}
+		connOpt = append(connOpt, rec.connOpts()...)
+		logger.Printf("golangci-lint-langserver: recording to %s", *recordPath)
+	}
 
 	logger.Printf("golangci-lint-langserver: connections opened")
 
 	<-jsonrpc2.NewConn(
 		context.Background(),
-		jsonrpc2.NewBufferedStream(stdrwc{}, jsonrpc2.VSCodeObjectCodec{}),
+		newResyncStream(stdrwc{}, logger),
 		handler,
 		connOpt...,
 	).DisconnectNotify()
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// mergeable reports whether issue may be merged with the same issue on the lines around it:
+// it sits on a single line and has no fix, whose edit only covers its own line.
+func mergeable(issue *Issue, exclude []string) bool {
+	if issue.Pos.Line <= 0 || issue.Replacement != nil || containsFold(exclude, issue.FromLinter) {
+		return false
+	}
+
+	return issue.LineRange.To <= issue.LineRange.From
+}
+
+// mergeConsecutive merges the issues of a linter with the same text on consecutive lines of
+// a file into one spanning them, such as lll firing on every line of a long literal. The
+// issues of the linters in exclude are left alone.
+func mergeConsecutive(issues []Issue, exclude []string) []Issue {
+	sorted := append([]Issue{}, issues...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if a, b := filepath.Clean(sorted[i].Pos.Filename), filepath.Clean(sorted[j].Pos.Filename); a != b {
+			return a < b
+		}
+
+		return sorted[i].Pos.Line < sorted[j].Pos.Line
+	})
+
+	type runKey struct{ file, linter, text string }
+	// runs indexes the issue of merged each run of consecutive lines extends.
+	runs := make(map[runKey]int)
+	merged := make([]Issue, 0, len(sorted))
+	for _, issue := range sorted {
+		if !mergeable(&issue, exclude) {
+			merged = append(merged, issue)
+
+			continue
+		}
+
+		key := runKey{filepath.Clean(issue.Pos.Filename), issue.FromLinter, issue.Text}
+		if i, ok := runs[key]; ok && merged[i].LineRange.To == issue.Pos.Line-1 {
+			merged[i].LineRange.To = issue.Pos.Line
+			merged[i].Merged++
+
+			continue
+		}
+
+		issue.LineRange.From, issue.LineRange.To = issue.Pos.Line, issue.Pos.Line
+		issue.Merged = 1
+		runs[key] = len(merged)
+		merged = append(merged, issue)
+	}
+
+	for i := range merged {
+		if merged[i].Merged == 1 {
+			// Alone on its line, nothing was merged.
+			merged[i].Merged = 0
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+const cmdOpenLocation = "golangci-lint.openLocation"
+
+// fileReference matches a file:line or file:line:column reference in an issue message,
+// such as the "other.go:42" of dupl.
+var fileReference = regexp.MustCompile(`([\w.\-/\\]+\.go):(\d+)(?::(\d+))?`)
+
+// OpenLocationArgs is the argument of the golangci-lint.openLocation command.
+type OpenLocationArgs struct {
+	URI DocumentURI `json:"uri"`
+	// Line and Character are zero-based.
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// messageReference returns the location of the first existing file an issue of a run from
+// dir refers to in its message, if any, with its URI translated by paths.
+func messageReference(paths *pathMapper, dir string, issue *Issue) (OpenLocationArgs, bool) {
+	for _, m := range fileReference.FindAllStringSubmatch(issue.Text, -1) {
+		path := filepath.FromSlash(m[1])
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+
+		line, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+
+		return OpenLocationArgs{URI: paths.pathToURI(path), Line: max(line-1, 0), Character: max(column-1, 0)}, true
+	}
+
+	return OpenLocationArgs{}, false
+}
+
+// openLocationTarget returns the command URI running the golangci-lint.openLocation command
+// named id with args, which clients such as VS Code execute when the link is followed.
+func openLocationTarget(id string, args OpenLocationArgs) string {
+	b, _ := json.Marshal([]OpenLocationArgs{args})
+
+	return "command:" + id + "?" + url.PathEscape(string(b))
+}
+
+// handleTextDocumentDocumentLink links the diagnostics of the document whose messages refer
+// to another location, such as the duplicate of a dupl finding.
+func (h *langHandler) handleTextDocumentDocumentLink(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params DocumentLinkParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	uri := params.TextDocument.URI
+	links := make([]DocumentLink, 0)
+	if !h.currentOptions().MessageLinks {
+		return links, nil
+	}
+
+	dir := filepath.Dir(h.paths.uriToPath(string(uri)))
+	if run := h.issues.run(uri); run != nil {
+		dir = run.Dir
+	}
+	for _, issue := range h.issues.get(uri) {
+		issue := issue
+		location, ok := messageReference(h.paths, dir, &issue)
+		if !ok {
+			continue
+		}
+
+		r := h.fileDiagnostic(uri, h.paths.uriToPath(string(uri)), &issue).Range
+		if r.Start == r.End {
+			// A link needs some text to click on: the rest of the line.
+			r.End = Position{Line: r.Start.Line, Character: h.lineLength(uri, r.Start.Line, &issue)}
+		}
+		links = append(links, DocumentLink{
+			Range:   r,
+			Target:  openLocationTarget(h.commandID(cmdOpenLocation), location),
+			Tooltip: h.catalog().Sprintf(messages.OpenLocation, filepath.Base(h.paths.uriToPath(string(location.URI))), location.Line+1),
+		})
+	}
+
+	return nonOverlapping(links), nil
+}
+
+// nonOverlapping trims the links starting inside an earlier one, as several diagnostics of
+// a line often share a range, and drops those left empty.
+func nonOverlapping(links []DocumentLink) []DocumentLink {
+	sort.SliceStable(links, func(i, j int) bool {
+		return positionBefore(links[i].Range.Start, links[j].Range.Start)
+	})
+
+	kept := links[:0]
+	for _, link := range links {
+		if len(kept) > 0 {
+			if end := kept[len(kept)-1].Range.End; positionBefore(link.Range.Start, end) {
+				link.Range.Start = end
+			}
+		}
+		if positionBefore(link.Range.Start, link.Range.End) {
+			kept = append(kept, link)
+		}
+	}
+
+	return kept
+}
+
+func positionBefore(a, b Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
+
+// executeOpenLocation asks the client to show the location of args with it selected.
+func (h *langHandler) executeOpenLocation(arguments []json.RawMessage) error {
+	var args OpenLocationArgs
+	if len(arguments) > 0 {
+		if err := json.Unmarshal(arguments[0], &args); err != nil {
+			return err
+		}
+	}
+
+	if !h.clientCaps.Window.ShowDocument.Support {
+		h.showMessage(MTInfo, string(args.URI)+":"+strconv.Itoa(args.Line+1))
+
+		return nil
+	}
+
+	position := Position{Line: args.Line, Character: args.Character}
+	params := &ShowDocumentParams{URI: string(args.URI), TakeFocus: true, Selection: &Range{Start: position, End: position}}
+	h.callAsync("window/showDocument", params, &ShowDocumentResult{})
+
+	return nil
+}
@@ -0,0 +1,179 @@
+// Package messages holds the server-generated, user-facing strings in every supported locale.
+// golangci-lint's own output is never translated.
+package messages
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Key identifies a message in the catalog.
+type Key string
+
+const (
+	UnsupportedVersion    Key = "unsupportedVersion"
+	InvalidInitOptions    Key = "invalidInitOptions"
+	InvalidSettings       Key = "invalidSettings"
+	OptionsNotObject      Key = "optionsNotObject"
+	UnknownOption         Key = "unknownOption"
+	UnknownOptionNearest  Key = "unknownOptionNearest"
+	OptionWrongType       Key = "optionWrongType"
+	CommandRequired       Key = "commandRequired"
+	UnknownMessageKey     Key = "unknownMessageKey"
+	WorkspaceRunFailed    Key = "workspaceRunFailed"
+	MergeConflict         Key = "mergeConflict"
+	ApplyFix              Key = "applyFix"
+	DisableLinterForLine  Key = "disableLinterForLine"
+	IssueGone             Key = "issueGone"
+	NoFix                 Key = "noFix"
+	CommandNotSupported   Key = "commandNotSupported"
+	OptionNotPositive     Key = "optionNotPositive"
+	OutputTooLarge        Key = "outputTooLarge"
+	OptionNotOneOf        Key = "optionNotOneOf"
+	OptionNegative        Key = "optionNegative"
+	NoRuleDocs            Key = "noRuleDocs"
+	OpenRuleDocs          Key = "openRuleDocs"
+	CopyIssue             Key = "copyIssue"
+	IssueNotFound         Key = "issueNotFound"
+	Repeated              Key = "repeated"
+	LintingWorkspace      Key = "lintingWorkspace"
+	LintedPackages        Key = "lintedPackages"
+	InvalidSettingsFile   Key = "invalidSettingsFile"
+	LintKilled            Key = "lintKilled"
+	BuildTagsSkipped      Key = "buildTagsSkipped"
+	WarmingUp             Key = "warmingUp"
+	FolderCommandRequired Key = "folderCommandRequired"
+	InvalidPathMapping    Key = "invalidPathMapping"
+	ProfileNameRequired   Key = "profileNameRequired"
+	TestsExcluded         Key = "testsExcluded"
+	InvalidGlob           Key = "invalidGlob"
+	HiddenIssues          Key = "hiddenIssues"
+	CacheCleaned          Key = "cacheCleaned"
+	CleanCacheFailed      Key = "cleanCacheFailed"
+	OptionNotAbsolute     Key = "optionNotAbsolute"
+	TrustPrompt           Key = "trustPrompt"
+	TrustAllow            Key = "trustAllow"
+	TrustDeny             Key = "trustDeny"
+	CommandNotTrusted     Key = "commandNotTrusted"
+	LintingChanged        Key = "lintingChanged"
+	NoChangedFiles        Key = "noChangedFiles"
+	GitFailed             Key = "gitFailed"
+	LinterRequired        Key = "linterRequired"
+	DeprecationWarnings   Key = "deprecationWarnings"
+	InvalidExportScope    Key = "invalidExportScope"
+	ExportNeedsURI        Key = "exportNeedsURI"
+	BundleCaptured        Key = "bundleCaptured"
+	LinterPanicked        Key = "linterPanicked"
+	InvalidLogLevel       Key = "invalidLogLevel"
+	LogLevelChanged       Key = "logLevelChanged"
+	SnoozeIssue           Key = "snoozeIssue"
+	UnresolvedPlaceholder Key = "unresolvedPlaceholder"
+	InvalidIncludePattern Key = "invalidIncludePattern"
+	FastLintersRequired   Key = "fastLintersRequired"
+	NotActionable         Key = "notActionable"
+	OptionOutOfRange      Key = "optionOutOfRange"
+	OpenLocation          Key = "openLocation"
+	OptionEmpty           Key = "optionEmpty"
+	MergedLines           Key = "mergedLines"
+	ExecutablePlaceholder Key = "executablePlaceholder"
+	DefaultLocale             = "en"
+)
+
+//go:embed catalog/*.json
+var catalogFS embed.FS
+
+var catalogs = mustLoad()
+
+func mustLoad() map[string]map[Key]string {
+	entries, err := catalogFS.ReadDir("catalog")
+	if err != nil {
+		panic(err)
+	}
+
+	result := make(map[string]map[Key]string, len(entries))
+	for _, entry := range entries {
+		b, err := catalogFS.ReadFile(path.Join("catalog", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		var m map[Key]string
+		if err := json.Unmarshal(b, &m); err != nil {
+			panic(fmt.Sprintf("messages: %s: %s", entry.Name(), err))
+		}
+		result[strings.TrimSuffix(entry.Name(), ".json")] = m
+	}
+
+	return result
+}
+
+// Catalog formats messages for one locale.
+type Catalog struct {
+	locale    string
+	overrides map[Key]string
+}
+
+// New returns the catalog best matching locale, e.g. "ja-JP" falls back to "ja" and then to English.
+// overrides replace individual messages regardless of the locale.
+func New(locale string, overrides map[string]string) *Catalog {
+	c := &Catalog{locale: DefaultLocale, overrides: make(map[Key]string, len(overrides))}
+
+	locale = strings.ReplaceAll(locale, "_", "-")
+	for _, candidate := range []string{locale, strings.SplitN(locale, "-", 2)[0]} {
+		if _, ok := catalogs[candidate]; ok {
+			c.locale = candidate
+
+			break
+		}
+	}
+
+	for key, msg := range overrides {
+		c.overrides[Key(key)] = msg
+	}
+
+	return c
+}
+
+// Locale returns the locale the catalog resolved to.
+func (c *Catalog) Locale() string {
+	return c.locale
+}
+
+// Has reports whether key exists in the default catalog.
+func Has(key Key) bool {
+	_, ok := catalogs[DefaultLocale][key]
+
+	return ok
+}
+
+// Sprintf formats the message for key.
+func (c *Catalog) Sprintf(key Key, args ...interface{}) string {
+	format, ok := c.overrides[key]
+	if !ok {
+		format, ok = catalogs[c.locale][key]
+	}
+	if !ok {
+		format, ok = catalogs[DefaultLocale][key]
+	}
+	if !ok {
+		return string(key)
+	}
+
+	return fmt.Sprintf(format, args...)
+}
+
+// Errorf is like Sprintf but returns an error.
+func (c *Catalog) Errorf(key Key, args ...interface{}) error {
+	return &messageError{msg: c.Sprintf(key, args...)}
+}
+
+type messageError struct {
+	msg string
+}
+
+func (e *messageError) Error() string {
+	return e.msg
+}
@@ -0,0 +1,95 @@
+package messages
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// declaredKeys returns the values of the Key constants declared in messages.go.
+func declaredKeys(t *testing.T) []Key {
+	t.Helper()
+
+	f, err := parser.ParseFile(token.NewFileSet(), "messages.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []Key
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			if ident, ok := value.Type.(*ast.Ident); !ok || ident.Name != "Key" {
+				continue
+			}
+			s, err := strconv.Unquote(value.Values[0].(*ast.BasicLit).Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys = append(keys, Key(s))
+		}
+	}
+
+	return keys
+}
+
+// verbPattern matches the formatting verbs of a message.
+var verbPattern = regexp.MustCompile(`%[-+# 0]*\d*(?:\.\d+)?[a-zA-Z%]`)
+
+func TestCatalogsComplete(t *testing.T) {
+	keys := declaredKeys(t)
+	if len(keys) == 0 {
+		t.Fatal("no keys declared")
+	}
+
+	for locale, catalog := range catalogs {
+		for _, key := range keys {
+			if _, ok := catalog[key]; !ok {
+				t.Errorf("%s: %s is missing", locale, key)
+			}
+		}
+		if len(catalog) != len(keys) {
+			for key := range catalog {
+				if !containsKey(keys, key) {
+					t.Errorf("%s: %s isn't a declared key", locale, key)
+				}
+			}
+		}
+	}
+}
+
+func TestCatalogsVerbs(t *testing.T) {
+	for locale, catalog := range catalogs {
+		if locale == DefaultLocale {
+			continue
+		}
+		for key, msg := range catalog {
+			want := verbPattern.FindAllString(catalogs[DefaultLocale][key], -1)
+			got := verbPattern.FindAllString(msg, -1)
+			sort.Strings(want)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %s has the verbs %q, want %q", locale, key, got, want)
+			}
+		}
+	}
+}
+
+func containsKey(keys []Key, key Key) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+const defaultMetricsEvery = 10
+
+// Stats is the result of the golangci-lint/stats request.
+type Stats struct {
+	Panics        int64 `json:"panics"`
+	SkippedIssues int64 `json:"skippedIssues"`
+	// SkippedRuns counts saves that needed no run because the text was already linted.
+	SkippedRuns int64 `json:"skippedRuns"`
+	// Lints counts the golangci-lint runs.
+	Lints   int64                  `json:"lints"`
+	Linters map[string]LinterStats `json:"linters"`
+	// BackgroundPreemptions counts the background processes paused or cancelled for a user lint.
+	BackgroundPreemptions int64 `json:"backgroundPreemptions"`
+	// Snoozed counts the issues hidden for the session with golangci-lint.snoozeIssue.
+	Snoozed int `json:"snoozed"`
+	// CacheDir and CacheSize describe the cache of golangci-lint, only in golangci-lint/stats.
+	CacheDir  string `json:"cacheDir,omitempty"`
+	CacheSize int64  `json:"cacheSize,omitempty"`
+}
+
+// stats holds the counters reported by golangci-lint/stats.
+type stats struct {
+	panics        int64
+	skippedIssues int64
+	skippedRuns   int64
+	lints         int64
+	linters       linterStats
+	preemptions   int64
+}
+
+func (s *stats) snapshot() Stats {
+	return Stats{
+		Panics:        atomic.LoadInt64(&s.panics),
+		SkippedIssues: atomic.LoadInt64(&s.skippedIssues),
+		SkippedRuns:   atomic.LoadInt64(&s.skippedRuns),
+		Lints:         atomic.LoadInt64(&s.lints),
+		Linters:       s.linters.snapshot(),
+
+		BackgroundPreemptions: atomic.LoadInt64(&s.preemptions),
+	}
+}
+
+// handleStats answers golangci-lint/stats. The size of the cache directory is the last one
+// measured in the background, missing until the first measurement completes.
+func (h *langHandler) handleStats(_ context.Context, _ *jsonrpc2.Conn, _ *jsonrpc2.Request) (result interface{}, err error) {
+	stats := h.stats.snapshot()
+	stats.Snoozed = h.snoozes.count()
+	stats.CacheDir = cacheDir(h.currentOptions())
+	if stats.CacheDir != "" {
+		stats.CacheSize = h.cacheSize.get(stats.CacheDir)
+	}
+
+	return stats, nil
+}
+
+// LinterStats counts what a linter caused during the session.
+type LinterStats struct {
+	// Issues counts the diagnostics of the linter published, each publication counting again.
+	Issues int64 `json:"issues"`
+	// Severities splits Issues by severity name.
+	Severities map[string]int64 `json:"severities"`
+	// Fixes and Suppressions count the suggested fixes and //nolint directives picked by the
+	// user, which only clients resolving the edits of code actions report.
+	Fixes        int64 `json:"fixes"`
+	Suppressions int64 `json:"suppressions"`
+}
+
+// linterStats aggregates LinterStats per linter.
+type linterStats struct {
+	mu      sync.Mutex
+	linters map[string]*LinterStats
+}
+
+func (s *linterStats) get(linter string) *LinterStats {
+	if s.linters == nil {
+		s.linters = make(map[string]*LinterStats)
+	}
+	ls, ok := s.linters[linter]
+	if !ok {
+		ls = &LinterStats{Severities: make(map[string]int64)}
+		s.linters[linter] = ls
+	}
+
+	return ls
+}
+
+// published counts diagnostics by linter, whatever the source style.
+func (s *linterStats) published(diagnostics []Diagnostic) {
+	if len(diagnostics) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, d := range diagnostics {
+		d := d
+		linter := diagnosticLinter(&d)
+		if linter == "" && d.Source != nil {
+			// Not an issue, e.g. a configuration error.
+			linter = *d.Source
+		}
+		ls := s.get(linter)
+		ls.Issues++
+		if d.Severity >= DSError && int(d.Severity) <= len(severityNames) {
+			ls.Severities[severityNames[d.Severity-1]]++
+		}
+	}
+}
+
+// picked counts a code action of linter the user picked.
+func (s *linterStats) picked(linter, action string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch action {
+	case actionFix:
+		s.get(linter).Fixes++
+	case actionNoLint:
+		s.get(linter).Suppressions++
+	}
+}
+
+func (s *linterStats) snapshot() map[string]LinterStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	linters := make(map[string]LinterStats, len(s.linters))
+	for linter, ls := range s.linters {
+		c := *ls
+		c.Severities = make(map[string]int64, len(ls.Severities))
+		for severity, n := range ls.Severities {
+			c.Severities[severity] = n
+		}
+		linters[linter] = c
+	}
+
+	return linters
+}
+
+// metricsFile writes the stats to path, every every lints and at shutdown.
+type metricsFile struct {
+	mu    sync.Mutex
+	path  string
+	every int64
+}
+
+// countLint counts a lint, writing the stats when it is the every-th.
+func (h *langHandler) countLint() {
+	lints := atomic.AddInt64(&h.stats.lints, 1)
+	if h.metrics.path != "" && h.metrics.every > 0 && lints%h.metrics.every == 0 {
+		go h.writeMetrics()
+	}
+}
+
+// writeMetrics replaces the metrics file with the current stats.
+func (h *langHandler) writeMetrics() {
+	if h.metrics.path == "" {
+		return
+	}
+
+	h.metrics.mu.Lock()
+	defer h.metrics.mu.Unlock()
+
+	b, err := json.MarshalIndent(h.stats.snapshot(), "", "  ")
+	if err != nil {
+		h.logger.Printf("golangci-lint-langserver: %s", err)
+
+		return
+	}
+
+	// Written aside and renamed, so that readers never see a partial file.
+	f, err := ioutil.TempFile(filepath.Dir(h.metrics.path), filepath.Base(h.metrics.path)+".*")
+	if err == nil {
+		_, err = f.Write(append(b, '\n'))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(f.Name(), h.metrics.path)
+		}
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}
+	if err != nil {
+		h.logger.Printf("golangci-lint-langserver: can't write metrics: %s", err)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const goModFile = "go.mod"
+
+// findModuleRoot returns the nearest directory at or above dir containing a go.mod, or "".
+func findModuleRoot(dir string) string {
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if info, err := os.Stat(filepath.Join(d, goModFile)); err == nil && !info.IsDir() {
+			return d
+		}
+
+		if parent := filepath.Dir(d); parent == d {
+			return ""
+		}
+	}
+}
+
+func isGoMod(path string) bool {
+	return filepath.Base(path) == goModFile
+}
+
+const goWorkFile = "go.work"
+
+// goWork caches the modules listed by the use directives of a go.work file.
+type goWork struct {
+	mu      sync.Mutex
+	modTime time.Time
+	modules []string
+}
+
+// load returns the absolute module directories of root/go.work, rereading the file when it changed.
+// changed reports whether a previously loaded file was modified or removed.
+func (w *goWork) load(root string) (modules []string, changed bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	path := filepath.Join(root, goWorkFile)
+	info, err := os.Stat(path)
+	if err != nil {
+		changed = !w.modTime.IsZero()
+		w.modTime, w.modules = time.Time{}, nil
+
+		return nil, changed
+	}
+
+	if info.ModTime().Equal(w.modTime) {
+		return w.modules, false
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return w.modules, false
+	}
+
+	changed = !w.modTime.IsZero()
+	w.modTime = info.ModTime()
+	w.modules = nil
+	for _, dir := range parseGoWorkUse(string(b)) {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, filepath.FromSlash(dir))
+		}
+		w.modules = append(w.modules, filepath.Clean(dir))
+	}
+
+	return w.modules, changed
+}
+
+// parseGoWorkUse returns the directories of the use directives of a go.work file.
+func parseGoWorkUse(content string) []string {
+	var dirs []string
+
+	inBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock && line != "":
+			dirs = append(dirs, unquote(line))
+		case line == "use (":
+			inBlock = true
+		case strings.HasPrefix(line, "use "):
+			dirs = append(dirs, unquote(strings.TrimSpace(strings.TrimPrefix(line, "use "))))
+		}
+	}
+
+	return dirs
+}
+
+func unquote(s string) string {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+
+	return s
+}
+
+// owningModule returns the deepest module directory containing path, or "".
+func owningModule(modules []string, path string) string {
+	owner := ""
+	for _, dir := range modules {
+		if isSubdir(dir, filepath.Dir(path)) && len(dir) > len(owner) {
+			owner = dir
+		}
+	}
+
+	return owner
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
+)
+
+// noGoFilesMessage is how golangci-lint reports a target without Go files to analyze, on
+// stderr or, in newer versions, as the error of the JSON report.
+const noGoFilesMessage = "no go files to analyze"
+
+// noGoFiles reports whether a run failed only because its target has no Go files.
+func noGoFiles(result *lint.Result, err error) bool {
+	if result != nil && strings.Contains(result.Report.Error, noGoFilesMessage) {
+		return true
+	}
+
+	var exitErr *exec.ExitError
+
+	return errors.As(err, &exitErr) && strings.Contains(string(exitErr.Stderr), noGoFilesMessage)
+}
+
+// withSubpackages makes lc lint the packages under its target directory instead of the target
+// itself, staying in the module of lc since the go command doesn't cross into nested ones.
+func withSubpackages(lc lintCommand) lintCommand {
+	args := append([]string{}, lc.Args...)
+	target := args[len(args)-1]
+	if !strings.HasSuffix(target, "...") {
+		args[len(args)-1] = strings.TrimRight(target, `/\`) + "/..."
+	}
+	lc.Args = args
+
+	return lc
+}
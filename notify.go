@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+const defaultMessageRepeatWindow = 300
+
+// notice tracks a message shown to the user and how often it was suppressed since.
+type notice struct {
+	since    time.Time
+	repeated int
+}
+
+// notifier shows a message once per window and counts the identical ones in between,
+// so that a broken configuration doesn't pop up on every save.
+type notifier struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	window func() time.Duration
+	send   func(typ MessageType, message string, repeated int)
+	seen   map[string]*notice
+}
+
+func newNotifier(window func() time.Duration, send func(MessageType, string, int)) *notifier {
+	return &notifier{
+		now:    time.Now,
+		window: window,
+		send:   send,
+		seen:   make(map[string]*notice),
+	}
+}
+
+// notify sends message unless it was sent less than a window ago. The first message after the
+// window expires carries the number of repeats suppressed in the meantime.
+func (n *notifier) notify(typ MessageType, message string) {
+	sum := sha1.Sum([]byte(strconv.Itoa(int(typ)) + "\x00" + message)) //nolint:gosec
+	key := hex.EncodeToString(sum[:])
+
+	n.mu.Lock()
+	now := n.now()
+	last, ok := n.seen[key]
+	if ok && now.Sub(last.since) < n.window() {
+		last.repeated++
+		n.mu.Unlock()
+
+		return
+	}
+
+	repeated := 0
+	if ok {
+		repeated = last.repeated
+	}
+	n.seen[key] = &notice{since: now}
+	n.mu.Unlock()
+
+	n.send(typ, message, repeated)
+}
+
+// reset forgets every message, so the next failure is shown right away.
+func (n *notifier) reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.seen) > 0 {
+		n.seen = make(map[string]*notice)
+	}
+}
+
+func (h *langHandler) messageRepeatWindow() time.Duration {
+	return time.Duration(h.currentOptions().MessageRepeatWindow) * time.Second
+}
+
+func (h *langHandler) sendNotice(typ MessageType, message string, repeated int) {
+	if repeated > 0 {
+		message = h.catalog().Sprintf(messages.Repeated, message, repeated)
+	}
+
+	h.showMessage(typ, message)
+}
+
+// notifyError shows an error through the notifier. Every failure the user didn't ask for
+// directly goes through here rather than showMessage.
+func (h *langHandler) notifyError(message string) {
+	h.notifier.notify(MTError, message)
+}
+
+// notifyLintError surfaces a failed golangci-lint run.
+func (h *langHandler) notifyLintError(err error) {
+	h.notifyError(strings.TrimSpace(h.errToDiagnostics(err, nil)[0].Message))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// sentNotice is a message a notifier sent.
+type sentNotice struct {
+	typ      MessageType
+	message  string
+	repeated int
+}
+
+func TestNotifierWindow(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var sent []sentNotice
+	n := newNotifier(func() time.Duration { return 5 * time.Minute }, func(typ MessageType, message string, repeated int) {
+		sent = append(sent, sentNotice{typ, message, repeated})
+	})
+	n.now = func() time.Time { return clock }
+
+	steps := []struct {
+		after   time.Duration
+		typ     MessageType
+		message string
+		reset   bool
+		want    *sentNotice
+	}{
+		{typ: MTError, message: "broken", want: &sentNotice{MTError, "broken", 0}},
+		{after: time.Minute, typ: MTError, message: "broken"},
+		{after: time.Minute, typ: MTError, message: "broken"},
+		// Another message or another type of the same one isn't suppressed.
+		{typ: MTError, message: "other", want: &sentNotice{MTError, "other", 0}},
+		{typ: MTWarning, message: "broken", want: &sentNotice{MTWarning, "broken", 0}},
+		// Still within five minutes of the first one.
+		{after: 2*time.Minute + 59*time.Second, typ: MTError, message: "broken"},
+		// The window expired: shown with the three repeats suppressed.
+		{after: time.Second, typ: MTError, message: "broken", want: &sentNotice{MTError, "broken", 3}},
+		{after: time.Minute, typ: MTError, message: "broken"},
+		// A successful run forgets the messages.
+		{reset: true},
+		{typ: MTError, message: "broken", want: &sentNotice{MTError, "broken", 0}},
+	}
+	for i, step := range steps {
+		clock = clock.Add(step.after)
+		sent = nil
+		if step.reset {
+			n.reset()
+
+			continue
+		}
+
+		n.notify(step.typ, step.message)
+
+		var want []sentNotice
+		if step.want != nil {
+			want = []sentNotice{*step.want}
+		}
+		if !reflect.DeepEqual(sent, want) {
+			t.Errorf("step %d: sent %v, want %v", i, sent, want)
+		}
+	}
+}
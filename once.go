@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
+)
+
+// runOnce lints the file or directory at path the way the server would, starting
+// golangci-lint with runner, and writes the resulting PublishDiagnosticsParams as a JSON
+// array to w. It reports whether any diagnostic has Error severity.
+func runOnce(w io.Writer, logger logger, runner lint.Runner, noLinterName bool, path, rootDir, initOptions string) (bool, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	if rootDir != "" {
+		if rootDir, err = filepath.Abs(rootDir); err != nil {
+			return false, err
+		}
+	} else {
+		rootDir = findModuleRoot(filepath.Dir(path))
+	}
+
+	h := newLangHandler(logger, noLinterName)
+	h.runner = runner
+	h.setRoot(rootDir)
+	if rootDir != "" {
+		h.rootURI = string(pathToURI(rootDir))
+	}
+
+	opts, err := decodeOptions(json.RawMessage(initOptions), h.catalog())
+	if err != nil {
+		return false, err
+	}
+	if err := h.trustOptions(opts); err != nil {
+		return false, err
+	}
+	if err := h.applyOptions(opts); err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	var diagnostics map[DocumentURI][]Diagnostic
+	if info.IsDir() {
+		diagnostics, err = h.lintWorkspaceUnit(h.dirUnit(path), time.Now(), nil, make(map[DocumentURI]struct{}))
+	} else {
+		diagnostics, err = h.lint(h.paths.pathToURI(path))
+	}
+	if err != nil {
+		return false, errors.New(strings.TrimSpace(h.errToDiagnostics(err, nil)[0].Message))
+	}
+
+	uris := make([]string, 0, len(diagnostics))
+	for uri := range diagnostics {
+		uris = append(uris, string(uri))
+	}
+	sort.Strings(uris)
+
+	hasErrors := false
+	params := make([]PublishDiagnosticsParams, 0, len(uris))
+	for _, uri := range uris {
+		ds := diagnostics[DocumentURI(uri)]
+		for _, d := range ds {
+			hasErrors = hasErrors || d.Severity == DSError
+		}
+		params = append(params, PublishDiagnosticsParams{URI: DocumentURI(uri), Diagnostics: ds})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return hasErrors, enc.Encode(params)
+}
+
+// trustOptions allows for this run the executables opts names, with their environment: they
+// come from the command line rather than from a workspace. Any other executable, such as one
+// configured by a settings file, still needs a decision of the user in the trust file.
+func (h *langHandler) trustOptions(opts Options) error {
+	subjects := []string{trustSubject(opts.Command[0], nil)}
+	for _, override := range opts.Folders {
+		command := opts.Command
+		if len(override.Command) > 0 {
+			command = override.Command
+		}
+		env := make([]string, 0, len(override.Env))
+		for name, value := range override.Env {
+			env = append(env, name+"="+value)
+		}
+		subjects = append(subjects, trustSubject(command[0], nil), trustSubject(command[0], env))
+	}
+
+	for _, subject := range subjects {
+		if err := h.trust.decide(h.root(), subject, true, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dirUnit lints every package under dir from the module owning it.
+func (h *langHandler) dirUnit(dir string) workspaceUnit {
+	root := owningModule(h.workModules(), filepath.Join(dir, goModFile))
+	if root == "" {
+		root = findModuleRoot(dir)
+	}
+	if root == "" {
+		root = dir
+	}
+
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return workspaceUnit{root: root, target: "./..."}
+	}
+
+	return workspaceUnit{root: root, target: "./" + filepath.ToSlash(rel) + "/..."}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunOnce(t *testing.T) {
+	root := t.TempDir()
+	for name, text := range map[string]string{
+		"go.mod":   "module example.com/once\n\ngo 1.16\n",
+		"a.go":     "package once\n\nfunc A() {}\n",
+		"sub/b.go": "package sub\n\nfunc B() {}\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	issue := func(file, severity string) Issue {
+		var issue Issue
+		issue.FromLinter = "unused"
+		issue.Text = "func is unused"
+		issue.Severity = severity
+		issue.Pos.Filename = file
+		issue.Pos.Line = 3
+		issue.Pos.Column = 6
+
+		return issue
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		issues     []Issue
+		wantTarget string
+		wantURIs   []DocumentURI
+		wantErrors bool
+	}{
+		{
+			name:       "file",
+			path:       "a.go",
+			issues:     []Issue{issue("a.go", "warning")},
+			wantURIs:   []DocumentURI{pathToURI(filepath.Join(root, "a.go"))},
+			wantErrors: false,
+		},
+		{
+			name:       "directory",
+			path:       ".",
+			issues:     []Issue{issue("a.go", "warning"), issue("sub/b.go", "error")},
+			wantTarget: "./...",
+			wantURIs: []DocumentURI{
+				pathToURI(filepath.Join(root, "a.go")),
+				pathToURI(filepath.Join(root, "sub", "b.go")),
+			},
+			wantErrors: true,
+		},
+		{
+			name:       "subdirectory",
+			path:       "sub",
+			issues:     []Issue{issue("sub/b.go", "error")},
+			wantTarget: "./sub/...",
+			wantURIs:   []DocumentURI{pathToURI(filepath.Join(root, "sub", "b.go"))},
+			wantErrors: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{output: func(*exec.Cmd) string { return issuesOutput(t, tt.issues...) }}
+			// An executable that isn't trusted by default: naming it on the command line trusts it.
+			options := `{"command":["/nonexistent/bin/golangci-lint","run","--out-format=json"]}`
+
+			var buf bytes.Buffer
+			hasErrors, err := runOnce(&buf, &testLogger{}, runner, false, filepath.Join(root, tt.path), root, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hasErrors != tt.wantErrors {
+				t.Errorf("hasErrors = %v, want %v", hasErrors, tt.wantErrors)
+			}
+
+			runs := runner.Runs()
+			if len(runs) != 1 {
+				t.Fatalf("%d runs, want 1", len(runs))
+			}
+			if runs[0].Args[0] != "/nonexistent/bin/golangci-lint" {
+				t.Errorf("ran %s", runs[0].Args[0])
+			}
+			if got := runs[0].Args[len(runs[0].Args)-1]; tt.wantTarget != "" && got != tt.wantTarget {
+				t.Errorf("linted %s, want %s", got, tt.wantTarget)
+			}
+
+			var params []PublishDiagnosticsParams
+			if err := json.Unmarshal(buf.Bytes(), &params); err != nil {
+				t.Fatalf("output %q: %s", buf.String(), err)
+			}
+			var uris []DocumentURI
+			for _, p := range params {
+				uris = append(uris, p.URI)
+				if len(p.Diagnostics) != 1 || p.Diagnostics[0].Range.Start.Line != 2 {
+					t.Errorf("diagnostics of %s: %+v", p.URI, p.Diagnostics)
+				}
+			}
+			if len(uris) != len(tt.wantURIs) {
+				t.Fatalf("published %v, want %v", uris, tt.wantURIs)
+			}
+			for i := range uris {
+				if uris[i] != tt.wantURIs[i] {
+					t.Errorf("published %v, want %v", uris, tt.wantURIs)
+				}
+			}
+		})
+	}
+}
+
+func TestTrustOptions(t *testing.T) {
+	h := newLangHandler(&testLogger{}, false)
+	h.trust.path = ""
+	h.rootDir = "/work"
+
+	opts := defaultOptions()
+	opts.Command = []string{"/work/bin/golangci-lint", "run"}
+	opts.Folders = map[string]FolderOptions{
+		"tools": {Command: []string{"/work/tools/golangci-lint"}, Env: map[string]string{"GOFLAGS": "-mod=vendor"}},
+	}
+	if err := h.trustOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		bin  string
+		env  []string
+		want bool
+	}{
+		{bin: "/work/bin/golangci-lint", want: true},
+		{bin: "/work/tools/golangci-lint", want: true},
+		{bin: "/work/tools/golangci-lint", env: []string{"GOFLAGS=-mod=vendor"}, want: true},
+		{bin: "/work/tools/golangci-lint", env: []string{"PATH=/work/bin"}, want: false},
+		{bin: "/work/other/golangci-lint", want: false},
+	} {
+		if got := h.trusted(tt.bin, tt.env); got != tt.want {
+			t.Errorf("trusted(%s, %v) = %v, want %v", tt.bin, tt.env, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,556 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+// Options is the typed form of initializationOptions and of the
+// "golangci-lint" section sent by workspace/didChangeConfiguration.
+type Options struct {
+	Command        []string          `json:"command"`
+	ShowSourceLine bool              `json:"showSourceLine"`
+	Messages       map[string]string `json:"messages"`
+	MaxOutputSize  int               `json:"maxOutputSize"`
+	ShowRunInfo    bool              `json:"showRunInfo"`
+	DiagnosticMode string            `json:"diagnosticMode"`
+	// SaveBatchThreshold is the number of saves within SaveBatchWindow milliseconds
+	// after which the burst is linted with a single run. 0 disables batching.
+	SaveBatchThreshold int `json:"saveBatchThreshold"`
+	SaveBatchWindow    int `json:"saveBatchWindow"`
+	// MessageRepeatWindow is the number of seconds an identical error message stays suppressed after being shown.
+	MessageRepeatWindow int `json:"messageRepeatWindow"`
+	// WatchedFilesDelay is the number of milliseconds without changes on disk after which the
+	// packages of open documents are linted again. 0 disables it.
+	WatchedFilesDelay int `json:"watchedFilesDelay"`
+	// WatchedFilesBurst is the number of package lints changes on disk may start at once, refilled
+	// at WatchedFilesRate per minute; larger batches are linted with one run per module. 0 disables it.
+	WatchedFilesBurst int `json:"watchedFilesBurst"`
+	WatchedFilesRate  int `json:"watchedFilesRate"`
+	// FormattingSeverity is the severity of the findings of FormattingLinters and the built-in formatting linters,
+	// or "off" to drop them.
+	FormattingSeverity string   `json:"formattingSeverity"`
+	FormattingLinters  []string `json:"formattingLinters"`
+	// SeverityMap maps severity strings reported by golangci-lint to error, warning, info or hint,
+	// taking precedence over the built-in mapping.
+	SeverityMap map[string]string `json:"severityMap"`
+	// Concurrency and GOGC lower the memory use of golangci-lint when set. 0 leaves them alone.
+	Concurrency int `json:"concurrency"`
+	GOGC        int `json:"gogc"`
+	// BuildTags are passed with --build-tags. Files needing other tags get them added for their run,
+	// unless BuildTagsMode is "skip".
+	BuildTags     []string `json:"buildTags"`
+	BuildTagsMode string   `json:"buildTagsMode"`
+	// CustomLinters describes linters such as module plugins by name.
+	CustomLinters map[string]CustomLinter `json:"customLinters"`
+	// Warmup lints the root module in the background after initialized to fill the caches.
+	Warmup bool `json:"warmup"`
+	// Folders overrides the command, configuration file and environment per workspace folder.
+	Folders map[string]FolderOptions `json:"folders"`
+	// PathMappings translates URI prefixes of the client to path prefixes of the local filesystem.
+	PathMappings map[string]string `json:"pathMappings"`
+	// Profiles are configurations run for every lint, with their issues merged.
+	Profiles []Profile `json:"profiles"`
+	// CompanionGlobs maps patterns of saved file names to the files to lint again after them.
+	CompanionGlobs map[string][]string `json:"companionGlobs"`
+	// HiddenIssuesHint shows an inlay hint with the number of issues the options hide in a file.
+	HiddenIssuesHint bool `json:"hiddenIssuesHint"`
+	// CacheDir sets GOLANGCI_LINT_CACHE for every golangci-lint run.
+	CacheDir string `json:"cacheDir"`
+	// LargeRangeStyle is how issues spanning more than largeRangeLines lines are underlined:
+	// "full", "firstLine" or "declarationOnly".
+	LargeRangeStyle string `json:"largeRangeStyle"`
+	// RestoreDiagnostics saves the published diagnostics at shutdown and publishes those of
+	// unchanged files again at the next start.
+	RestoreDiagnostics bool `json:"restoreDiagnostics"`
+	// PathRules adjust the issues per workspace-relative path; the first matching rule applies.
+	PathRules []PathRule `json:"pathRules"`
+	// ProvisionalVet publishes the findings of go vet for a package opened for the first
+	// time until golangci-lint reports.
+	ProvisionalVet bool `json:"provisionalVet"`
+	// HighlightNewIssues marks the issues of open documents their previous run didn't report:
+	// "off", "message" or "severity".
+	HighlightNewIssues string `json:"highlightNewIssues"`
+	// MaxConcurrency is the number of golangci-lint processes that may run at once. 0 runs one and
+	// coalesces the requests made meanwhile into a single run afterwards.
+	MaxConcurrency int `json:"maxConcurrency"`
+	// SilenceDeprecations doesn't show the deprecation warnings of golangci-lint.
+	SilenceDeprecations bool `json:"silenceDeprecations"`
+	// InstanceLockWait is the number of milliseconds a run waits for another server linting the
+	// same module to finish. 0 disables the lock.
+	InstanceLockWait int `json:"instanceLockWait"`
+	// SourceStyle is the source of diagnostics: "linter", "golangci-lint" with the linter
+	// as the code when there's no rule ID, or "both", e.g. "golangci-lint(errcheck)".
+	SourceStyle string `json:"sourceStyle"`
+	// TwoPass lints with FastLinters only first and publishes, then with the configuration.
+	TwoPass bool `json:"twoPass"`
+	// FastLinters are the linters of the first pass of TwoPass.
+	FastLinters []string `json:"fastLinters"`
+	// Include restricts linting to these package patterns relative to the root, e.g.
+	// "services/foo/...". Everything is linted when it's empty.
+	Include []string `json:"include"`
+	// PathPrefix rewrites the issue file names of wrappers reporting them relative to
+	// another directory, such as the bazel execroot.
+	PathPrefix PathPrefix `json:"pathPrefix"`
+	// SuppressLintersDuplicatedByGopls drops the issues of GoplsDuplicatedLinters, whose
+	// findings gopls running alongside reports as well.
+	SuppressLintersDuplicatedByGopls bool     `json:"suppressLintersDuplicatedByGopls"`
+	GoplsDuplicatedLinters           []string `json:"goplsDuplicatedLinters"`
+	// MergeConsecutiveIssues merges the issues of a linter with the same text on consecutive
+	// lines into one, except for the linters of MergeConsecutiveExclude.
+	MergeConsecutiveIssues  bool     `json:"mergeConsecutiveIssues"`
+	MergeConsecutiveExclude []string `json:"mergeConsecutiveExclude"`
+	// CommandPrefix replaces the "golangci-lint." prefix of the command names, e.g. to tell
+	// them from those of another server.
+	CommandPrefix string `json:"commandPrefix"`
+	// MessageLinks advertises textDocument/documentLink, linking the diagnostics whose message
+	// refers to a file:line location to it.
+	MessageLinks bool `json:"messageLinks"`
+	// ProcessPriority lowers the CPU and I/O priority of golangci-lint.
+	ProcessPriority ProcessPriority `json:"processPriority"`
+	// Watcher tells who watches the configuration files, go.mod and go.work for changes: "client",
+	// falling back to the internal watcher when the client can't, "internal" or "off".
+	Watcher string `json:"watcher"`
+
+	// set holds the keys the options were decoded from, as spelled in optionKeys.
+	set map[string]struct{}
+}
+
+// isSet reports whether key was given rather than defaulted.
+func (o Options) isSet(key string) bool {
+	_, ok := o.set[key]
+
+	return ok
+}
+
+func defaultOptions() Options {
+	return Options{
+		Command:        []string{"golangci-lint", "run"},
+		CommandPrefix:  defaultCommandPrefix,
+		MaxOutputSize:  lint.DefaultMaxOutputSize,
+		DiagnosticMode: diagnosticModePush,
+
+		SaveBatchThreshold: defaultSaveBatchThreshold,
+		SaveBatchWindow:    defaultSaveBatchWindow,
+
+		MessageRepeatWindow: defaultMessageRepeatWindow,
+		WatchedFilesDelay:   defaultWatchedFilesDelay,
+		WatchedFilesBurst:   defaultWatchedFilesBurst,
+		WatchedFilesRate:    defaultWatchedFilesRate,
+
+		FormattingSeverity: defaultFormattingSeverity,
+		BuildTagsMode:      buildTagsModeAdd,
+		Warmup:             true,
+		LargeRangeStyle:    largeRangeStyleFirstLine,
+		HighlightNewIssues: highlightNewOff,
+		MaxConcurrency:     defaultMaxConcurrency,
+		Watcher:            watcherClient,
+		SourceStyle:        sourceStyleLinter,
+		InstanceLockWait:   defaultInstanceLockWait,
+		FastLinters:        []string{"govet", "errcheck", "ineffassign"},
+
+		// gopls reports the findings of the vet analyzers and the type errors by default.
+		GoplsDuplicatedLinters: []string{"govet", "typecheck"},
+	}
+}
+
+// optionKeys returns the JSON keys Options accepts.
+func optionKeys() []string {
+	return jsonKeys(reflect.TypeOf(Options{}))
+}
+
+// jsonKeys returns the JSON keys of the struct type t.
+func jsonKeys(t reflect.Type) []string {
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// decodeOptions strictly decodes raw over the defaults and validates the result.
+func decodeOptions(raw json.RawMessage, msgs *messages.Catalog) (Options, error) {
+	opts := defaultOptions()
+
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return opts, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return opts, msgs.Errorf(messages.OptionsNotObject, err)
+	}
+
+	keys := optionKeys()
+	opts.set = make(map[string]struct{}, len(fields))
+	for name := range fields {
+		for _, key := range keys {
+			if strings.EqualFold(key, name) {
+				opts.set[key] = struct{}{}
+			}
+		}
+	}
+	if err := unknownOption(raw, reflect.TypeOf(opts), "", msgs); err != nil {
+		return opts, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&opts); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return opts, msgs.Errorf(messages.OptionWrongType, typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+
+		return opts, err
+	}
+
+	return opts, opts.validate(msgs)
+}
+
+// unknownOption returns the error for the first key of the objects of raw, decoded into a value
+// of type t, that has no field, down the nested objects and arrays. path is the name of raw,
+// which prefixes the name of the key reported.
+func unknownOption(raw json.RawMessage, t reflect.Type, path string, msgs *messages.Catalog) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Values of the wrong type are left for the decoding to report.
+	switch t.Kind() {
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(raw, &fields) != nil {
+			return nil
+		}
+
+		keys := jsonKeys(t)
+		for _, name := range sortedFields(fields) {
+			field, ok := fieldFold(t, name)
+			if !ok {
+				if nearest := nearestKey(name, keys); nearest != "" {
+					return msgs.Errorf(messages.UnknownOptionNearest, path+name, nearest, strings.Join(keys, ", "))
+				}
+
+				return msgs.Errorf(messages.UnknownOption, path+name, strings.Join(keys, ", "))
+			}
+			if err := unknownOption(fields[name], field.Type, path+name+".", msgs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		var values map[string]json.RawMessage
+		if json.Unmarshal(raw, &values) != nil {
+			return nil
+		}
+
+		for _, key := range sortedFields(values) {
+			if err := unknownOption(values[key], t.Elem(), path+key+".", msgs); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		var values []json.RawMessage
+		if json.Unmarshal(raw, &values) != nil {
+			return nil
+		}
+
+		prefix := strings.TrimSuffix(path, ".")
+		for i, value := range values {
+			if err := unknownOption(value, t.Elem(), prefix+"["+strconv.Itoa(i)+"].", msgs); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fieldFold returns the field of the struct type t decoded from the JSON key name, which
+// matches its tag regardless of case like encoding/json does.
+func fieldFold(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if key := strings.Split(field.Tag.Get("json"), ",")[0]; key != "" && key != "-" && strings.EqualFold(key, name) {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// sortedFields returns the keys of m in order, so that the first unknown key reported doesn't
+// change from one decoding to the next.
+func sortedFields(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (o Options) validate(msgs *messages.Catalog) error {
+	if len(o.Command) == 0 || o.Command[0] == "" {
+		return msgs.Errorf(messages.CommandRequired)
+	}
+	if placeholderPattern.MatchString(o.Command[0]) {
+		return msgs.Errorf(messages.ExecutablePlaceholder, "command", o.Command[0])
+	}
+
+	if o.MaxOutputSize <= 0 {
+		return msgs.Errorf(messages.OptionNotPositive, "maxOutputSize")
+	}
+
+	if o.SaveBatchThreshold < 0 {
+		return msgs.Errorf(messages.OptionNegative, "saveBatchThreshold")
+	}
+	if o.SaveBatchWindow <= 0 {
+		return msgs.Errorf(messages.OptionNotPositive, "saveBatchWindow")
+	}
+
+	if o.MessageRepeatWindow <= 0 {
+		return msgs.Errorf(messages.OptionNotPositive, "messageRepeatWindow")
+	}
+
+	if o.BuildTagsMode != buildTagsModeAdd && o.BuildTagsMode != buildTagsModeSkip {
+		return msgs.Errorf(messages.OptionNotOneOf, "buildTagsMode", strings.Join([]string{buildTagsModeAdd, buildTagsModeSkip}, ", "))
+	}
+
+	switch o.LargeRangeStyle {
+	case largeRangeStyleFull, largeRangeStyleFirstLine, largeRangeStyleDeclarationOnly:
+	default:
+		return msgs.Errorf(messages.OptionNotOneOf, "largeRangeStyle", strings.Join(largeRangeStyles, ", "))
+	}
+
+	switch o.HighlightNewIssues {
+	case highlightNewOff, highlightNewMessage, highlightNewSeverity:
+	default:
+		return msgs.Errorf(messages.OptionNotOneOf, "highlightNewIssues", strings.Join(highlightNewModes, ", "))
+	}
+
+	switch o.SourceStyle {
+	case sourceStyleLinter, sourceStyleGolangciLint, sourceStyleBoth:
+	default:
+		return msgs.Errorf(messages.OptionNotOneOf, "sourceStyle", strings.Join(sourceStyles, ", "))
+	}
+
+	switch o.Watcher {
+	case watcherClient, watcherInternal, watcherOff:
+	default:
+		return msgs.Errorf(messages.OptionNotOneOf, "watcher", strings.Join(watcherModes, ", "))
+	}
+
+	if o.MaxConcurrency < 0 {
+		return msgs.Errorf(messages.OptionNegative, "maxConcurrency")
+	}
+	if o.Concurrency < 0 {
+		return msgs.Errorf(messages.OptionNegative, "concurrency")
+	}
+	if o.GOGC < 0 {
+		return msgs.Errorf(messages.OptionNegative, "gogc")
+	}
+
+	if o.InstanceLockWait < 0 {
+		return msgs.Errorf(messages.OptionNegative, "instanceLockWait")
+	}
+
+	if o.WatchedFilesDelay < 0 {
+		return msgs.Errorf(messages.OptionNegative, "watchedFilesDelay")
+	}
+	if o.WatchedFilesBurst < 0 {
+		return msgs.Errorf(messages.OptionNegative, "watchedFilesBurst")
+	}
+	if o.WatchedFilesRate <= 0 {
+		return msgs.Errorf(messages.OptionNotPositive, "watchedFilesRate")
+	}
+
+	if _, ok := parseSeverity(o.FormattingSeverity); !ok && o.FormattingSeverity != formattingSeverityOff {
+		return msgs.Errorf(messages.OptionNotOneOf, "formattingSeverity", strings.Join(append(severityNames, formattingSeverityOff), ", "))
+	}
+
+	if o.DiagnosticMode != diagnosticModePush && o.DiagnosticMode != diagnosticModePull {
+		return msgs.Errorf(messages.OptionNotOneOf, "diagnosticMode", strings.Join([]string{diagnosticModePush, diagnosticModePull}, ", "))
+	}
+
+	for name, custom := range o.CustomLinters {
+		if _, ok := parseSeverity(custom.DefaultSeverity); !ok && custom.DefaultSeverity != "" {
+			return msgs.Errorf(messages.OptionNotOneOf, "customLinters."+name+".defaultSeverity", strings.Join(severityNames, ", "))
+		}
+		if _, ok := diagnosticTags[custom.Tag]; !ok && custom.Tag != "" {
+			return msgs.Errorf(messages.OptionNotOneOf, "customLinters."+name+".tag", "unnecessary, deprecated")
+		}
+	}
+
+	if o.CacheDir != "" && !filepath.IsAbs(o.CacheDir) {
+		return msgs.Errorf(messages.OptionNotAbsolute, "cacheDir")
+	}
+
+	if o.CommandPrefix == "" {
+		return msgs.Errorf(messages.OptionEmpty, "commandPrefix")
+	}
+
+	if o.ProcessPriority.Nice < 0 || o.ProcessPriority.Nice > maxNice {
+		return msgs.Errorf(messages.OptionOutOfRange, "processPriority.nice", 0, maxNice)
+	}
+	if o.ProcessPriority.CPULimit < 0 {
+		return msgs.Errorf(messages.OptionNegative, "processPriority.cpuLimit")
+	}
+
+	if o.TwoPass && len(o.FastLinters) == 0 {
+		return msgs.Errorf(messages.FastLintersRequired)
+	}
+
+	for _, pattern := range o.Include {
+		if _, _, ok := parseIncludePattern(pattern); !ok {
+			return msgs.Errorf(messages.InvalidIncludePattern, pattern)
+		}
+	}
+
+	for _, elem := range strings.Split(filepath.ToSlash(o.PathPrefix.Strip), "/") {
+		if _, err := filepath.Match(elem, ""); err != nil {
+			return msgs.Errorf(messages.InvalidGlob, "pathPrefix.strip", o.PathPrefix.Strip)
+		}
+	}
+
+	for pattern := range o.CompanionGlobs {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return msgs.Errorf(messages.InvalidGlob, "companionGlobs", pattern)
+		}
+	}
+
+	for i, rule := range o.PathRules {
+		if !validGlob(rule.Glob) {
+			return msgs.Errorf(messages.InvalidGlob, "pathRules["+strconv.Itoa(i)+"].glob", rule.Glob)
+		}
+		if _, ok := parseSeverity(rule.MinSeverity); !ok && rule.MinSeverity != "" {
+			return msgs.Errorf(messages.OptionNotOneOf, "pathRules["+strconv.Itoa(i)+"].minSeverity", strings.Join(severityNames, ", "))
+		}
+		if _, ok := parseSeverity(rule.SeverityOverride); !ok && rule.SeverityOverride != "" {
+			return msgs.Errorf(messages.OptionNotOneOf, "pathRules["+strconv.Itoa(i)+"].severityOverride", strings.Join(severityNames, ", "))
+		}
+	}
+
+	for i, profile := range o.Profiles {
+		if profile.Name == "" {
+			return msgs.Errorf(messages.ProfileNameRequired, i)
+		}
+		if _, ok := parseSeverity(profile.SeverityDefault); !ok && profile.SeverityDefault != "" {
+			return msgs.Errorf(messages.OptionNotOneOf, "profiles."+profile.Name+".severityDefault", strings.Join(severityNames, ", "))
+		}
+	}
+
+	for uri, path := range o.PathMappings {
+		if !validPathMapping(uri, path) {
+			return msgs.Errorf(messages.InvalidPathMapping, uri)
+		}
+	}
+
+	for folder, override := range o.Folders {
+		if len(override.Command) > 0 && override.Command[0] == "" {
+			return msgs.Errorf(messages.FolderCommandRequired, folder)
+		}
+		if len(override.Command) > 0 && placeholderPattern.MatchString(override.Command[0]) {
+			return msgs.Errorf(messages.ExecutablePlaceholder, "folders."+folder+".command", override.Command[0])
+		}
+	}
+
+	for name, severity := range o.SeverityMap {
+		if _, ok := parseSeverity(severity); !ok {
+			return msgs.Errorf(messages.OptionNotOneOf, "severityMap."+name, strings.Join(severityNames, ", "))
+		}
+	}
+
+	for key := range o.Messages {
+		if !messages.Has(messages.Key(key)) {
+			return msgs.Errorf(messages.UnknownMessageKey, key)
+		}
+	}
+
+	return nil
+}
+
+// env returns the environment variables the options add to golangci-lint's.
+func (o Options) env() []string {
+	var env []string
+	if o.GOGC > 0 {
+		env = append(env, "GOGC="+strconv.Itoa(o.GOGC))
+	}
+	if o.CacheDir != "" {
+		env = append(env, "GOLANGCI_LINT_CACHE="+o.CacheDir)
+	}
+	env = append(env, o.ProcessPriority.env()...)
+
+	return env
+}
+
+func containsFold(keys []string, name string) bool {
+	for _, key := range keys {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nearestKey returns the key closest to name, or "" when nothing is close enough to be a typo.
+func nearestKey(name string, keys []string) string {
+	best, bestDist := "", len(name)/2+1
+	for _, key := range keys {
+		if d := levenshtein(strings.ToLower(name), strings.ToLower(key)); d < bestDist {
+			best, bestDist = key, d
+		}
+	}
+
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+func min(a int, rest ...int) int {
+	for _, b := range rest {
+		if b < a {
+			a = b
+		}
+	}
+
+	return a
+}
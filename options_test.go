@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeOptionsUnknownNested(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		// want is the start of the error, "" for none.
+		want string
+	}{
+		{name: "top level", raw: `{"saveBatchTreshold": 2}`, want: `unknown option "saveBatchTreshold" (did you mean "saveBatchThreshold"?)`},
+		{name: "folders", raw: `{"folders": {"services/api": {"comand": ["api-lint"]}}}`, want: `unknown option "folders.services/api.comand" (did you mean "command"?)`},
+		{name: "profiles", raw: `{"profiles": [{"name": "strict"}, {"name": "fast", "confgPath": "fast.yml"}]}`, want: `unknown option "profiles[1].confgPath" (did you mean "configPath"?)`},
+		{name: "customLinters", raw: `{"customLinters": {"mylint": {"docsURL": "https://example.com", "severty": "error"}}}`, want: `unknown option "customLinters.mylint.severty"`},
+		{name: "pathRules", raw: `{"pathRules": [{"glob": "gen/**", "excludeLinter": ["lll"]}]}`, want: `unknown option "pathRules[0].excludeLinter" (did you mean "excludeLinters"?)`},
+		{name: "processPriority", raw: `{"processPriority": {"nice": 10, "ioidle": true, "cpuLimt": 2}}`, want: `unknown option "processPriority.cpuLimt" (did you mean "cpuLimit"?)`},
+		{name: "pathPrefix", raw: `{"pathPrefix": {"strip": "/execroot", "prefix": "src"}}`, want: `unknown option "pathPrefix.prefix"`},
+		{name: "nothing close", raw: `{"processPriority": {"scheduler": "batch"}}`, want: `unknown option "processPriority.scheduler"; valid options are: cpuLimit, ioIdle, nice`},
+		// Keys match regardless of case, like the decoding.
+		{name: "case", raw: `{"Folders": {"api": {"ConfigPath": "api.yml", "command": ["api-lint"]}}, "PROCESSPRIORITY": {"IOIdle": true}}`},
+		{name: "maps of values", raw: `{"severityMap": {"anything": "error"}, "messages": {"commandRequired": "x"}, "companionGlobs": {"*.proto": ["*.pb.go"]}}`},
+	}
+	msgs := newLangHandler(&testLogger{}, false).catalog()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeOptions([]byte(tt.raw), msgs)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("decodeOptions() error = %v", err)
+			case tt.want != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.want)):
+				t.Errorf("decodeOptions() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
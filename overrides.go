@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+const (
+	cmdEnableLinter  = "golangci-lint.enableLinter"
+	cmdDisableLinter = "golangci-lint.disableLinter"
+)
+
+// LinterArgs is the argument of the golangci-lint.enableLinter and golangci-lint.disableLinter commands.
+type LinterArgs struct {
+	Linter string `json:"linter"`
+}
+
+// LinterOverrides are the linters enabled and disabled for the session on top of the
+// configuration, returned by the commands and part of golangci-lint/configuration.
+type LinterOverrides struct {
+	Enabled  []string `json:"enabled"`
+	Disabled []string `json:"disabled"`
+}
+
+// linterOverrides holds the session overrides. Enabled linters are passed with --enable.
+// Disabled ones are filtered out of the results instead of passed with --disable, which
+// golangci-lint rejects for linters its configuration enables.
+type linterOverrides struct {
+	mu       sync.Mutex
+	enabled  map[string]struct{}
+	disabled map[string]struct{}
+}
+
+// set enables or disables linter. Toggling a linter back drops its override, so the
+// configuration decides again.
+func (o *linterOverrides) set(linter string, enable bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.enabled == nil {
+		o.enabled = make(map[string]struct{})
+		o.disabled = make(map[string]struct{})
+	}
+
+	linter = strings.ToLower(linter)
+	add, remove := o.disabled, o.enabled
+	if enable {
+		add, remove = o.enabled, o.disabled
+	}
+	if _, ok := remove[linter]; ok {
+		delete(remove, linter)
+
+		return
+	}
+	add[linter] = struct{}{}
+}
+
+func (o *linterOverrides) list() LinterOverrides {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return LinterOverrides{Enabled: sortedKeys(o.enabled), Disabled: sortedKeys(o.disabled)}
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// apply adds --enable for the enabled linters to lc, before its target.
+func (o *linterOverrides) apply(lc lintCommand) lintCommand {
+	enabled := o.list().Enabled
+	if len(enabled) == 0 {
+		return lc
+	}
+
+	command, target := lc.Args[:len(lc.Args)-1], lc.Args[len(lc.Args)-1]
+	args := make([]string, 0, len(lc.Args)+1)
+	args = append(args, command...)
+	args = append(args, "--enable="+strings.Join(enabled, ","))
+	lc.Args = append(args, target)
+
+	return lc
+}
+
+// filter drops the issues of the disabled linters.
+func (o *linterOverrides) filter(issues []Issue) (kept, dropped []Issue) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.disabled) == 0 {
+		return issues, nil
+	}
+
+	kept = issues[:0]
+	for _, issue := range issues {
+		if _, ok := o.disabled[strings.ToLower(issue.FromLinter)]; ok {
+			dropped = append(dropped, issue)
+
+			continue
+		}
+		kept = append(kept, issue)
+	}
+
+	return kept, dropped
+}
+
+// executeToggleLinter applies an enableLinter or disableLinter command and lints the open
+// documents again. It returns the overrides now in effect.
+func (h *langHandler) executeToggleLinter(arguments []json.RawMessage, enable bool) (LinterOverrides, error) {
+	var args LinterArgs
+	if len(arguments) > 0 {
+		if err := json.Unmarshal(arguments[0], &args); err != nil {
+			return LinterOverrides{}, err
+		}
+	}
+	if strings.TrimSpace(args.Linter) == "" {
+		return LinterOverrides{}, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: h.catalog().Sprintf(messages.LinterRequired)}
+	}
+
+	h.overrides.set(strings.TrimSpace(args.Linter), enable)
+	h.invalidate("linter overrides changed")
+
+	return h.overrides.list(), nil
+}
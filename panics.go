@@ -0,0 +1,56 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+// panickedLinters returns the names of the linters of panics.
+func panickedLinters(panics []lint.Panic) map[string]struct{} {
+	linters := make(map[string]struct{}, len(panics))
+	for _, p := range panics {
+		linters[p.Linter] = struct{}{}
+	}
+
+	return linters
+}
+
+// keepPanicked returns the cached issues of uri reported by the linters of panics, which the
+// run that panicked couldn't report again: their absence doesn't mean they are fixed.
+func (h *langHandler) keepPanicked(uri DocumentURI, panics []lint.Panic) []Issue {
+	if len(panics) == 0 {
+		return nil
+	}
+
+	linters := panickedLinters(panics)
+
+	var kept []Issue
+	for _, issue := range h.issues.get(uri) {
+		if _, ok := linters[issue.FromLinter]; ok {
+			kept = append(kept, issue)
+		}
+	}
+
+	return kept
+}
+
+// reportPanics warns about the linters that panicked, suggesting to disable them.
+func (h *langHandler) reportPanics(panics []lint.Panic) {
+	if len(panics) == 0 {
+		return
+	}
+
+	var names []string
+	for linter := range panickedLinters(panics) {
+		names = append(names, linter)
+	}
+	sort.Strings(names)
+
+	for _, p := range panics {
+		h.logger.Printf("golangci-lint-langserver: warn: linter %s panicked in %q, keeping its previous issues", p.Linter, p.Package)
+	}
+	h.notifier.notify(MTWarning, h.catalog().Sprintf(messages.LinterPanicked, strings.Join(names, ", ")))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestKeepPanicked checks that the issues of a linter survive a run in which it panicked,
+// while those of the other linters are replaced.
+func TestKeepPanicked(t *testing.T) {
+	var runs int32
+	runner := &fakeRunner{output: func(cmd *exec.Cmd) string {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			var staticcheck, errcheck Issue
+			staticcheck.FromLinter = "staticcheck"
+			staticcheck.Text = "SA4006: this value is never used"
+			staticcheck.Pos.Filename = "a.go"
+			staticcheck.Pos.Line = 3
+			errcheck.FromLinter = "errcheck"
+			errcheck.Text = "Error return value is not checked"
+			errcheck.Pos.Filename = "a.go"
+			errcheck.Pos.Line = 3
+
+			return issuesOutput(t, staticcheck, errcheck)
+		}
+
+		_, _ = io.WriteString(cmd.Stderr, `level=warning msg="[runner] Can't run linter staticcheck: staticcheck: buildir: package \"example.com/test\" (isInitialPkg: true, needAnalyzeSource: true): in example.com/test.A: panic: runtime error"`+"\n")
+
+		return `{"Issues":[]}`
+	}}
+	ts := newTestServer(t, testConfig{runner: runner})
+
+	ts.open("a.go")
+	if diagnostics := ts.waitPublished("a.go"); len(diagnostics) != 2 {
+		t.Fatalf("%d diagnostics, want 2", len(diagnostics))
+	}
+
+	ts.mu.Lock()
+	delete(ts.diagnostics, ts.uri("a.go"))
+	ts.mu.Unlock()
+	params := DidSaveTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: ts.uri("a.go")}}
+	if err := ts.client.Notify(context.Background(), "textDocument/didSave", params); err != nil {
+		t.Fatal(err)
+	}
+	diagnostics := ts.waitPublished("a.go")
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, "SA4006") {
+		t.Errorf("diagnostics %+v after the panic, want the staticcheck issue only", diagnostics)
+	}
+}
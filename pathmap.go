@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// pathMapping translates between a URI prefix of the client and a path prefix of the
+// filesystem golangci-lint sees, for clients that mount the workspace elsewhere.
+type pathMapping struct {
+	uri  string
+	path string
+}
+
+// pathMapper applies the mappings of the pathMappings option. Each handler has its own, so
+// that the mappings of a workspace don't leak into another. A nil pathMapper maps nothing.
+type pathMapper struct {
+	mu sync.RWMutex
+	// byURI and byPath hold the mappings with the longest prefixes first.
+	byURI  []pathMapping
+	byPath []pathMapping
+}
+
+// set replaces the mappings from URI prefixes to path prefixes.
+func (m *pathMapper) set(mappings map[string]string) {
+	var list []pathMapping
+	for uri, path := range mappings {
+		list = append(list, pathMapping{uri: strings.TrimSuffix(uri, "/"), path: filepath.Clean(path)})
+	}
+
+	// Several URI prefixes may map to the same path: the first in sorted order translates it
+	// back, whatever the order of the options.
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].uri != list[j].uri {
+			return list[i].uri < list[j].uri
+		}
+
+		return list[i].path < list[j].path
+	})
+	byURI := append([]pathMapping{}, list...)
+	sort.SliceStable(byURI, func(i, j int) bool { return len(byURI[i].uri) > len(byURI[j].uri) })
+	byPath := append([]pathMapping{}, list...)
+	sort.SliceStable(byPath, func(i, j int) bool { return len(byPath[i].path) > len(byPath[j].path) })
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.byURI = byURI
+	m.byPath = byPath
+}
+
+// uriToPath is uriToPath translating uri with the deepest mapping of its prefix.
+func (m *pathMapper) uriToPath(uri string) string {
+	if path, ok := m.mapURI(uri); ok {
+		return path
+	}
+
+	return uriToPath(uri)
+}
+
+// pathToURI is pathToURI translating path with the deepest mapping of its prefix.
+func (m *pathMapper) pathToURI(path string) DocumentURI {
+	if uri, ok := m.mapPath(path); ok {
+		return uri
+	}
+
+	return pathToURI(path)
+}
+
+func (m *pathMapper) mapURI(uri string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, mapping := range m.byURI {
+		if rest, ok := trimPrefixAt(uri, mapping.uri, "/"); ok {
+			if unescaped, err := url.PathUnescape(rest); err == nil {
+				rest = unescaped
+			}
+
+			return filepath.Join(mapping.path, filepath.FromSlash(rest)), true
+		}
+	}
+
+	return "", false
+}
+
+func (m *pathMapper) mapPath(path string) (DocumentURI, bool) {
+	if m == nil {
+		return "", false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, mapping := range m.byPath {
+		if rest, ok := trimPrefixAt(path, mapping.path, string(filepath.Separator)); ok {
+			return DocumentURI(mapping.uri + (&url.URL{Path: filepath.ToSlash(rest)}).EscapedPath()), true
+		}
+	}
+
+	return "", false
+}
+
+// trimPrefixAt removes prefix from s if it ends at a separator of s, keeping the separator.
+func trimPrefixAt(s, prefix, separator string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return "", false
+	}
+	rest := s[len(prefix):]
+	if rest != "" && !strings.HasPrefix(rest, separator) {
+		return "", false
+	}
+
+	return rest, true
+}
+
+// validPathMapping reports whether a mapping goes from a URI to an absolute path.
+func validPathMapping(uri, path string) bool {
+	u, err := url.Parse(uri)
+
+	return err == nil && u.Scheme != "" && filepath.IsAbs(path)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPathMappings(t *testing.T) {
+	root := t.TempDir()
+	path := func(name string) string {
+		return filepath.Join(root, filepath.FromSlash(name))
+	}
+
+	// The client mounts the repository and its vendored copy at different depths, and a
+	// deeper URI prefix overlaps a shallower one.
+	var paths pathMapper
+	paths.set(map[string]string{
+		"file:///projects":             path("src"),
+		"file:///projects/repo/vendor": path("cache/vendor"),
+		"file:///projects-old/":        path("old"),
+		"vscode-remote://ssh/work":     path("src/work"),
+	})
+
+	tests := []struct {
+		name string
+		uri  string
+		path string
+	}{
+		{name: "shallow prefix", uri: "file:///projects/repo/a.go", path: path("src/repo/a.go")},
+		{name: "deeper prefix", uri: "file:///projects/repo/vendor/x/x.go", path: path("cache/vendor/x/x.go")},
+		{name: "prefix itself", uri: "file:///projects", path: path("src")},
+		{name: "sibling sharing a prefix", uri: "file:///projects/repo/vendored/v.go", path: path("src/repo/vendored/v.go")},
+		{name: "prefix with a trailing slash", uri: "file:///projects-old/b.go", path: path("old/b.go")},
+		{name: "escaped", uri: "file:///projects/my%20repo/a.go", path: path("src/my repo/a.go")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := paths.uriToPath(tt.uri); got != tt.path {
+				t.Errorf("uriToPath(%q) = %q, want %q", tt.uri, got, tt.path)
+			}
+			if got := paths.pathToURI(tt.path); string(got) != tt.uri {
+				t.Errorf("pathToURI(%q) = %q, want %q", tt.path, got, tt.uri)
+			}
+		})
+	}
+
+	// The remote mapping is nested in the path of the shallower one: the path translates back
+	// to the deepest path prefix, which isn't the URI the client sent.
+	if got, want := paths.uriToPath("vscode-remote://ssh/work/w.go"), path("src/work/w.go"); got != want {
+		t.Errorf("remote URI translated to %q, want %q", got, want)
+	}
+	if got, want := paths.pathToURI(path("src/work/w.go")), DocumentURI("vscode-remote://ssh/work/w.go"); got != want {
+		t.Errorf("remote path translated to %q, want %q", got, want)
+	}
+	if got, want := paths.pathToURI(path("src/workshop/w.go")), DocumentURI("file:///projects/workshop/w.go"); got != want {
+		t.Errorf("path sharing the remote prefix translated to %q, want %q", got, want)
+	}
+
+	// Unmapped URIs and paths are left alone.
+	if got, want := paths.uriToPath("file:///elsewhere/c.go"), filepath.FromSlash("/elsewhere/c.go"); got != want {
+		t.Errorf("unmapped URI translated to %q, want %q", got, want)
+	}
+	if _, ok := paths.mapPath(path("other/c.go")); ok {
+		t.Error("unmapped path translated")
+	}
+
+	// The mappings belong to their handler: another one, or none, maps nothing.
+	var other *pathMapper
+	if got, want := other.uriToPath("file:///projects/repo/a.go"), filepath.FromSlash("/projects/repo/a.go"); got != want {
+		t.Errorf("URI translated without mappings to %q, want %q", got, want)
+	}
+	if got, want := newLangHandler(&testLogger{}, false).paths.pathToURI(path("src/a.go")), pathToURI(path("src/a.go")); got != want {
+		t.Errorf("path translated by another handler to %q, want %q", got, want)
+	}
+}
+
+// TestPathMappingsSamePath checks that paths mapped from several URI prefixes translate to the
+// same URI whatever the order of the options.
+func TestPathMappingsSamePath(t *testing.T) {
+	root := t.TempDir()
+
+	var paths pathMapper
+	for i := 0; i < 20; i++ {
+		paths.set(map[string]string{
+			"file:///b":              root,
+			"file:///a":              root,
+			"vscode-remote://host/a": root,
+			"file:///c":              root,
+		})
+		if got, want := paths.pathToURI(filepath.Join(root, "f.go")), DocumentURI("file:///a/f.go"); got != want {
+			t.Fatalf("pathToURI() = %q, want %q", got, want)
+		}
+	}
+}
+
+// TestApplyOptionsRoot checks that the root a configuration change moves with the path
+// mappings is seen consistently by the lints running meanwhile. Run with -race.
+func TestApplyOptionsRoot(t *testing.T) {
+	ts := newTestServer(t, testConfig{})
+	opts := ts.h.currentOptions()
+	opts.PathMappings = map[string]string{string(ts.uri("")): ts.root}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 20; i++ {
+			if err := ts.h.applyOptions(opts); err != nil {
+				t.Error(err)
+
+				return
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if root := ts.h.root(); root != ts.root {
+			t.Fatalf("root %q, want %q", root, ts.root)
+		}
+		if dirs := ts.h.workspaceDirs(); len(dirs) != 1 || dirs[0] != ts.root {
+			t.Fatalf("workspace folders %q, want the root %q", dirs, ts.root)
+		}
+	}
+	<-done
+}
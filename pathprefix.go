@@ -0,0 +1,84 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// PathPrefix rewrites the issue file names of wrappers, such as bazel, that report them
+// relative to another directory than the working directory of the run.
+type PathPrefix struct {
+	// Strip is removed from the file names starting with it. Its elements may hold the
+	// wildcards of filepath.Match, e.g. "/home/*/.cache/bazel/_bazel_*/*/execroot/_main".
+	Strip string `json:"strip"`
+	// Prepend is prepended to the relative file names, after Strip.
+	Prepend string `json:"prepend"`
+}
+
+// commandPathPrefix returns the --path-prefix golangci-lint prepends to the file names of
+// the command args, if any.
+func commandPathPrefix(args []string) string {
+	for i, arg := range args {
+		if arg == "--path-prefix" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if prefix := strings.TrimPrefix(arg, "--path-prefix="); prefix != arg {
+			return prefix
+		}
+	}
+
+	return ""
+}
+
+// stripPathPrefix removes the leading elements of name matching those of prefix, and
+// reports whether they did.
+func stripPathPrefix(name, prefix string) (string, bool) {
+	patterns := strings.Split(strings.Trim(path.Clean(prefix), "/"), "/")
+	elems := strings.Split(name, "/")
+	start := 0
+	if path.IsAbs(prefix) {
+		if !path.IsAbs(name) {
+			return name, false
+		}
+		start = 1
+	}
+	if len(elems)-start <= len(patterns) {
+		return name, false
+	}
+	for i, pattern := range patterns {
+		if ok, _ := path.Match(pattern, elems[start+i]); !ok {
+			return name, false
+		}
+	}
+
+	return strings.Join(elems[start+len(patterns):], "/"), true
+}
+
+// mapPathPrefixes rewrites the file names of the issues of a run with args along the
+// --path-prefix of the command and the pathPrefix option, before they turn into URIs.
+func (h *langHandler) mapPathPrefixes(args []string, issues []Issue) []Issue {
+	option := h.currentOptions().PathPrefix
+	prefixes := []string{commandPathPrefix(args), option.Strip}
+	if prefixes[0] == "" && prefixes[1] == "" && option.Prepend == "" {
+		return issues
+	}
+
+	for i := range issues {
+		name := filepath.ToSlash(issues[i].Pos.Filename)
+		for _, prefix := range prefixes {
+			if prefix == "" {
+				continue
+			}
+			if stripped, ok := stripPathPrefix(name, filepath.ToSlash(prefix)); ok {
+				name = stripped
+			}
+		}
+		if option.Prepend != "" && !path.IsAbs(name) && !filepath.IsAbs(name) {
+			name = path.Join(filepath.ToSlash(option.Prepend), name)
+		}
+		issues[i].Pos.Filename = filepath.FromSlash(name)
+	}
+
+	return issues
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
+)
+
+// bazelExecroot is the execroot of the recorded bazel output, matched by a wildcard strip.
+const bazelExecroot = "/home/*/.cache/bazel/_bazel_*/*/execroot/_main"
+
+func TestStripPathPrefix(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		want   string
+		ok     bool
+	}{
+		{name: "/work/repo/a.go", prefix: "/work/repo", want: "a.go", ok: true},
+		{name: "/work/repo/pkg/a.go", prefix: "/work/repo/", want: "pkg/a.go", ok: true},
+		{name: "/work/repository/a.go", prefix: "/work/repo"},
+		{name: "/work/repo", prefix: "/work/repo"},
+		{name: "work/repo/a.go", prefix: "/work/repo"},
+		{name: "bazel-out/k8/bin/a.go", prefix: "bazel-out/*/bin", want: "a.go", ok: true},
+		{name: "/home/ci/.cache/bazel/_bazel_ci/0c1d5e2a9f/execroot/_main/services/api/api.go", prefix: bazelExecroot, want: "services/api/api.go", ok: true},
+		{name: "/home/ci/.cache/bazel/_bazel_ci/0c1d5e2a9f/execroot/other/api.go", prefix: bazelExecroot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := stripPathPrefix(tt.name, tt.prefix)
+			if !tt.ok {
+				tt.want = tt.name
+			}
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("stripPathPrefix(%q) = %q, %v, want %q, %v", tt.prefix, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// readBazelOutput reads the recorded output of golangci-lint run by bazel.
+func readBazelOutput(t *testing.T) *lint.Result {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", "bazel", "run.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	result, err := lint.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+func TestMapPathPrefixes(t *testing.T) {
+	tests := []struct {
+		name   string
+		option PathPrefix
+		args   []string
+		want   []string
+	}{
+		{
+			name: "none",
+			want: []string{
+				"/home/ci/.cache/bazel/_bazel_ci/0c1d5e2a9f/execroot/_main/services/api/api.go",
+				"/home/ci/.cache/bazel/_bazel_ci/0c1d5e2a9f/execroot/_main/services/api/handler.go",
+				"services/api/api_test.go",
+			},
+		},
+		{
+			name:   "strip the execroot",
+			option: PathPrefix{Strip: bazelExecroot},
+			want:   []string{"services/api/api.go", "services/api/handler.go", "services/api/api_test.go"},
+		},
+		{
+			name:   "strip and prepend",
+			option: PathPrefix{Strip: bazelExecroot, Prepend: "go"},
+			want:   []string{"go/services/api/api.go", "go/services/api/handler.go", "go/services/api/api_test.go"},
+		},
+		{
+			// golangci-lint prepends its --path-prefix to the relative names only.
+			name: "command path prefix",
+			args: []string{"golangci-lint", "run", "--path-prefix", "services", "./..."},
+			want: []string{
+				"/home/ci/.cache/bazel/_bazel_ci/0c1d5e2a9f/execroot/_main/services/api/api.go",
+				"/home/ci/.cache/bazel/_bazel_ci/0c1d5e2a9f/execroot/_main/services/api/handler.go",
+				"api/api_test.go",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newLangHandler(&testLogger{}, false)
+			h.options = defaultOptions()
+			h.options.PathPrefix = tt.option
+
+			var got []string
+			for _, issue := range h.mapPathPrefixes(tt.args, readBazelOutput(t).Issues) {
+				got = append(got, filepath.ToSlash(issue.Pos.Filename))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("file names %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBazelRun lints a document with a bazel wrapper given the package relative to the root,
+// whose output names the files under the execroot.
+func TestBazelRun(t *testing.T) {
+	runner := &fakeRunner{output: func(*exec.Cmd) string {
+		b, err := os.ReadFile(filepath.Join("testdata", "bazel", "run.json"))
+		if err != nil {
+			t.Error(err)
+		}
+
+		return string(b)
+	}}
+	ts := newTestServer(t, testConfig{
+		options: map[string]interface{}{
+			"command":    []string{"bazel", "run", "//:lint", "--", "${relDir}"},
+			"pathPrefix": map[string]interface{}{"strip": bazelExecroot},
+		},
+		files: map[string]string{
+			"services/api/api.go":      "package api\n\nimport \"net/http\"\n\nfunc Serve(w http.ResponseWriter, body []byte) {\n\t// Write the body.\n\tw.Write(body)\n}\n",
+			"services/api/handler.go":  "package api\n\nfunc helper() {}\n",
+			"services/api/api_test.go": "package api\n",
+		},
+		runner: runner,
+	})
+
+	ts.open("services/api/api.go")
+	diagnostics := ts.waitPublished("services/api/api.go")
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, "w.Write") {
+		t.Errorf("api.go diagnostics %+v, want the errcheck issue", diagnostics)
+	} else if got := diagnostics[0].Range.Start; got.Line != 6 || got.Character != 8 {
+		t.Errorf("errcheck issue at %+v, want 6:8", got)
+	}
+	if diagnostics := ts.waitPublished("services/api/handler.go"); len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, "helper") {
+		t.Errorf("handler.go diagnostics %+v, want the unused issue", diagnostics)
+	}
+	ts.waitPublished("services/api/api_test.go")
+
+	runs := runner.Runs()
+	if want := []string{"bazel", "run", "//:lint", "--", filepath.Join("services", "api")}; !reflect.DeepEqual(runs[0].Args, want) {
+		t.Errorf("ran %q, want %q", runs[0].Args, want)
+	}
+	if runs[0].Dir != ts.root {
+		t.Errorf("ran in %s, want the root", runs[0].Dir)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// PathRule adjusts the issues of the files whose workspace-relative path matches Glob.
+type PathRule struct {
+	// Glob is matched against slash-separated paths, with ** matching any number of directories.
+	Glob string `json:"glob"`
+	// MinSeverity drops the issues less severe than it, after SeverityOverride.
+	MinSeverity string `json:"minSeverity,omitempty"`
+	// ExcludeLinters drops the issues of these linters.
+	ExcludeLinters []string `json:"excludeLinters,omitempty"`
+	// SeverityOverride replaces the severity of every issue.
+	SeverityOverride string `json:"severityOverride,omitempty"`
+}
+
+// matchGlob reports whether the slash-separated name matches pattern, where a ** element
+// matches zero or more path elements and the other elements follow path.Match.
+func matchGlob(pattern, name string) bool {
+	return matchElems(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElems(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchElems(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// validGlob reports whether pattern is a valid matchGlob pattern.
+func validGlob(pattern string) bool {
+	if pattern == "" {
+		return false
+	}
+
+	for _, elem := range strings.Split(pattern, "/") {
+		if _, err := path.Match(elem, ""); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pathRule returns the first rule matching rel.
+func (o Options) pathRule(rel string) (PathRule, bool) {
+	for _, rule := range o.PathRules {
+		if matchGlob(rule.Glob, rel) {
+			return rule, true
+		}
+	}
+
+	return PathRule{}, false
+}
+
+// workspacePath returns path relative to the root with slashes, or path itself outside of it.
+func (h *langHandler) workspacePath(path string) string {
+	if h.root() != "" && isSubdir(h.root(), path) {
+		if rel, err := filepath.Rel(h.root(), path); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+
+	return filepath.ToSlash(path)
+}
+
+// applyPathRules sets the severity overrides of the pathRules option on the issues of a run
+// from dir and drops the issues their rules exclude.
+func (h *langHandler) applyPathRules(dir string, issues []Issue) (kept, dropped []Issue) {
+	opts := h.currentOptions()
+	if len(opts.PathRules) == 0 {
+		return issues, nil
+	}
+
+	kept = issues[:0]
+	for _, issue := range issues {
+		issue := issue
+
+		rule, ok := opts.pathRule(h.workspacePath(issueFilePath(dir, &issue)))
+		if !ok {
+			kept = append(kept, issue)
+
+			continue
+		}
+
+		if containsFold(rule.ExcludeLinters, issue.FromLinter) {
+			dropped = append(dropped, issue)
+
+			continue
+		}
+
+		issue.SeverityOverride = rule.SeverityOverride
+		if minimum, ok := parseSeverity(rule.MinSeverity); ok && h.issueSeverity(&issue) > minimum {
+			// Severities count down from error to hint.
+			dropped = append(dropped, issue)
+
+			continue
+		}
+
+		kept = append(kept, issue)
+	}
+
+	return kept, dropped
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "*.go", name: "a.go", want: true},
+		{pattern: "*.go", name: "pkg/a.go", want: false},
+		{pattern: "**/*.go", name: "a.go", want: true},
+		{pattern: "**/*.go", name: "pkg/sub/a.go", want: true},
+		{pattern: "**/*_test.go", name: "pkg/a.go", want: false},
+		{pattern: "internal/**", name: "internal/a.go", want: true},
+		{pattern: "internal/**", name: "internal/x/y/a.go", want: true},
+		{pattern: "internal/**", name: "pkg/internal/a.go", want: false},
+		{pattern: "**/gen/**/*.pb.go", name: "api/gen/v1/a.pb.go", want: true},
+		{pattern: "**/gen/**/*.pb.go", name: "gen/a.pb.go", want: true},
+		{pattern: "**/gen/**/*.pb.go", name: "api/generated/a.pb.go", want: false},
+		{pattern: "pkg/**/a.go", name: "pkg/a.go", want: true},
+		{pattern: "pkg/?.go", name: "pkg/ab.go", want: false},
+		{pattern: "**", name: "a/b/c.go", want: true},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplyPathRules(t *testing.T) {
+	root := filepath.FromSlash("/ws")
+	h := newLangHandler(&testLogger{}, false)
+	h.setRoot(root)
+
+	tests := []struct {
+		name  string
+		rules []PathRule
+		issue Issue
+		// severity is the severity of the kept issue, or 0 when it is dropped.
+		severity DiagnosticSeverity
+	}{
+		{
+			name:     "no match",
+			rules:    []PathRule{{Glob: "internal/**", SeverityOverride: "hint"}},
+			issue:    Issue{FromLinter: "errcheck", Severity: "error"},
+			severity: DSError,
+		},
+		{
+			name:     "severity override",
+			rules:    []PathRule{{Glob: "**/*.go", SeverityOverride: "hint"}},
+			issue:    Issue{FromLinter: "errcheck", Severity: "error"},
+			severity: DSHint,
+		},
+		{
+			name:  "min severity drops",
+			rules: []PathRule{{Glob: "**", MinSeverity: "warning"}},
+			issue: Issue{FromLinter: "errcheck", Severity: "info"},
+		},
+		{
+			name:     "min severity keeps",
+			rules:    []PathRule{{Glob: "**", MinSeverity: "warning"}},
+			issue:    Issue{FromLinter: "errcheck", Severity: "error"},
+			severity: DSError,
+		},
+		{
+			// MinSeverity applies after SeverityOverride.
+			name:  "min severity after override",
+			rules: []PathRule{{Glob: "**", SeverityOverride: "hint", MinSeverity: "info"}},
+			issue: Issue{FromLinter: "errcheck", Severity: "error"},
+		},
+		{
+			name:  "exclude linters",
+			rules: []PathRule{{Glob: "pkg/**", ExcludeLinters: []string{"ErrCheck"}}},
+			issue: Issue{FromLinter: "errcheck", Severity: "error"},
+		},
+		{
+			name:     "exclude other linters",
+			rules:    []PathRule{{Glob: "pkg/**", ExcludeLinters: []string{"unused"}}},
+			issue:    Issue{FromLinter: "errcheck", Severity: "error"},
+			severity: DSError,
+		},
+		{
+			name: "first match wins",
+			rules: []PathRule{
+				{Glob: "pkg/**", SeverityOverride: "info"},
+				{Glob: "**/*.go", ExcludeLinters: []string{"errcheck"}},
+			},
+			issue:    Issue{FromLinter: "errcheck", Severity: "error"},
+			severity: DSInformation,
+		},
+		{
+			name: "first match wins when excluding",
+			rules: []PathRule{
+				{Glob: "**/*.go", ExcludeLinters: []string{"errcheck"}},
+				{Glob: "pkg/**", SeverityOverride: "info"},
+			},
+			issue: Issue{FromLinter: "errcheck", Severity: "error"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := defaultOptions()
+			opts.PathRules = tt.rules
+			h.mu.Lock()
+			h.options = opts
+			h.mu.Unlock()
+
+			issue := tt.issue
+			issue.Pos.Filename = filepath.FromSlash("pkg/a.go")
+			kept, dropped := h.applyPathRules(root, []Issue{issue})
+			if tt.severity == 0 {
+				if len(kept) != 0 || len(dropped) != 1 {
+					t.Fatalf("%d kept and %d dropped, want the issue dropped", len(kept), len(dropped))
+				}
+
+				return
+			}
+			if len(kept) != 1 || len(dropped) != 0 {
+				t.Fatalf("%d kept and %d dropped, want the issue kept", len(kept), len(dropped))
+			}
+			if got := h.issueSeverity(&kept[0]); got != tt.severity {
+				t.Errorf("severity %v, want %v", got, tt.severity)
+			}
+		})
+	}
+}
+
+// TestPathRules checks the pathRules option end to end, on the diagnostics of a lint.
+func TestPathRules(t *testing.T) {
+	runner := &fakeRunner{output: func(cmd *exec.Cmd) string {
+		var errcheck, unused Issue
+		errcheck.FromLinter = "errcheck"
+		errcheck.Severity = "error"
+		errcheck.Text = "Error return value is not checked"
+		errcheck.Pos.Filename = "internal/gen/b.go"
+		errcheck.Pos.Line = 3
+		unused = errcheck
+		unused.FromLinter = "unused"
+		unused.Text = "func B is unused"
+
+		return issuesOutput(t, errcheck, unused)
+	}}
+	ts := newTestServer(t, testConfig{
+		options: map[string]interface{}{
+			"pathRules": []map[string]interface{}{
+				{"glob": "internal/gen/**", "excludeLinters": []string{"errcheck"}},
+				{"glob": "internal/**", "severityOverride": "hint"},
+			},
+		},
+		files:  map[string]string{"internal/gen/b.go": "package gen\n\nfunc B() {}\n"},
+		runner: runner,
+	})
+
+	ts.open("internal/gen/b.go")
+	diagnostics := ts.waitPublished("internal/gen/b.go")
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, "func B is unused") {
+		t.Fatalf("diagnostics %+v, want the unused issue only", diagnostics)
+	}
+	// The first matching rule applies alone, so the severity stays.
+	if diagnostics[0].Severity != DSError {
+		t.Errorf("severity %v, want %v", diagnostics[0].Severity, DSError)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// positionEncoding is how the character offsets of positions count, as negotiated at initialize.
+// golangci-lint reports byte columns, which are UTF-8 offsets.
+type positionEncoding string
+
+const (
+	positionEncodingUTF8  positionEncoding = "utf-8"
+	positionEncodingUTF16 positionEncoding = "utf-16"
+)
+
+// negotiatePositionEncoding prefers UTF-8, which needs no conversion, and falls back to
+// UTF-16, which every client supports.
+func negotiatePositionEncoding(caps ClientCapabilities) positionEncoding {
+	for _, encoding := range caps.General.PositionEncodings {
+		if positionEncoding(encoding) == positionEncodingUTF8 {
+			return positionEncodingUTF8
+		}
+	}
+
+	return positionEncodingUTF16
+}
+
+// character converts the byte offset col in line to a character offset. Offsets past the
+// end of line, e.g. because line is stale, count one character per byte.
+func (e positionEncoding) character(line string, col int) int {
+	if e == positionEncodingUTF8 || col <= 0 {
+		return col
+	}
+
+	overflow := 0
+	if col > len(line) {
+		overflow = col - len(line)
+		col = len(line)
+	}
+
+	n := 0
+	for _, r := range line[:col] {
+		if r >= 0x10000 && utf8.ValidRune(r) {
+			// Outside the basic multilingual plane, a rune takes a surrogate pair.
+			n += 2
+		} else {
+			n++
+		}
+	}
+
+	return n + overflow
+}
+
+// issueLine returns the text of the line of issue without its line ending, if golangci-lint
+// reported it.
+func issueLine(issue *Issue) string {
+	if len(issue.SourceLines) > 0 {
+		return strings.TrimRight(issue.SourceLines[0], "\r")
+	}
+
+	return ""
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCharacter(t *testing.T) {
+	line := `var s = "é日本😀" + x`
+	tests := []struct {
+		col   int
+		utf8  int
+		utf16 int
+	}{
+		{col: 0, utf8: 0, utf16: 0},
+		{col: 9, utf8: 9, utf16: 9},    // é
+		{col: 11, utf8: 11, utf16: 10}, // 日
+		{col: 17, utf8: 17, utf16: 12}, // 😀
+		{col: 21, utf8: 21, utf16: 14}, // "
+		{col: 25, utf8: 25, utf16: 18}, // x
+		{col: len(line), utf8: len(line), utf16: 19},
+		{col: len(line) + 2, utf8: len(line) + 2, utf16: 21},
+	}
+	for _, tt := range tests {
+		if got := positionEncodingUTF8.character(line, tt.col); got != tt.utf8 {
+			t.Errorf("utf-8 character(%d) = %d, want %d", tt.col, got, tt.utf8)
+		}
+		if got := positionEncodingUTF16.character(line, tt.col); got != tt.utf16 {
+			t.Errorf("utf-16 character(%d) = %d, want %d", tt.col, got, tt.utf16)
+		}
+	}
+}
+
+// TestPositionEncodings lints the same multi-byte fixture with a client offering UTF-8 and
+// with one that doesn't.
+func TestPositionEncodings(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "encoding", "multibyte.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(fixture), "\n")
+
+	var issue Issue
+	issue.FromLinter = "typecheck"
+	issue.Text = "undefined: x"
+	issue.Severity = "error"
+	issue.SourceLines = []string{lines[2]}
+	issue.Pos.Filename = "multibyte.go"
+	issue.Pos.Line = 3
+	issue.Pos.Column = strings.Index(lines[2], "x") + 1
+
+	tests := []struct {
+		name      string
+		offered   []string
+		encoding  string
+		character int
+	}{
+		{name: "utf-8 offered", offered: []string{"utf-8", "utf-16"}, encoding: "utf-8", character: 25},
+		{name: "utf-8 not offered", offered: []string{"utf-32", "utf-16"}, encoding: "utf-16", character: 18},
+		{name: "nothing offered", encoding: "utf-16", character: 18},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var caps ClientCapabilities
+			caps.General.PositionEncodings = tt.offered
+			ts := newTestServer(t, testConfig{
+				files:        map[string]string{"multibyte.go": string(fixture)},
+				runner:       &fakeRunner{output: func(*exec.Cmd) string { return issuesOutput(t, issue) }},
+				capabilities: caps,
+			})
+
+			var result struct {
+				Capabilities struct {
+					PositionEncoding string `json:"positionEncoding"`
+				} `json:"capabilities"`
+			}
+			if err := json.Unmarshal(ts.initResult, &result); err != nil {
+				t.Fatal(err)
+			}
+			if got := result.Capabilities.PositionEncoding; got != tt.encoding {
+				t.Errorf("positionEncoding %q, want %q", got, tt.encoding)
+			}
+
+			ts.open("multibyte.go")
+			diagnostics := ts.waitPublished("multibyte.go")
+			if len(diagnostics) != 1 {
+				t.Fatalf("diagnostics %+v, want one", diagnostics)
+			}
+			// The range covers the identifier x.
+			want := Range{Start: Position{Line: 2, Character: tt.character}, End: Position{Line: 2, Character: tt.character + 1}}
+			if r := diagnostics[0].Range; r != want {
+				t.Errorf("range %+v, want %+v", r, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// errPreempted is the error of a background run cancelled for a user lint where processes
+// can't be paused, or mustn't be in single-flight mode. The run is started again once no user
+// lint is waiting.
+var errPreempted = errors.New("background run cancelled for a lint request")
+
+// backgroundProcess is the state of a running background process.
+type backgroundProcess struct {
+	paused    bool
+	cancelled bool
+}
+
+// track registers the started background process of cmd, pausing it right away when a user
+// lint started in the meantime.
+func (l *processLimit) track(cmd *exec.Cmd) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p := &backgroundProcess{}
+	l.background[cmd] = p
+	if l.users > 0 {
+		l.preempt(cmd, p)
+	}
+}
+
+// untrack forgets the background process of cmd once it exited, reporting whether it was
+// cancelled for a user lint.
+func (l *processLimit) untrack(cmd *exec.Cmd) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p, ok := l.background[cmd]
+	if !ok {
+		return false
+	}
+	delete(l.background, cmd)
+	if p.paused {
+		// Killed while paused, e.g. a cancelled warm-up.
+		l.paused--
+	}
+
+	return p.cancelled
+}
+
+// preemptBackground pauses or cancels every running background process. l.mu must be held.
+func (l *processLimit) preemptBackground() {
+	for cmd, p := range l.background {
+		l.preempt(cmd, p)
+	}
+}
+
+func (l *processLimit) preempt(cmd *exec.Cmd, p *backgroundProcess) {
+	if p.paused || p.cancelled || cmd.Process == nil {
+		return
+	}
+
+	// A paused process still exists: in single-flight mode the user lint would run beside it.
+	paused := false
+	if l.exclusive == nil || !l.exclusive() {
+		paused = pauseProcess(cmd.Process) == nil
+	}
+	if paused {
+		p.paused = true
+		l.paused++
+	} else {
+		p.cancelled = true
+		_ = cmd.Process.Kill()
+	}
+	if l.preempted != nil {
+		l.preempted()
+	}
+}
+
+// resumeBackground resumes the paused background processes. l.mu must be held.
+func (l *processLimit) resumeBackground() {
+	for cmd, p := range l.background {
+		if !p.paused {
+			continue
+		}
+		p.paused = false
+		l.paused--
+		_ = resumeProcess(cmd.Process)
+	}
+}
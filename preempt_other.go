@@ -0,0 +1,20 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+var errCannotPause = errors.New("processes can't be paused on this platform")
+
+// pauseProcess fails: background processes are cancelled instead.
+func pauseProcess(*os.Process) error {
+	return errCannotPause
+}
+
+func resumeProcess(*os.Process) error {
+	return errCannotPause
+}
@@ -0,0 +1,19 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// pauseProcess stops p with SIGSTOP.
+func pauseProcess(p *os.Process) error {
+	return p.Signal(syscall.SIGSTOP)
+}
+
+// resumeProcess continues p stopped by pauseProcess.
+func resumeProcess(p *os.Process) error {
+	return p.Signal(syscall.SIGCONT)
+}
@@ -0,0 +1,47 @@
+package main
+
+import "strconv"
+
+// maxNice is the lowest priority of nice(1).
+const maxNice = 19
+
+// ProcessPriority keeps golangci-lint from taking the machine over from the editor.
+type ProcessPriority struct {
+	// Nice is the niceness golangci-lint runs with, from 0 to 19, on systems with nice(1).
+	Nice int `json:"nice"`
+	// IOIdle runs golangci-lint in the idle I/O scheduling class, on Linux with ionice(1).
+	IOIdle bool `json:"ioIdle"`
+	// CPULimit caps the CPUs golangci-lint uses, with --concurrency and GOMAXPROCS. 0 leaves them alone.
+	CPULimit int `json:"cpuLimit"`
+}
+
+// env returns the variables enforcing the CPU limit of p in the golangci-lint process.
+func (p ProcessPriority) env() []string {
+	if p.CPULimit <= 0 {
+		return nil
+	}
+
+	return []string{"GOMAXPROCS=" + strconv.Itoa(p.CPULimit)}
+}
+
+// concurrency returns the --concurrency to pass: the concurrency option, lowered to the CPU
+// limit of processPriority.
+func (o Options) concurrency() int {
+	limit := o.ProcessPriority.CPULimit
+	if limit > 0 && (o.Concurrency <= 0 || limit < o.Concurrency) {
+		return limit
+	}
+
+	return o.Concurrency
+}
+
+// withPriority prefixes args with the programs lowering the priority of the run, those of
+// them not installed being left out.
+func withPriority(args []string, p ProcessPriority) []string {
+	wrapper := priorityWrapper(p)
+	if len(wrapper) == 0 {
+		return args
+	}
+
+	return append(wrapper, args...)
+}
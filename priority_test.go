@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+func TestProcessPriorityCPULimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		cpuLimit    int
+		want        int
+		env         []string
+	}{
+		{name: "neither"},
+		{name: "concurrency only", concurrency: 4, want: 4},
+		{name: "CPU limit only", cpuLimit: 2, want: 2, env: []string{"GOMAXPROCS=2"}},
+		{name: "CPU limit below the concurrency", concurrency: 4, cpuLimit: 2, want: 2, env: []string{"GOMAXPROCS=2"}},
+		{name: "CPU limit above the concurrency", concurrency: 2, cpuLimit: 4, want: 2, env: []string{"GOMAXPROCS=4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Concurrency: tt.concurrency, ProcessPriority: ProcessPriority{CPULimit: tt.cpuLimit}}
+			if got := opts.concurrency(); got != tt.want {
+				t.Errorf("concurrency() = %d, want %d", got, tt.want)
+			}
+			if got := opts.ProcessPriority.env(); !reflect.DeepEqual(got, tt.env) {
+				t.Errorf("env() = %q, want %q", got, tt.env)
+			}
+		})
+	}
+}
+
+func TestProcessPriorityValidation(t *testing.T) {
+	msgs := messages.New("", nil)
+	for _, priority := range []map[string]interface{}{
+		{"nice": -1},
+		{"nice": maxNice + 1},
+		{"cpuLimit": -1},
+	} {
+		raw, err := json.Marshal(map[string]interface{}{"processPriority": priority})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := decodeOptions(raw, msgs); err == nil {
+			t.Errorf("processPriority %v accepted", priority)
+		}
+	}
+}
+
+func TestWithPriority(t *testing.T) {
+	args := []string{"golangci-lint", "run", "./..."}
+	if got := withPriority(args, ProcessPriority{CPULimit: 2}); !reflect.DeepEqual(got, args) {
+		t.Errorf("CPU limit only: %q, want %q", got, args)
+	}
+	if runtime.GOOS == "windows" {
+		if got := withPriority(args, ProcessPriority{Nice: 10, IOIdle: true}); !reflect.DeepEqual(got, args) {
+			t.Errorf("%q on Windows, want %q", got, args)
+		}
+
+		return
+	}
+
+	nice, err := exec.LookPath("nice")
+	if err != nil {
+		t.Skip(err)
+	}
+	want := append([]string{nice, "-n", "10"}, args...)
+	if got := withPriority(args, ProcessPriority{Nice: 10}); !reflect.DeepEqual(got, want) {
+		t.Errorf("nice: %q, want %q", got, want)
+	}
+
+	ionice, err := exec.LookPath("ionice")
+	if runtime.GOOS != "linux" || err != nil {
+		return
+	}
+	want = append([]string{ionice, "-c", "3", nice, "-n", "10"}, args...)
+	if got := withPriority(args, ProcessPriority{Nice: 10, IOIdle: true}); !reflect.DeepEqual(got, want) {
+		t.Errorf("ionice and nice: %q, want %q", got, want)
+	}
+}
+
+// TestProcessPriorityRun checks that the CPU limit reaches the flags and environment of the
+// runs, and nice their invocation where it is installed.
+func TestProcessPriorityRun(t *testing.T) {
+	ts := newTestServer(t, testConfig{options: map[string]interface{}{
+		"concurrency":     4,
+		"processPriority": map[string]interface{}{"nice": 5, "cpuLimit": 2},
+	}})
+
+	ts.open("a.go")
+	ts.waitPublished("a.go")
+	run := ts.runner.Runs()[0]
+
+	if !containsFold(run.Args, "--concurrency=2") {
+		t.Errorf("ran %q, want --concurrency=2", run.Args)
+	}
+	if got := lookupEnv(run.Env, "GOMAXPROCS"); got != "2" {
+		t.Errorf("GOMAXPROCS=%q, want 2", got)
+	}
+
+	want := "golangci-lint"
+	if nice, err := exec.LookPath("nice"); err == nil && runtime.GOOS != "windows" {
+		want = nice
+		if got := run.Args[1:3]; !reflect.DeepEqual(got, []string{"-n", "5"}) {
+			t.Errorf("nice arguments %q, want -n 5", got)
+		}
+	}
+	if run.Args[0] != want {
+		t.Errorf("ran %s, want %s", run.Args[0], want)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os/exec"
+	"runtime"
+	"strconv"
+)
+
+// priorityWrapper returns the nice and ionice invocations applying p. The niceness can't be
+// set in SysProcAttr and setting it once started misses the threads already spawned, so the
+// child starts under nice(1) instead.
+func priorityWrapper(p ProcessPriority) []string {
+	var wrapper []string
+	if p.IOIdle && runtime.GOOS == "linux" {
+		if path, err := exec.LookPath("ionice"); err == nil {
+			wrapper = append(wrapper, path, "-c", "3")
+		}
+	}
+	if p.Nice > 0 {
+		if path, err := exec.LookPath("nice"); err == nil {
+			wrapper = append(wrapper, path, "-n", strconv.Itoa(p.Nice))
+		}
+	}
+
+	return wrapper
+}
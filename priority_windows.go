@@ -0,0 +1,6 @@
+package main
+
+// priorityWrapper returns nothing: Windows has no nice(1), so processPriority only limits the CPUs.
+func priorityWrapper(ProcessPriority) []string {
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import "github.com/nametake/golangci-lint-langserver/lint"
+
+// Profile is an additional golangci-lint configuration whose issues are merged into the diagnostics.
+type Profile struct {
+	Name            string   `json:"name"`
+	ConfigPath      string   `json:"configPath"`
+	Args            []string `json:"args"`
+	SeverityDefault string   `json:"severityDefault"`
+}
+
+// profileCommand returns lc running with the configuration and arguments of profile.
+func profileCommand(lc lintCommand, profile Profile, rootDir string) lintCommand {
+	command, target := lc.Args[:len(lc.Args)-1], lc.Args[len(lc.Args)-1]
+	if profile.ConfigPath != "" {
+		base := rootDir
+		if base == "" {
+			base = lc.Dir
+		}
+		command = withConfig(command, absFrom(base, profile.ConfigPath))
+	}
+
+	args := make([]string, 0, len(command)+len(profile.Args)+1)
+	args = append(args, command...)
+	args = append(args, profile.Args...)
+	args = append(args, target)
+
+	lc.Args, lc.Env, lc.Profile = args, append([]string{}, lc.Env...), profile.Name
+
+	return lc
+}
+
+// runProfiles runs lc once per profile, or once if there are none, and merges the issues of
+// the runs, keeping the first of identical issues. A failing profile doesn't drop the issues
+// of the others: the merged result comes with the error of the first failing profile.
+func (h *langHandler) runProfiles(lc lintCommand) (*lint.Result, *runInfo, error) {
+	profiles := h.currentOptions().Profiles
+	if len(profiles) == 0 {
+		return h.runLint(lc)
+	}
+
+	var (
+		merged   *lint.Result
+		firstRun *runInfo
+		firstErr error
+		seen     = make(map[string]struct{})
+	)
+	for _, profile := range profiles {
+		result, run, err := h.runLint(profileCommand(lc, profile, h.root()))
+		if err != nil {
+			h.logger.Printf("golangci-lint-langserver: profile %s failed: %s", profile.Name, err)
+			if firstErr == nil {
+				firstErr, firstRun = err, run
+			}
+
+			continue
+		}
+		if merged == nil {
+			merged, firstRun = &lint.Result{}, run
+		}
+		if result == nil {
+			continue
+		}
+
+		for _, issue := range result.Issues {
+			id := issueID(&issue)
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+
+			issue.Profile = profile.Name
+			if issue.Severity == "" {
+				issue.Severity = profile.SeverityDefault
+			}
+			merged.Issues = append(merged.Issues, issue)
+		}
+		merged.Hidden = append(merged.Hidden, result.Hidden...)
+		merged.Panics = append(merged.Panics, result.Panics...)
+	}
+
+	if merged == nil {
+		return nil, firstRun, firstErr
+	}
+
+	return merged, firstRun, firstErr
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// provisionalSource is the source of the diagnostics published by the go vet fast path.
+const provisionalSource = "go vet (provisional)"
+
+// provisionalRun is the go vet fast path of a package directory.
+type provisionalRun struct {
+	cancel context.CancelFunc
+	// shown holds the URIs the run published diagnostics for.
+	shown map[DocumentURI]struct{}
+}
+
+// provisionals tracks the go vet runs started on didOpen, so that their diagnostics are
+// replaced by the first full result of the package and never published after it.
+type provisionals struct {
+	mu   sync.Mutex
+	runs map[string]*provisionalRun
+}
+
+// vetFinding is a diagnostic of `go vet -json`.
+type vetFinding struct {
+	Posn string `json:"posn"`
+	// End is reported by recent versions only.
+	End     string `json:"end,omitempty"`
+	Message string `json:"message"`
+}
+
+// startProvisional runs `go vet -json` on the package of uri and publishes its findings
+// until the full lint of the package lands, if the provisionalVet option is set and the
+// package wasn't linted yet.
+func (h *langHandler) startProvisional(uri DocumentURI) {
+	if !h.currentOptions().ProvisionalVet || h.isPullMode() || !strings.HasSuffix(string(uri), ".go") || h.readOnlyPath(h.paths.uriToPath(string(uri))) {
+		return
+	}
+
+	dir := filepath.Dir(h.paths.uriToPath(string(uri)))
+	h.publishedMu.Lock()
+	_, linted := h.published[dir]
+	h.publishedMu.Unlock()
+	if linted {
+		return
+	}
+
+	h.provisionals.mu.Lock()
+	defer h.provisionals.mu.Unlock()
+
+	if h.provisionals.runs == nil {
+		h.provisionals.runs = make(map[string]*provisionalRun)
+	}
+	if _, ok := h.provisionals.runs[dir]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &provisionalRun{cancel: cancel, shown: make(map[DocumentURI]struct{})}
+	h.provisionals.runs[dir] = run
+
+	go h.runProvisional(ctx, dir, run)
+}
+
+func (h *langHandler) runProvisional(ctx context.Context, dir string, run *provisionalRun) {
+	defer h.recoverPanic("go vet " + dir)
+
+	// Older Go versions write the JSON to stderr, newer ones to stdout.
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "vet", "-json", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), h.currentOptions().env()...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	// go vet exits with an error when it reports findings, and when the package doesn't build.
+	_ = cmd.Run()
+	if ctx.Err() != nil {
+		return
+	}
+
+	findings := parseVetOutput(output.Bytes())
+
+	h.provisionals.mu.Lock()
+	defer h.provisionals.mu.Unlock()
+
+	if ctx.Err() != nil || h.provisionals.runs[dir] != run {
+		// The full result landed while go vet ran.
+		return
+	}
+
+	diagnostics := make(map[DocumentURI][]Diagnostic)
+	for _, finding := range findings {
+		path, line, col, ok := parsePosn(finding.Posn)
+		if !ok || filepath.Dir(path) != dir {
+			continue
+		}
+		uri := h.paths.pathToURI(path)
+		endLine, endCol := line, col
+		if endPath, l, c, ok := parsePosn(finding.End); ok && endPath == path {
+			endLine, endCol = l, c
+		}
+		diagnostics[uri] = append(diagnostics[uri], h.vetDiagnostic(uri, [2]int{line, col}, [2]int{endLine, endCol}, finding.Message))
+	}
+
+	h.logger.Printf("golangci-lint-langserver: go vet found %d issues in %s while golangci-lint runs", len(findings), dir)
+	for uri, ds := range diagnostics {
+		run.shown[uri] = struct{}{}
+		h.publishDiagnostics(uri, ds)
+	}
+}
+
+// vetDiagnostic returns the provisional diagnostic of a finding between two 1-based
+// line and byte column pairs.
+func (h *langHandler) vetDiagnostic(uri DocumentURI, start, end [2]int, message string) Diagnostic {
+	text, ok := h.documents.text(uri)
+	if !ok {
+		b, _ := ioutil.ReadFile(h.paths.uriToPath(string(uri)))
+		text = string(b)
+	}
+	lines := strings.Split(text, "\n")
+
+	position := func(pos [2]int) Position {
+		line, col := pos[0], pos[1]
+		character := 0
+		if line > 0 && line <= len(lines) && col > 0 {
+			character = h.encoding.character(lines[line-1], col-1)
+		}
+		if line > 0 {
+			line--
+		}
+
+		return Position{Line: line, Character: character}
+	}
+
+	source := provisionalSource
+
+	return Diagnostic{
+		Range:    Range{Start: position(start), End: position(end)},
+		Severity: h.issueSeverity(&Issue{FromLinter: "govet"}),
+		Source:   &source,
+		Message:  message,
+	}
+}
+
+// endProvisional stops the go vet run of the package in dir and clears the provisional
+// diagnostics of the files the full result has none for. It must run before the full
+// result is published.
+func (h *langHandler) endProvisional(dir string, diagnostics map[DocumentURI][]Diagnostic) {
+	h.provisionals.mu.Lock()
+	defer h.provisionals.mu.Unlock()
+
+	run, ok := h.provisionals.runs[dir]
+	if !ok {
+		return
+	}
+	run.cancel()
+	delete(h.provisionals.runs, dir)
+
+	for uri := range run.shown {
+		if _, ok := diagnostics[uri]; !ok {
+			h.publishDiagnostics(uri, []Diagnostic{})
+		}
+	}
+}
+
+// parseVetOutput returns the findings of `go vet -json`, which writes a JSON object per
+// package, possibly preceded by a "# package" comment line, as
+// {"pkg": {"analyzer": [{"posn": "file:line:col", "message": ...}]}}.
+// Analyzers that failed report {"error": ...} instead of a list and are skipped.
+func parseVetOutput(out []byte) []vetFinding {
+	var stripped bytes.Buffer
+	for _, line := range bytes.SplitAfter(out, []byte("\n")) {
+		if !bytes.HasPrefix(line, []byte("#")) {
+			stripped.Write(line)
+		}
+	}
+
+	var findings []vetFinding
+	dec := json.NewDecoder(&stripped)
+	for {
+		var packages map[string]map[string]json.RawMessage
+		if err := dec.Decode(&packages); err != nil {
+			// Compile errors are printed as plain text, so stop at the first thing that isn't JSON.
+			return findings
+		}
+		for _, analyzers := range packages {
+			for _, raw := range analyzers {
+				var list []vetFinding
+				if err := json.Unmarshal(raw, &list); err == nil {
+					findings = append(findings, list...)
+				}
+			}
+		}
+	}
+}
+
+// parsePosn splits a go vet position "file:line:col" or "file:line".
+func parsePosn(posn string) (path string, line, col int, ok bool) {
+	parts := strings.Split(posn, ":")
+	if len(parts) < 2 {
+		return "", 0, 0, false
+	}
+
+	if n, err := strconv.Atoi(parts[len(parts)-1]); err == nil && len(parts) >= 3 {
+		if l, err := strconv.Atoi(parts[len(parts)-2]); err == nil {
+			return strings.Join(parts[:len(parts)-2], ":"), l, n, true
+		}
+	}
+	if l, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
+		return strings.Join(parts[:len(parts)-1], ":"), l, 0, true
+	}
+
+	return "", 0, 0, false
+}
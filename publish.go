@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+const publishQueueSize = 1024
+
+// publisher sends textDocument/publishDiagnostics from its own goroutine, so that a client
+// that stops reading can't stall linting. Only the latest diagnostics of each URI are queued;
+// when the queue is full the oldest URI is dropped and published again once the queue drained.
+type publisher struct {
+	mu      sync.Mutex
+	pending map[DocumentURI][]Diagnostic
+	order   []DocumentURI
+	dirty   map[DocumentURI][]Diagnostic
+	wake    chan struct{}
+	// latest holds the last non-empty diagnostics published for each URI.
+	latest map[DocumentURI][]Diagnostic
+
+	logger logger
+	send   func(uri DocumentURI, diagnostics []Diagnostic) error
+}
+
+func newPublisher(logger logger, send func(DocumentURI, []Diagnostic) error) *publisher {
+	return &publisher{
+		pending: make(map[DocumentURI][]Diagnostic),
+		dirty:   make(map[DocumentURI][]Diagnostic),
+		wake:    make(chan struct{}, 1),
+		latest:  make(map[DocumentURI][]Diagnostic),
+		logger:  logger,
+		send:    send,
+	}
+}
+
+// publish queues the diagnostics of uri, replacing any not sent yet.
+func (p *publisher) publish(uri DocumentURI, diagnostics []Diagnostic) {
+	p.mu.Lock()
+	if _, ok := p.pending[uri]; !ok {
+		if len(p.order) >= publishQueueSize {
+			p.drop()
+		}
+		p.order = append(p.order, uri)
+	}
+	p.pending[uri] = diagnostics
+	delete(p.dirty, uri)
+	if len(diagnostics) > 0 {
+		p.latest[uri] = diagnostics
+	} else {
+		delete(p.latest, uri)
+	}
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// published returns the last non-empty diagnostics published for each URI.
+func (p *publisher) published() map[DocumentURI][]Diagnostic {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	published := make(map[DocumentURI][]Diagnostic, len(p.latest))
+	for uri, diagnostics := range p.latest {
+		published[uri] = diagnostics
+	}
+
+	return published
+}
+
+// drop moves the oldest queued URI to dirty. p.mu must be held.
+func (p *publisher) drop() {
+	uri := p.order[0]
+	p.order = p.order[1:]
+	p.dirty[uri] = p.pending[uri]
+	delete(p.pending, uri)
+
+	p.logger.Printf("golangci-lint-langserver: client is not reading, dropped diagnostics of %s until it catches up", uri)
+}
+
+// next returns the oldest queued URI. Once the queue drained, the dropped URIs are queued again.
+func (p *publisher) next() (DocumentURI, []Diagnostic, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.order) == 0 {
+		for uri, diagnostics := range p.dirty {
+			p.order = append(p.order, uri)
+			p.pending[uri] = diagnostics
+		}
+		p.dirty = make(map[DocumentURI][]Diagnostic)
+	}
+	if len(p.order) == 0 {
+		return "", nil, false
+	}
+
+	uri := p.order[0]
+	p.order = p.order[1:]
+	diagnostics := p.pending[uri]
+	delete(p.pending, uri)
+
+	return uri, diagnostics, true
+}
+
+// queued returns the URIs waiting to be published, dropped ones included.
+func (p *publisher) queued() []DocumentURI {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	uris := append([]DocumentURI{}, p.order...)
+	for uri := range p.dirty {
+		uris = append(uris, uri)
+	}
+
+	return uris
+}
+
+func (p *publisher) run() {
+	for range p.wake {
+		for {
+			uri, diagnostics, ok := p.next()
+			if !ok {
+				break
+			}
+
+			if err := p.send(uri, diagnostics); err != nil {
+				p.logger.Printf("%s", err)
+			}
+		}
+	}
+}
+
+func (h *langHandler) notifyDiagnostics(uri DocumentURI, diagnostics []Diagnostic) error {
+	return h.conn.Notify(
+		context.Background(),
+		"textDocument/publishDiagnostics",
+		&PublishDiagnosticsParams{
+			URI:         uri,
+			Diagnostics: diagnostics,
+		})
+}
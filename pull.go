@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+const (
+	diagnosticModePush = "push"
+	diagnosticModePull = "pull"
+)
+
+// report is the last diagnostic report computed for a document in pull mode.
+type report struct {
+	resultID    string
+	revision    int
+	diagnostics []Diagnostic
+}
+
+// workspaceReportChunk is the number of documents per partial result of workspace/diagnostic.
+const workspaceReportChunk = 100
+
+// reportStore holds the reports served to textDocument/diagnostic and workspace/diagnostic.
+type reportStore struct {
+	mu      sync.Mutex
+	reports map[DocumentURI]report
+	// changed is closed and replaced whenever a report is stored or dropped.
+	changed chan struct{}
+}
+
+func newReportStore() *reportStore {
+	return &reportStore{
+		reports: make(map[DocumentURI]report),
+		changed: make(chan struct{}),
+	}
+}
+
+// resultID identifies diagnostics by their content, so that linting a document again without
+// changing its diagnostics lets the client keep them.
+func resultID(diagnostics []Diagnostic) string {
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+	b, err := json.Marshal(diagnostics)
+	if err != nil {
+		return ""
+	}
+	//nolint:gosec
+	sum := sha1.Sum(b)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+func (s *reportStore) store(uri DocumentURI, revision int, diagnostics []Diagnostic) report {
+	r := report{resultID: resultID(diagnostics), revision: revision, diagnostics: diagnostics}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reports[uri] = r
+	s.notify()
+
+	return r
+}
+
+// notify wakes the workspace/diagnostic requests waiting for a change. s.mu must be held.
+func (s *reportStore) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// snapshot returns the reports and a channel closed on the next change.
+func (s *reportStore) snapshot() (map[DocumentURI]report, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reports := make(map[DocumentURI]report, len(s.reports))
+	for uri, r := range s.reports {
+		reports[uri] = r
+	}
+
+	return reports, s.changed
+}
+
+func (s *reportStore) get(uri DocumentURI) (report, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.reports[uri]
+
+	return r, ok
+}
+
+// clear drops every report so the next pull recomputes it.
+func (s *reportStore) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reports = make(map[DocumentURI]report)
+	s.notify()
+}
+
+// pullHandler answers textDocument/diagnostic in its own goroutine, since it may have to wait for
+// golangci-lint and jsonrpc2 handles messages one at a time.
+type pullHandler struct {
+	jsonrpc2.Handler
+	h *langHandler
+}
+
+func (p *pullHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	var handle func(context.Context, *jsonrpc2.Conn, *jsonrpc2.Request) (interface{}, error)
+	switch {
+	case req.Notif:
+	case req.Method == "textDocument/diagnostic":
+		handle = p.h.handleTextDocumentDiagnostic
+	case req.Method == "workspace/diagnostic":
+		// Held until a report changes.
+		handle = p.h.handleWorkspaceDiagnostic
+	}
+	if handle == nil {
+		p.Handler.Handle(ctx, conn, req)
+
+		return
+	}
+
+	go func() {
+		result, err := recoverHandler(p.h, handle)(ctx, conn, req)
+		if err == nil {
+			err = conn.Reply(ctx, req.ID, result)
+		} else {
+			var rpcErr *jsonrpc2.Error
+			if !errors.As(err, &rpcErr) {
+				rpcErr = &jsonrpc2.Error{Code: jsonrpc2.CodeInternalError, Message: err.Error()}
+			}
+			err = conn.ReplyWithError(ctx, req.ID, rpcErr)
+		}
+		if err != nil {
+			p.h.logger.Printf("golangci-lint-langserver: %s", err)
+		}
+	}()
+}
+
+func (h *langHandler) handleTextDocumentDiagnostic(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params DocumentDiagnosticParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	uri := params.TextDocument.URI
+	doc, _ := h.documents.get(uri)
+
+	r, ok := h.reports.get(uri)
+	if !ok || r.revision != doc.Revision {
+		r = h.pull(uri, doc.Revision)
+	}
+
+	if params.PreviousResultID != "" && params.PreviousResultID == r.resultID {
+		return UnchangedDocumentDiagnosticReport{Kind: "unchanged", ResultID: r.resultID}, nil
+	}
+
+	items := r.diagnostics
+	if items == nil {
+		items = []Diagnostic{}
+	}
+	h.stats.linters.published(items)
+
+	return FullDocumentDiagnosticReport{Kind: "full", ResultID: r.resultID, Items: items}, nil
+}
+
+// handleWorkspaceDiagnostic returns the reports of every document linted in pull mode,
+// including the siblings of the open documents, as unchanged when the client already has
+// them. When the client has all of them, the request is held until one changes, since
+// clients ask again as soon as they get the answer. With a partialResultToken, the reports
+// are streamed in chunks and the response is empty.
+func (h *langHandler) handleWorkspaceDiagnostic(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params WorkspaceDiagnosticParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	previous := make(map[DocumentURI]string, len(params.PreviousResultIDs))
+	for _, id := range params.PreviousResultIDs {
+		previous[id.URI] = id.Value
+	}
+
+	var (
+		items []interface{}
+		full  int
+	)
+	for {
+		reports, changed := h.reports.snapshot()
+		items, full = h.workspaceReports(reports, previous)
+		if full > 0 {
+			break
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return WorkspaceDiagnosticReport{Items: items}, nil
+		}
+	}
+
+	if len(params.PartialResultToken) == 0 {
+		return WorkspaceDiagnosticReport{Items: items}, nil
+	}
+	for start := 0; start < len(items); start += workspaceReportChunk {
+		end := start + workspaceReportChunk
+		if end > len(items) {
+			end = len(items)
+		}
+		h.progress(params.PartialResultToken, WorkspaceDiagnosticReport{Items: items[start:end]})
+	}
+
+	return WorkspaceDiagnosticReport{Items: []interface{}{}}, nil
+}
+
+// workspaceReports turns reports into workspace reports, sorted by URI, full unless previous
+// holds their result id. It also returns the number of full reports.
+func (h *langHandler) workspaceReports(reports map[DocumentURI]report, previous map[DocumentURI]string) ([]interface{}, int) {
+	uris := make([]string, 0, len(reports))
+	for uri := range reports {
+		uris = append(uris, string(uri))
+	}
+	sort.Strings(uris)
+
+	items := make([]interface{}, 0, len(uris))
+	full := 0
+	for _, u := range uris {
+		uri := DocumentURI(u)
+		r := reports[uri]
+
+		var version *int
+		if doc, ok := h.documents.get(uri); ok {
+			version = &doc.Version
+		}
+
+		if previous[uri] == r.resultID {
+			items = append(items, WorkspaceUnchangedDocumentDiagnosticReport{
+				UnchangedDocumentDiagnosticReport: UnchangedDocumentDiagnosticReport{Kind: "unchanged", ResultID: r.resultID},
+				URI:                               uri,
+				Version:                           version,
+			})
+
+			continue
+		}
+
+		diagnostics := r.diagnostics
+		if diagnostics == nil {
+			diagnostics = []Diagnostic{}
+		}
+		h.stats.linters.published(diagnostics)
+		items = append(items, WorkspaceFullDocumentDiagnosticReport{
+			FullDocumentDiagnosticReport: FullDocumentDiagnosticReport{Kind: "full", ResultID: r.resultID, Items: diagnostics},
+			URI:                          uri,
+			Version:                      version,
+		})
+		full++
+	}
+
+	return items, full
+}
+
+// pull lints uri and stores reports for it and the siblings the run covered.
+func (h *langHandler) pull(uri DocumentURI, revision int) report {
+	if text, ok := h.documents.text(uri); ok {
+		if diagnostics := conflictDiagnostics(text, h.catalog().Sprintf(messages.MergeConflict), h.encoding); len(diagnostics) > 0 {
+			return h.reports.store(uri, revision, diagnostics)
+		}
+	}
+
+	diagnostics, err := h.lint(uri)
+	if err != nil {
+		h.logger.Printf("%s", err)
+	}
+
+	for target, ds := range diagnostics {
+		if target == uri {
+			continue
+		}
+
+		doc, _ := h.documents.get(target)
+		h.reports.store(target, doc.Revision, ds)
+	}
+
+	return h.reports.store(uri, revision, diagnostics[uri])
+}
+
+// checkBinary invalidates the results when the golangci-lint executable was replaced, e.g. by an upgrade.
+func (h *langHandler) checkBinary(name string) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	previous := h.binaryModTime
+	h.binaryModTime = info.ModTime()
+	h.mu.Unlock()
+
+	if !previous.IsZero() && !previous.Equal(info.ModTime()) {
+		h.invalidate("golangci-lint executable changed")
+	}
+}
+
+func (h *langHandler) isPullMode() bool {
+	return pullMode(h.currentOptions(), h.clientCaps)
+}
+
+// pullMode reports whether diagnostics are pulled: the option asks for it and the client supports it.
+func pullMode(opts Options, caps ClientCapabilities) bool {
+	return opts.DiagnosticMode == diagnosticModePull && caps.TextDocument.Diagnostic != nil
+}
+
+// invalidate discards every lint result after something that affects all of them changed:
+// pull clients are asked to pull again, push clients get the open documents republished.
+func (h *langHandler) invalidate(reason string) {
+	h.logger.Printf("golangci-lint-langserver: invalidating lint results: %s", reason)
+	h.linted.clear()
+
+	if h.isPullMode() {
+		h.reports.clear()
+
+		if h.clientCaps.Workspace.Diagnostics.RefreshSupport {
+			h.callAsync("workspace/diagnostic/refresh", nil, nil)
+		}
+
+		return
+	}
+
+	uris := h.documents.uris()
+	go func() {
+		for _, uri := range uris {
+			h.enqueue(uri, TriggerInvalidate)
+		}
+	}()
+}
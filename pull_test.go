@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// TestInvalidateNegotiation checks how each kind of client learns that the lint results were
+// invalidated by a configuration change: pull clients supporting it are asked to refresh and
+// get the documents linted again on their next pull, the others get them republished.
+func TestInvalidateNegotiation(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		pull    bool
+		refresh bool
+		// wantPull is set when the diagnostics are pulled rather than published.
+		wantPull    bool
+		wantRefresh bool
+	}{
+		{name: "pull with refresh", mode: diagnosticModePull, pull: true, refresh: true, wantPull: true, wantRefresh: true},
+		{name: "pull without refresh", mode: diagnosticModePull, pull: true, wantPull: true},
+		{name: "pull asked of a push client", mode: diagnosticModePull, refresh: true},
+		{name: "push to a pull client", mode: diagnosticModePush, pull: true, refresh: true},
+		{name: "push", mode: diagnosticModePush},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refreshed := make(chan struct{}, 16)
+			var caps ClientCapabilities
+			if tt.pull {
+				caps.TextDocument.Diagnostic = &struct{}{}
+			}
+			caps.Workspace.Diagnostics.RefreshSupport = tt.refresh
+
+			ts := newTestServer(t, testConfig{
+				options:      map[string]interface{}{"diagnosticMode": tt.mode},
+				capabilities: caps,
+				handle: func(_ *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+					if req.Method == "workspace/diagnostic/refresh" {
+						refreshed <- struct{}{}
+					}
+
+					return nil, nil
+				},
+			})
+
+			ts.open("a.go")
+			pull := func(previous string) string {
+				t.Helper()
+
+				var report FullDocumentDiagnosticReport
+				params := DocumentDiagnosticParams{TextDocument: TextDocumentIdentifier{URI: ts.uri("a.go")}, PreviousResultID: previous}
+				if err := ts.call("textDocument/diagnostic", params, &report); err != nil {
+					t.Fatal(err)
+				}
+
+				return report.ResultID
+			}
+			var resultID string
+			if tt.wantPull {
+				resultID = pull("")
+			} else {
+				ts.waitPublished("a.go")
+			}
+			runs := len(ts.runner.Runs())
+
+			ts.mu.Lock()
+			delete(ts.diagnostics, ts.uri("a.go"))
+			ts.mu.Unlock()
+			settings, err := json.Marshal(map[string]interface{}{
+				"warmup":           false,
+				"watcher":          watcherOff,
+				"instanceLockWait": 0,
+				"diagnosticMode":   tt.mode,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			params := DidChangeConfigurationParams{Settings: map[string]json.RawMessage{"golangci-lint": settings}}
+			if err := ts.client.Notify(context.Background(), "workspace/didChangeConfiguration", params); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantRefresh {
+				select {
+				case <-refreshed:
+				case <-time.After(testTimeout):
+					t.Fatal("the client wasn't asked to refresh")
+				}
+			}
+			if tt.wantPull {
+				if got := pull(resultID); got != resultID {
+					t.Errorf("result id %q after linting the same issues again, want %q", got, resultID)
+				}
+				if n := len(ts.runner.Runs()); n != runs+1 {
+					t.Errorf("%d runs for the pull after the invalidation, want 1", n-runs)
+				}
+			} else {
+				ts.waitPublished("a.go")
+			}
+
+			select {
+			case <-refreshed:
+				if !tt.wantRefresh {
+					t.Error("the client was asked to refresh")
+				}
+			case <-time.After(50 * time.Millisecond):
+			}
+			if tt.wantPull {
+				ts.mu.Lock()
+				_, published := ts.diagnostics[ts.uri("a.go")]
+				ts.mu.Unlock()
+				if published {
+					t.Error("diagnostics were published to a pull client")
+				}
+			}
+		})
+	}
+}
+
+func TestResultID(t *testing.T) {
+	diagnostics := largeDiagnostics(3)
+	if resultID(nil) != resultID([]Diagnostic{}) {
+		t.Error("no diagnostics and an empty list have different result ids")
+	}
+	if resultID(diagnostics) != resultID(largeDiagnostics(3)) {
+		t.Error("the same diagnostics have different result ids")
+	}
+
+	changed := largeDiagnostics(3)
+	changed[2].Range.Start.Character++
+	if resultID(diagnostics) == resultID(changed) {
+		t.Error("a moved diagnostic kept the result id")
+	}
+}
+
+// largeDiagnostics returns n diagnostics like those of a large generated file.
+func largeDiagnostics(n int) []Diagnostic {
+	source := "golangci-lint"
+	code := "ST1003"
+	diagnostics := make([]Diagnostic, 0, n)
+	for i := 0; i < n; i++ {
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    Range{Start: Position{Line: i * 25, Character: 1}, End: Position{Line: i * 25, Character: 40}},
+			Severity: DSWarning,
+			Code:     &code,
+			Source:   &source,
+			Message:  fmt.Sprintf("stylecheck: var generatedField%d should be generatedFieldID%d", i, i),
+			Data:     &DiagnosticData{IssueID: fmt.Sprintf("%016x", i)},
+		})
+	}
+
+	return diagnostics
+}
+
+// BenchmarkDiagnosticReport compares the serialization of a full report of a large file with
+// that of the unchanged report sent instead when the client has its result id, and with the
+// result id computed for every report stored.
+func BenchmarkDiagnosticReport(b *testing.B) {
+	diagnostics := largeDiagnostics(800)
+	id := resultID(diagnostics)
+
+	b.Run("full", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			out, err := json.Marshal(FullDocumentDiagnosticReport{Kind: "full", ResultID: id, Items: diagnostics})
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.SetBytes(int64(len(out)))
+		}
+	})
+	b.Run("unchanged", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			out, err := json.Marshal(UnchangedDocumentDiagnosticReport{Kind: "unchanged", ResultID: id})
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.SetBytes(int64(len(out)))
+		}
+	})
+	b.Run("resultID", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resultID(diagnostics)
+		}
+	})
+}
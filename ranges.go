@@ -0,0 +1,54 @@
+package main
+
+import "regexp"
+
+const (
+	largeRangeStyleFull            = "full"
+	largeRangeStyleFirstLine       = "firstLine"
+	largeRangeStyleDeclarationOnly = "declarationOnly"
+)
+
+var largeRangeStyles = []string{largeRangeStyleFull, largeRangeStyleFirstLine, largeRangeStyleDeclarationOnly}
+
+// largeRangeLines is the number of lines above which an issue spanning several lines,
+// such as a funlen or gocognit finding for a whole function, is rendered with largeRangeStyle.
+const largeRangeLines = 10
+
+// declarationName matches the name of the function, method or type declared on a line.
+var declarationName = regexp.MustCompile(`^\s*(?:func\s*(?:\([^)]*\)\s*)?|type\s+)([\pL_][\pL\pN_]*)`)
+
+// issueRange returns the range of issue: from its position to the end of its LineRange when
+// that spans several lines, cut down according to style when it spans more than
+// largeRangeLines, and the position golangci-lint reported otherwise.
+func issueRange(issue *Issue, encoding positionEncoding, style string) Range {
+	text := issueLine(issue)
+	start := Position{
+		Line:      max(issue.Pos.Line-1, 0),
+		Character: encoding.character(text, max(issue.Pos.Column-1, 0)),
+	}
+
+	// Code actions find the issue of a diagnostic by its start line, so ranges not starting
+	// on the line of the issue are left alone.
+	from, to := issue.LineRange.From, issue.LineRange.To
+	if from != issue.Pos.Line || to <= from {
+		return Range{Start: start, End: start}
+	}
+
+	if to-from+1 <= largeRangeLines || style == largeRangeStyleFull {
+		// The end of a line is the start of the next one, so the range holds the last line whole.
+		return Range{Start: start, End: Position{Line: to}}
+	}
+
+	if m := declarationName.FindStringSubmatchIndex(text); m != nil && style == largeRangeStyleDeclarationOnly {
+		return Range{
+			Start: Position{Line: start.Line, Character: encoding.character(text, m[2])},
+			End:   Position{Line: start.Line, Character: encoding.character(text, m[3])},
+		}
+	}
+
+	if text == "" {
+		return Range{Start: start, End: Position{Line: from}}
+	}
+
+	return Range{Start: start, End: Position{Line: start.Line, Character: encoding.character(text, len(text))}}
+}
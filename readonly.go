@@ -0,0 +1,60 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// readOnlyPath reports whether path belongs to code the user doesn't edit: the module cache,
+// GOROOT or a vendor directory, which editors open read-only when jumping to a definition.
+// A directory holding the workspace root doesn't count, so that Go itself can be worked on.
+func (h *langHandler) readOnlyPath(path string) bool {
+	_, env := h.commandFor(path)
+	e := h.goEnv.get(env)
+
+	dirs := []string{e.GOMODCACHE, e.GOROOT}
+	if e.GOMODCACHE == "" {
+		// Go versions before 1.15 don't report GOMODCACHE.
+		for _, gopath := range filepath.SplitList(e.GOPATH) {
+			dirs = append(dirs, filepath.Join(gopath, "pkg", "mod"))
+		}
+	}
+	for _, dir := range dirs {
+		if dir != "" && isSubdir(dir, path) && (h.root() == "" || !isSubdir(dir, h.root())) {
+			return true
+		}
+	}
+
+	rel := path
+	if h.root() != "" && isSubdir(h.root(), path) {
+		rel, _ = filepath.Rel(h.root(), path)
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(filepath.Dir(rel)), "/") {
+		if elem == "vendor" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// actionable reports whether the server may offer changes to uri: it must be outside of
+// read-only code and, when there is a workspace, inside it.
+func (h *langHandler) actionable(uri DocumentURI) bool {
+	path := h.paths.uriToPath(string(uri))
+	if h.readOnlyPath(path) {
+		return false
+	}
+
+	dirs := h.workspaceDirs()
+	if len(dirs) == 0 {
+		return true
+	}
+	for _, dir := range dirs {
+		if isSubdir(dir, path) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadOnlyPath(t *testing.T) {
+	tmp := t.TempDir()
+	path := func(name string) string {
+		return filepath.Join(tmp, filepath.FromSlash(name))
+	}
+	modcache := path("gopath/pkg/mod")
+	goroot := path("goroot")
+
+	tests := []struct {
+		name       string
+		root       string
+		env        goEnv
+		file       string
+		readOnly   bool
+		actionable bool
+	}{
+		{name: "workspace file", root: path("work"), file: path("work/a.go"), actionable: true},
+		{name: "module cache", root: path("work"), file: path("gopath/pkg/mod/example.com/dep@v1.0.0/dep.go"), readOnly: true},
+		{name: "GOROOT", root: path("work"), file: path("goroot/src/fmt/print.go"), readOnly: true},
+		{name: "vendor", root: path("work"), file: path("work/vendor/example.com/dep/dep.go"), readOnly: true},
+		{name: "nested vendor", root: path("work"), file: path("work/tools/vendor/example.com/dep/dep.go"), readOnly: true},
+		{name: "vendor above the root", root: path("vendor/work"), file: path("vendor/work/a.go"), actionable: true},
+		{name: "package named like vendor", root: path("work"), file: path("work/vendored/v.go"), actionable: true},
+		{name: "outside the workspace", root: path("work"), file: path("other/b.go")},
+		{name: "no workspace", file: path("other/b.go"), actionable: true},
+		{name: "vendor without a workspace", file: path("other/vendor/example.com/dep/dep.go"), readOnly: true},
+		{name: "workspace in the module cache", root: path("gopath/pkg/mod/example.com/dep@v1.0.0"), file: path("gopath/pkg/mod/example.com/dep@v1.0.0/dep.go"), actionable: true},
+		{name: "workspace in GOROOT", root: path("goroot/src"), file: path("goroot/src/fmt/print.go"), actionable: true},
+		{
+			name:     "module cache of GOPATH before Go 1.15",
+			root:     path("work"),
+			env:      goEnv{GOPATH: path("gopath"), GOROOT: goroot},
+			file:     path("gopath/pkg/mod/example.com/dep@v1.0.0/dep.go"),
+			readOnly: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newLangHandler(&testLogger{}, false)
+			h.rootDir = tt.root
+			if tt.env == (goEnv{}) {
+				tt.env = goEnv{GOPATH: path("gopath"), GOMODCACHE: modcache, GOROOT: goroot}
+			}
+			// The go env of the server's environment, with no folder override adding variables.
+			h.goEnv.probed = map[string]goEnv{"": tt.env}
+
+			if got := h.readOnlyPath(tt.file); got != tt.readOnly {
+				t.Errorf("readOnlyPath() = %v, want %v", got, tt.readOnly)
+			}
+			if got := h.actionable(pathToURI(tt.file)); got != tt.actionable {
+				t.Errorf("actionable() = %v, want %v", got, tt.actionable)
+			}
+		})
+	}
+}
+
+// TestReadOnlyCodeActions checks that no code action is offered for a vendored file, and that
+// resolving one for it fails with a message naming the file.
+func TestReadOnlyCodeActions(t *testing.T) {
+	ts := newTestServer(t, testConfig{files: map[string]string{
+		"vendor/example.com/dep/dep.go": "package dep\n",
+	}})
+	uri := ts.uri("vendor/example.com/dep/dep.go")
+
+	var actions []CodeAction
+	params := CodeActionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Context:      CodeActionContext{Diagnostics: []Diagnostic{{Message: "unused"}}},
+	}
+	if err := ts.call("textDocument/codeAction", params, &actions); err != nil {
+		t.Fatal(err)
+	}
+	if actions == nil || len(actions) != 0 {
+		t.Errorf("code actions %+v, want an empty list", actions)
+	}
+
+	action := CodeAction{Title: "fix", Data: &CodeActionData{URI: uri, IssueID: "0", Action: "fix"}}
+	err := ts.call("codeAction/resolve", action, &action)
+	if err == nil || !strings.Contains(err.Error(), ts.path("vendor/example.com/dep/dep.go")) {
+		t.Errorf("resolve error %v, want one naming the read-only file", err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// recovered logs a recovered panic with its stack and counts it.
+func (h *langHandler) recovered(where string, r interface{}) {
+	atomic.AddInt64(&h.stats.panics, 1)
+	h.logger.Printf("golangci-lint-langserver: error: panic in %s: %v\n%s", where, r, debug.Stack())
+	h.dumpTraffic()
+}
+
+// recoverPanic keeps a background goroutine's panic from taking down the server.
+// It must be deferred directly.
+func (h *langHandler) recoverPanic(where string) {
+	if r := recover(); r != nil {
+		h.recovered(where, r)
+	}
+}
+
+// recoverHandler turns a panic while handling req into an InternalError response.
+// Notifications get no response, so their panics are only logged.
+func recoverHandler(h *langHandler, next func(context.Context, *jsonrpc2.Conn, *jsonrpc2.Request) (interface{}, error)) func(context.Context, *jsonrpc2.Conn, *jsonrpc2.Request) (interface{}, error) {
+	return func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				h.recovered(req.Method, r)
+				result, err = nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInternalError, Message: fmt.Sprintf("internal error handling %s: %v", req.Method, r)}
+			}
+		}()
+
+		return next(ctx, conn, req)
+	}
+}
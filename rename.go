@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// handleWorkspaceDidRenameFiles clears the diagnostics of files renamed or moved in the editor
+// and lints the packages they left and the ones they joined, whose contents changed.
+func (h *langHandler) handleWorkspaceDidRenameFiles(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params RenameFilesParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	dirs := make(map[string]DocumentURI)
+	for _, file := range params.Files {
+		oldPath := h.paths.uriToPath(string(file.OldURI))
+		newPath := h.paths.uriToPath(string(file.NewURI))
+
+		for _, uri := range h.publishedUnder(oldPath) {
+			h.clearFile(uri)
+			h.linted.forget(uri)
+		}
+
+		if info, err := os.Stat(newPath); err == nil && info.IsDir() {
+			// The package directories under oldPath are gone; lint the ones they became.
+			h.forgetHiddenUnder(oldPath)
+			for dir, uri := range h.goDirs(newPath) {
+				dirs[dir] = uri
+			}
+
+			continue
+		}
+
+		if filepath.Ext(oldPath) == ".go" {
+			if _, ok := dirs[filepath.Dir(oldPath)]; !ok {
+				dirs[filepath.Dir(oldPath)] = ""
+			}
+		}
+		if filepath.Ext(newPath) == ".go" {
+			dirs[filepath.Dir(newPath)] = file.NewURI
+		}
+	}
+
+	if h.isPullMode() {
+		h.invalidate("files renamed")
+
+		return nil, nil
+	}
+
+	var uris []DocumentURI
+	for dir, uri := range dirs {
+		if uri == "" {
+			uri = h.dirDocument(dir)
+		}
+		if uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+	sort.Slice(uris, func(i, j int) bool { return uris[i] < uris[j] })
+
+	// enqueue blocks until the worker takes the request.
+	go func() {
+		for _, uri := range uris {
+			h.enqueue(uri, TriggerRename)
+		}
+	}()
+
+	return nil, nil
+}
+
+// publishedUnder returns the URIs with diagnostics that are path or lie below it.
+func (h *langHandler) publishedUnder(path string) []DocumentURI {
+	h.publishedMu.Lock()
+	defer h.publishedMu.Unlock()
+
+	var uris []DocumentURI
+	for dir, published := range h.published {
+		for uri := range published {
+			if h.paths.uriToPath(string(uri)) == path || isSubdir(path, dir) {
+				uris = append(uris, uri)
+			}
+		}
+	}
+
+	return uris
+}
+
+// forgetHiddenUnder drops the hidden issue counts of the package directories below path.
+func (h *langHandler) forgetHiddenUnder(path string) {
+	h.publishedMu.Lock()
+	var dirs []string
+	for dir := range h.published {
+		if isSubdir(path, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	h.publishedMu.Unlock()
+
+	for _, dir := range dirs {
+		h.updateHidden(dir, nil)
+	}
+}
+
+// dirDocument picks a file to lint the package in dir with: an open document if there is
+// one, the first Go file on disk otherwise, or "" when dir holds no Go file anymore.
+func (h *langHandler) dirDocument(dir string) DocumentURI {
+	for _, uri := range h.documents.uris() {
+		if filepath.Dir(h.paths.uriToPath(string(uri))) == dir {
+			return uri
+		}
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	if len(matches) == 0 {
+		return ""
+	}
+
+	return h.paths.pathToURI(matches[0])
+}
+
+// goDirs returns, for every directory below root holding Go files, one of those files.
+func (h *langHandler) goDirs(root string) map[string]DocumentURI {
+	dirs := make(map[string]DocumentURI)
+	_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if name := info.Name(); path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+		if dir := filepath.Dir(path); filepath.Ext(path) == ".go" && dirs[dir] == "" {
+			dirs[dir] = h.paths.pathToURI(path)
+		}
+
+		return nil
+	})
+
+	return dirs
+}
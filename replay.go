@@ -0,0 +1,413 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
+)
+
+// recordedMethods are the messages from the client a replay needs.
+var recordedMethods = map[string]bool{
+	"initialize":                          true,
+	"initialized":                         true,
+	"textDocument/didOpen":                true,
+	"textDocument/didChange":              true,
+	"textDocument/didSave":                true,
+	"textDocument/didClose":               true,
+	"workspace/didChangeConfiguration":    true,
+	"workspace/didChangeWatchedFiles":     true,
+	"workspace/didChangeWorkspaceFolders": true,
+	"workspace/didRenameFiles":            true,
+	"workspace/executeCommand":            true,
+}
+
+// replayEvent is one line of a recording: a message from the client, a golangci-lint run
+// or the diagnostics published in response.
+type replayEvent struct {
+	Type    string          `json:"type"` // "message", "run" or "publish"
+	Method  string          `json:"method,omitempty"`
+	Request bool            `json:"request,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Run     *recordedRun    `json:"run,omitempty"`
+}
+
+type recordedRun struct {
+	Dir      string          `json:"dir"`
+	Args     []string        `json:"args"`
+	Stdout   json.RawMessage `json:"stdout,omitempty"`
+	Output   string          `json:"output,omitempty"` // stdout that isn't JSON
+	Stderr   string          `json:"stderr,omitempty"`
+	ExitCode int             `json:"exitCode"`
+}
+
+// recorder writes the events of a session for -record, hashing the file paths in them
+// unless keepPaths is set.
+type recorder struct {
+	mu        sync.Mutex
+	enc       *json.Encoder
+	keepPaths bool
+	// redactSources drops the source lines of the recorded issues.
+	redactSources bool
+}
+
+func newRecorder(w io.Writer, keepPaths, redactSources bool) *recorder {
+	return &recorder{enc: json.NewEncoder(w), keepPaths: keepPaths, redactSources: redactSources}
+}
+
+func (r *recorder) write(event replayEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_ = r.enc.Encode(event)
+}
+
+// connOpts records the messages of the client a replay needs and the published diagnostics.
+func (r *recorder) connOpts() []jsonrpc2.ConnOpt {
+	return []jsonrpc2.ConnOpt{
+		jsonrpc2.OnRecv(func(req *jsonrpc2.Request, resp *jsonrpc2.Response) {
+			if req == nil || resp != nil || !recordedMethods[req.Method] || req.Params == nil {
+				return
+			}
+			r.write(replayEvent{Type: "message", Method: req.Method, Request: !req.Notif, Params: r.anonymizeParams(*req.Params)})
+		}),
+		jsonrpc2.OnSend(func(req *jsonrpc2.Request, _ *jsonrpc2.Response) {
+			if req == nil || req.Method != "textDocument/publishDiagnostics" || req.Params == nil {
+				return
+			}
+			r.write(replayEvent{Type: "publish", Params: r.anonymizeParams(*req.Params)})
+		}),
+	}
+}
+
+// run records a golangci-lint run.
+func (r *recorder) run(dir string, args []string, stdout, stderr []byte, err error) {
+	run := &recordedRun{Dir: r.path(dir), Stderr: string(stderr)}
+	for _, arg := range args {
+		run.Args = append(run.Args, r.arg(arg))
+	}
+	if out := r.anonymizeOutput(stdout); json.Valid(out) {
+		run.Stdout = out
+	} else {
+		run.Output = string(stdout)
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		run.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		run.ExitCode = -1
+		run.Stderr += err.Error()
+	}
+
+	r.write(replayEvent{Type: "run", Run: run})
+}
+
+// path replaces every element of path by a hash, keeping the names and suffixes that
+// change how the server treats a file.
+func (r *recorder) path(path string) string {
+	if r.keepPaths {
+		return path
+	}
+
+	elems := strings.Split(filepath.ToSlash(path), "/")
+	for i, elem := range elems {
+		switch elem {
+		case "", ".", "..", "...", goModFile, "go.work", settingsFile:
+			continue
+		}
+		if strings.HasPrefix(elem, ".golangci.") {
+			continue
+		}
+
+		suffix := filepath.Ext(elem)
+		if strings.HasSuffix(elem, "_test.go") {
+			suffix = "_test.go"
+		}
+		sum := sha256.Sum256([]byte(strings.TrimSuffix(elem, suffix)))
+		elems[i] = "h" + hex.EncodeToString(sum[:4]) + suffix
+	}
+
+	return filepath.FromSlash(strings.Join(elems, "/"))
+}
+
+func (r *recorder) uri(uri string) string {
+	if r.keepPaths || !strings.HasPrefix(uri, "file://") {
+		return uri
+	}
+
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(r.path(uriToPath(uri)))}).String()
+}
+
+// arg hashes an argument that is an absolute path or a flag with one.
+func (r *recorder) arg(arg string) string {
+	if filepath.IsAbs(arg) {
+		return r.path(arg)
+	}
+	if i := strings.Index(arg, "="); strings.HasPrefix(arg, "-") && i > 0 && filepath.IsAbs(arg[i+1:]) {
+		return arg[:i+1] + r.path(arg[i+1:])
+	}
+
+	return arg
+}
+
+// anonymizeParams hashes the file URIs of params and drops environment variables.
+func (r *recorder) anonymizeParams(params json.RawMessage) json.RawMessage {
+	var v interface{}
+	if err := json.Unmarshal(params, &v); err != nil {
+		return params
+	}
+
+	b, err := json.Marshal(r.anonymize(v))
+	if err != nil {
+		return params
+	}
+
+	return b
+}
+
+func (r *recorder) anonymize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			switch {
+			case key == "env":
+				delete(v, key)
+			case key == "rootPath":
+				if s, ok := value.(string); ok {
+					v[key] = r.path(s)
+				}
+			default:
+				v[key] = r.anonymize(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = r.anonymize(value)
+		}
+	case string:
+		return r.uri(v)
+	}
+
+	return v
+}
+
+// anonymizeOutput hashes the file names of the issues in the JSON output of golangci-lint
+// and drops their source lines if asked to.
+func (r *recorder) anonymizeOutput(stdout []byte) json.RawMessage {
+	var output map[string]interface{}
+	if err := json.Unmarshal(stdout, &output); err != nil {
+		return stdout
+	}
+
+	issues, _ := output["Issues"].([]interface{})
+	for _, issue := range issues {
+		issue, _ := issue.(map[string]interface{})
+		if r.redactSources {
+			delete(issue, "SourceLines")
+		}
+		if pos, ok := issue["Pos"].(map[string]interface{}); ok {
+			if filename, ok := pos["Filename"].(string); ok {
+				pos["Filename"] = r.path(filename)
+			}
+		}
+	}
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return stdout
+	}
+
+	return b
+}
+
+// recordingRunner records the runs of next.
+type recordingRunner struct {
+	next lint.Runner
+	rec  *recorder
+}
+
+func (r recordingRunner) Start(cmd *exec.Cmd) (io.Reader, func() error, error) {
+	var stdout, stderr bytes.Buffer
+	if cmd.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, &stderr)
+	} else {
+		cmd.Stderr = &stderr
+	}
+
+	out, wait, err := r.next.Start(cmd)
+	if err != nil {
+		r.rec.run(cmd.Dir, cmd.Args, nil, nil, err)
+
+		return nil, nil, err
+	}
+
+	return io.TeeReader(out, &stdout), func() error {
+		err := wait()
+		r.rec.run(cmd.Dir, cmd.Args, stdout.Bytes(), stderr.Bytes(), err)
+
+		return err
+	}, nil
+}
+
+// replayRunner hands out the recorded runs in their order instead of running golangci-lint.
+type replayRunner struct {
+	mu   sync.Mutex
+	runs []*recordedRun
+	last time.Time
+}
+
+func (r *replayRunner) Start(cmd *exec.Cmd) (io.Reader, func() error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.last = time.Now()
+	if len(r.runs) == 0 {
+		return nil, nil, fmt.Errorf("no recorded run left for %s", strings.Join(cmd.Args, " "))
+	}
+	run := r.runs[0]
+	r.runs = r.runs[1:]
+
+	stdout := []byte(run.Output)
+	if len(run.Stdout) > 0 {
+		stdout = run.Stdout
+	}
+
+	return bytes.NewReader(stdout), func() error {
+		if run.ExitCode != 0 {
+			return fmt.Errorf("exit status %d: %s", run.ExitCode, run.Stderr)
+		}
+
+		return nil
+	}, nil
+}
+
+func (r *replayRunner) lastStart() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.last
+}
+
+const (
+	// replayPause is how long the server has to be idle before the next message is sent, so
+	// that debouncing doesn't merge messages the recorded session saw apart.
+	replayPause = 200 * time.Millisecond
+	// replayQuietPeriod is how long a replay waits for further runs or diagnostics before it ends.
+	replayQuietPeriod = time.Second
+)
+
+// runReplay feeds the messages of the recording at path through a handler whose golangci-lint
+// runs return the recorded outputs, and writes the diagnostics it publishes to w, one
+// PublishDiagnosticsParams per line.
+func runReplay(w io.Writer, logger logger, noLinterName bool, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var (
+		messages []replayEvent
+		runs     []*recordedRun
+	)
+	dec := json.NewDecoder(f)
+	for {
+		var event replayEvent
+		if err := dec.Decode(&event); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		switch event.Type {
+		case "message":
+			messages = append(messages, event)
+		case "run":
+			runs = append(runs, event.Run)
+		}
+	}
+
+	h := newLangHandler(logger, noLinterName)
+	h.trust.all = true
+	replay := &replayRunner{runs: runs}
+	h.runner = replay
+
+	ctx := context.Background()
+	serverSide, clientSide := net.Pipe()
+	server := jsonrpc2.NewConn(ctx, jsonrpc2.NewBufferedStream(serverSide, jsonrpc2.VSCodeObjectCodec{}), newHandler(h))
+	defer server.Close()
+
+	var (
+		mu           sync.Mutex
+		lastActivity time.Time
+		enc          = json.NewEncoder(w)
+	)
+	client := jsonrpc2.NewConn(ctx, jsonrpc2.NewBufferedStream(clientSide, jsonrpc2.VSCodeObjectCodec{}), jsonrpc2.HandlerWithError(
+		func(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+			if req.Method == "textDocument/publishDiagnostics" && req.Params != nil {
+				mu.Lock()
+				lastActivity = time.Now()
+				_ = enc.Encode(req.Params)
+				mu.Unlock()
+			}
+
+			return nil, nil
+		}))
+	defer client.Close()
+
+	idle := func(d time.Duration) {
+		for {
+			time.Sleep(d / 10)
+
+			mu.Lock()
+			last := lastActivity
+			mu.Unlock()
+			if start := replay.lastStart(); start.After(last) {
+				last = start
+			}
+			if time.Since(last) >= d {
+				return
+			}
+		}
+	}
+
+	for _, message := range messages {
+		idle(replayPause)
+
+		params := message.Params
+		if message.Request {
+			var result json.RawMessage
+			if err := client.Call(ctx, message.Method, &params, &result); err != nil {
+				logger.Printf("golangci-lint-langserver: replay: %s: %s", message.Method, err)
+			}
+		} else if err := client.Notify(ctx, message.Method, &params); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		lastActivity = time.Now()
+		mu.Unlock()
+	}
+
+	idle(replayQuietPeriod)
+
+	return client.Call(ctx, "shutdown", nil, nil)
+}
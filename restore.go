@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// restoreStateVersion changes whenever restoreState changes incompatibly; files of other
+// versions are ignored.
+const restoreStateVersion = 1
+
+// maxRestoreStateSize bounds the state file. Files beyond it are left out and linted again.
+const maxRestoreStateSize = 8 << 20
+
+// restoreState is what restoreDiagnostics keeps of a workspace between two sessions.
+type restoreState struct {
+	Version int            `json:"version"`
+	Root    string         `json:"root"`
+	Files   []restoredFile `json:"files"`
+}
+
+// restoredFile holds the diagnostics of a file with the hash of its content when they were saved.
+type restoredFile struct {
+	URI         DocumentURI  `json:"uri"`
+	Hash        string       `json:"hash"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// restoreStatePath returns the state file of the workspace at root, under os.UserCacheDir.
+func restoreStatePath(root string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(root))
+
+	return filepath.Join(dir, "golangci-lint-langserver", "diagnostics", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+func fileHash(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// saveDiagnostics writes the diagnostics published for the files under the root to the
+// state file, with the hashes of the files as they are now.
+func (h *langHandler) saveDiagnostics() {
+	if !h.currentOptions().RestoreDiagnostics || h.root() == "" {
+		return
+	}
+
+	path, err := restoreStatePath(h.root())
+	if err != nil {
+		h.logger.Printf("golangci-lint-langserver: not saving diagnostics: %s", err)
+
+		return
+	}
+
+	published := h.publisher.published()
+	uris := make([]string, 0, len(published))
+	for uri := range published {
+		uris = append(uris, string(uri))
+	}
+	sort.Strings(uris)
+
+	state := restoreState{Version: restoreStateVersion, Root: h.root()}
+	size := 0
+	for _, uri := range uris {
+		filePath := h.paths.uriToPath(uri)
+		if !isSubdir(h.root(), filePath) {
+			continue
+		}
+		hash, err := fileHash(filePath)
+		if err != nil {
+			continue
+		}
+
+		file := restoredFile{URI: DocumentURI(uri), Hash: hash, Diagnostics: published[DocumentURI(uri)]}
+		b, err := json.Marshal(file)
+		if err != nil {
+			continue
+		}
+		if size += len(b); size > maxRestoreStateSize {
+			h.logger.Printf("golangci-lint-langserver: saving the diagnostics of %d of %d files, the rest exceeds %d bytes", len(state.Files), len(uris), maxRestoreStateSize)
+
+			break
+		}
+		state.Files = append(state.Files, file)
+	}
+
+	b, err := json.Marshal(state)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o700)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(path, b, 0o600)
+	}
+	if err != nil {
+		h.logger.Printf("golangci-lint-langserver: failed to save diagnostics: %s", err)
+	}
+}
+
+// restoreDiagnostics publishes the diagnostics saved by the last session for the files that
+// didn't change since, and lints the packages of the others again.
+func (h *langHandler) restoreDiagnostics() {
+	defer h.recoverPanic("restore diagnostics")
+
+	if !h.currentOptions().RestoreDiagnostics || h.root() == "" || h.isPullMode() {
+		return
+	}
+
+	path, err := restoreStatePath(h.root())
+	if err != nil {
+		return
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var state restoreState
+	if err := json.Unmarshal(b, &state); err != nil || state.Version != restoreStateVersion || state.Root != h.root() {
+		h.logger.Printf("golangci-lint-langserver: ignoring saved diagnostics in %s from another version or workspace", path)
+
+		return
+	}
+
+	restored := make(map[DocumentURI][]Diagnostic)
+	stale := make(map[string]DocumentURI)
+	for _, file := range state.Files {
+		filePath := h.paths.uriToPath(string(file.URI))
+		if hash, err := fileHash(filePath); err == nil && hash == file.Hash {
+			restored[file.URI] = file.Diagnostics
+
+			continue
+		}
+		if _, err := os.Stat(filePath); err == nil {
+			stale[filepath.Dir(filePath)] = file.URI
+		}
+	}
+
+	h.logger.Printf("golangci-lint-langserver: restored the diagnostics of %d files, linting %d packages again", len(restored), len(stale))
+	h.publishFiles(restored)
+
+	for _, uri := range stale {
+		h.enqueue(uri, TriggerRestore)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+const cmdOpenRuleDocs = "golangci-lint.openRuleDocs"
+
+var (
+	ruleIDRegexp    = regexp.MustCompile(`^((?:G|SA|S|ST|QF|U)\d{3,4})\b`)
+	gosecRuleRegexp = regexp.MustCompile(`^G\d+$`)
+	staticcheckRule = regexp.MustCompile(`^(SA|S|ST|QF|U)\d+$`)
+)
+
+// ruleID extracts a rule ID like G401 or SA1019 from the issue text, or returns "".
+func ruleID(issue *Issue) string {
+	return ruleIDRegexp.FindString(issue.Text)
+}
+
+// RuleDocsArgs is the argument of the golangci-lint.openRuleDocs command.
+type RuleDocsArgs struct {
+	Code   string `json:"code"`
+	Linter string `json:"linter,omitempty"`
+}
+
+// ruleDocsURL returns the documentation page of a rule ID, falling back to the linter page.
+func ruleDocsURL(args RuleDocsArgs) (string, bool) {
+	switch {
+	case gosecRuleRegexp.MatchString(args.Code):
+		return "https://securego.io/docs/rules/" + strings.ToLower(args.Code) + ".html", true
+	case staticcheckRule.MatchString(args.Code):
+		return "https://staticcheck.dev/docs/checks/#" + args.Code, true
+	case args.Linter != "":
+		return "https://golangci-lint.run/usage/linters/#" + strings.ToLower(args.Linter), true
+	}
+
+	return "", false
+}
+
+func (h *langHandler) executeOpenRuleDocs(arguments []json.RawMessage) error {
+	var args RuleDocsArgs
+	if len(arguments) > 0 {
+		if err := json.Unmarshal(arguments[0], &args); err != nil {
+			return err
+		}
+	}
+
+	url, ok := h.docsURL(args)
+	if !ok {
+		h.showMessage(MTInfo, h.catalog().Sprintf(messages.NoRuleDocs, args.Code))
+
+		return nil
+	}
+
+	if !h.clientCaps.Window.ShowDocument.Support {
+		h.showMessage(MTInfo, url)
+
+		return nil
+	}
+
+	h.callAsync("window/showDocument", &ShowDocumentParams{URI: url, External: true}, &ShowDocumentResult{})
+
+	return nil
+}
+
+// ruleDocsAction offers to open the docs of the rule behind issue.
+func (h *langHandler) ruleDocsAction(issue *Issue) (CodeAction, bool) {
+	args := RuleDocsArgs{Code: ruleID(issue), Linter: issue.FromLinter}
+	if _, ok := h.docsURL(args); !ok {
+		return CodeAction{}, false
+	}
+
+	name := args.Code
+	if name == "" {
+		name = args.Linter
+	}
+	title := h.catalog().Sprintf(messages.OpenRuleDocs, name)
+
+	return CodeAction{
+		Title:   title,
+		Kind:    CAKQuickFix,
+		Command: &Command{Title: title, Command: h.commandID(cmdOpenRuleDocs), Arguments: []interface{}{args}},
+	}, true
+}
+
+func formatCode(code string) *string {
+	if code == "" {
+		return nil
+	}
+
+	return &code
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// runInfo describes how golangci-lint was executed, to explain differences with CI.
+type runInfo struct {
+	Dir        string    `json:"dir"`
+	Args       []string  `json:"args"`
+	ConfigPath string    `json:"configPath,omitempty"`
+	Version    string    `json:"version,omitempty"`
+	Time       time.Time `json:"time"`
+	// Fast is set for the first pass of the twoPass option, which runs only the fast linters.
+	Fast bool `json:"fast,omitempty"`
+}
+
+var configFileNames = []string{".golangci.yml", ".golangci.yaml", ".golangci.toml", ".golangci.json"}
+
+// findConfigPath returns the config golangci-lint uses for a run from dir:
+// the -c/--config argument or the nearest config file in dir or its parents.
+func findConfigPath(dir string, args []string) string {
+	for i, arg := range args {
+		switch {
+		case (arg == "-c" || arg == "--config") && i+1 < len(args):
+			return absFrom(dir, args[i+1])
+		case strings.HasPrefix(arg, "--config="):
+			return absFrom(dir, strings.TrimPrefix(arg, "--config="))
+		case arg == "--no-config":
+			return ""
+		}
+	}
+
+	for d := dir; ; d = filepath.Dir(d) {
+		for _, name := range configFileNames {
+			path := filepath.Join(d, name)
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+
+		if parent := filepath.Dir(d); parent == d {
+			return ""
+		}
+	}
+}
+
+func absFrom(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
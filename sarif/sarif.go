@@ -0,0 +1,157 @@
+// Package sarif converts lint findings into a minimal SARIF 2.1.0 log, as ingested by code
+// scanning and security tooling.
+package sarif
+
+import "sort"
+
+const (
+	// Version is the SARIF version of the logs Build returns.
+	Version = "2.1.0"
+	// Schema is the JSON schema of Version.
+	Schema = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// Levels of results.
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+)
+
+// Finding is a problem reported by a linter, the input of Build.
+type Finding struct {
+	// Linter becomes the rule of the result.
+	Linter  string
+	HelpURI string
+	Level   string
+	Message string
+	// URI locates the file, preferably relative to the repository root.
+	URI string
+	// StartLine and StartColumn are 1-based; 0 leaves them out. EndLine is inclusive.
+	StartLine   int
+	StartColumn int
+	EndLine     int
+}
+
+// Log is the sarifLog object, the root of the document.
+type Log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is the output of a single invocation of a tool.
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool describes the analysis tool of a run.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is the toolComponent of the tool itself.
+type Driver struct {
+	Name           string                `json:"name"`
+	Version        string                `json:"version,omitempty"`
+	InformationURI string                `json:"informationUri,omitempty"`
+	Rules          []ReportingDescriptor `json:"rules"`
+}
+
+// ReportingDescriptor describes a rule, here a linter.
+type ReportingDescriptor struct {
+	ID      string `json:"id"`
+	HelpURI string `json:"helpUri,omitempty"`
+}
+
+// Result is a single finding.
+type Result struct {
+	RuleID    string     `json:"ruleId"`
+	RuleIndex int        `json:"ruleIndex"`
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations"`
+}
+
+// Message holds the plain text of a result.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Location is where a result was found.
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+// PhysicalLocation is a region of a file.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+// ArtifactLocation locates a file.
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// Region is a range of lines of a file, 1-based and inclusive.
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+}
+
+// Build returns a log with a single run of the tool named name, with one rule per linter of
+// findings, sorted by ID, and the results in the order of findings.
+func Build(name, version, informationURI string, findings []Finding) *Log {
+	helpURIs := make(map[string]string)
+	for _, finding := range findings {
+		if helpURIs[finding.Linter] == "" {
+			helpURIs[finding.Linter] = finding.HelpURI
+		}
+	}
+
+	ids := make([]string, 0, len(helpURIs))
+	for id := range helpURIs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	driver := Driver{Name: name, Version: version, InformationURI: informationURI, Rules: make([]ReportingDescriptor, 0, len(ids))}
+	index := make(map[string]int, len(ids))
+	for i, id := range ids {
+		driver.Rules = append(driver.Rules, ReportingDescriptor{ID: id, HelpURI: helpURIs[id]})
+		index[id] = i
+	}
+
+	results := make([]Result, 0, len(findings))
+	for _, finding := range findings {
+		location := PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: finding.URI}}
+		if finding.StartLine > 0 {
+			location.Region = &Region{StartLine: finding.StartLine, StartColumn: finding.StartColumn}
+			if finding.EndLine > finding.StartLine {
+				location.Region.EndLine = finding.EndLine
+			}
+		}
+
+		level := finding.Level
+		if level == "" {
+			level = LevelWarning
+		}
+
+		results = append(results, Result{
+			RuleID:    finding.Linter,
+			RuleIndex: index[finding.Linter],
+			Level:     level,
+			Message:   Message{Text: finding.Message},
+			Locations: []Location{{PhysicalLocation: location}},
+		})
+	}
+
+	return &Log{
+		Version: Version,
+		Schema:  Schema,
+		Runs:    []Run{{Tool: Tool{Driver: driver}, Results: results}},
+	}
+}
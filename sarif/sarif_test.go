@@ -0,0 +1,83 @@
+package sarif
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		findings []Finding
+	}{
+		{name: "empty"},
+		{
+			name: "findings",
+			findings: []Finding{
+				{
+					Linter:      "unused",
+					HelpURI:     "https://golangci-lint.run/usage/linters/#unused",
+					Level:       LevelWarning,
+					Message:     "func `f` is unused",
+					URI:         "pkg/a.go",
+					StartLine:   3,
+					StartColumn: 6,
+				},
+				{
+					Linter:      "errcheck",
+					HelpURI:     "https://golangci-lint.run/usage/linters/#errcheck",
+					Level:       LevelError,
+					Message:     "Error return value is not checked",
+					URI:         "pkg/b.go",
+					StartLine:   10,
+					StartColumn: 2,
+					EndLine:     12,
+				},
+				{
+					// No level and a single line: the default level and no end line.
+					Linter:    "unused",
+					Message:   "field `x` is unused",
+					URI:       "pkg/b.go",
+					StartLine: 20,
+					EndLine:   20,
+				},
+				{
+					// No position: no region.
+					Linter:  "typecheck",
+					Level:   LevelNote,
+					Message: "could not import example.com/missing",
+					URI:     "file:///work/pkg/c.go",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.MarshalIndent(Build("golangci-lint", "1.64.8", "https://golangci-lint.run", tt.findings), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			b = append(b, '\n')
+
+			golden := filepath.Join("testdata", tt.name+".sarif")
+			if *update {
+				if err := os.WriteFile(golden, b, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b, want) {
+				t.Errorf("log differs from %s, run go test -update to update it:\n%s", golden, b)
+			}
+		})
+	}
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Trigger tells what caused a lint request.
+type Trigger string
+
+const (
+	TriggerOpen         Trigger = "open"
+	TriggerSave         Trigger = "save"
+	TriggerWatchedFiles Trigger = "watchedFiles"
+	TriggerInvalidate   Trigger = "invalidate"
+	TriggerStale        Trigger = "stale"
+	TriggerCompanion    Trigger = "companion"
+	TriggerRename       Trigger = "rename"
+	TriggerRestore      Trigger = "restore"
+	// TriggerFullPass follows the fast pass of the twoPass option.
+	TriggerFullPass Trigger = "fullPass"
+)
+
+// background reports whether requests made for t run in the background: nobody waits for them.
+func (t Trigger) background() bool {
+	switch t {
+	case TriggerWatchedFiles, TriggerInvalidate, TriggerStale, TriggerCompanion, TriggerRestore:
+		return true
+	}
+
+	return false
+}
+
+// Request asks for a lint of the package of a document.
+type Request struct {
+	URI     DocumentURI
+	Trigger Trigger
+	// Version is the version of the document when the request was made, or 0 if it isn't open.
+	Version    int
+	EnqueuedAt time.Time
+	// Generation tells the full pass of the twoPass option which request it follows.
+	Generation int
+}
+
+// Scheduler decides when and in which order lint requests run.
+type Scheduler interface {
+	// Enqueue adds req, reporting false if the scheduler is closed.
+	Enqueue(req Request) bool
+	// Go runs job, a run of golangci-lint other than a lint request such as the warm-up or a
+	// workspace lint, reporting false if the scheduler is closed.
+	Go(job func()) bool
+	// Drain runs the requests until the scheduler is closed or ctx is done.
+	Drain(ctx context.Context)
+	// Close stops Drain; requests enqueued afterwards are dropped.
+	Close()
+}
+
+// fifoScheduler runs the requests one at a time in the order they arrive, those the user waits
+// for before background ones. Enqueue blocks until the worker takes the request, so a flood of
+// requests can't pile up in memory.
+type fifoScheduler struct {
+	requests  chan Request
+	urgent    chan Request
+	done      chan struct{}
+	closeOnce sync.Once
+	run       func(Request)
+}
+
+func newFIFOScheduler(run func(Request)) *fifoScheduler {
+	return &fifoScheduler{
+		requests: make(chan Request),
+		urgent:   make(chan Request),
+		done:     make(chan struct{}),
+		run:      run,
+	}
+}
+
+func (s *fifoScheduler) Enqueue(req Request) bool {
+	// requests is never closed, so senders racing with Close can't panic.
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+
+	requests := s.urgent
+	if req.Trigger.background() {
+		requests = s.requests
+	}
+
+	select {
+	case requests <- req:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// Go runs job right away: the process limit orders it against the lint requests.
+func (s *fifoScheduler) Go(job func()) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+
+	go job()
+
+	return true
+}
+
+func (s *fifoScheduler) Drain(ctx context.Context) {
+	for {
+		select {
+		case req := <-s.urgent:
+			s.run(req)
+
+			continue
+		default:
+		}
+
+		select {
+		case req := <-s.urgent:
+			s.run(req)
+		case req := <-s.requests:
+			s.run(req)
+		case <-s.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *fifoScheduler) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// coalescingScheduler never blocks Enqueue: the requests arriving while a lint runs wait
+// together, one per document, and run as a single batch once it is done. Jobs take their turn
+// on the same worker, after the pending requests, so that only one runs at a time.
+type coalescingScheduler struct {
+	mu        sync.Mutex
+	pending   []Request
+	jobs      []func()
+	wake      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	run       func(Request)
+	runBatch  func([]Request)
+}
+
+func newCoalescingScheduler(run func(Request), runBatch func([]Request)) *coalescingScheduler {
+	return &coalescingScheduler{
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		run:      run,
+		runBatch: runBatch,
+	}
+}
+
+func (s *coalescingScheduler) Enqueue(req Request) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+
+	s.mu.Lock()
+	for i, pending := range s.pending {
+		if pending.URI == req.URI {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+
+			break
+		}
+	}
+	s.pending = append(s.pending, req)
+	s.mu.Unlock()
+
+	s.signal()
+
+	return true
+}
+
+// Go queues job behind the requests and jobs already waiting.
+func (s *coalescingScheduler) Go(job func()) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+
+	s.mu.Lock()
+	s.jobs = append(s.jobs, job)
+	s.mu.Unlock()
+
+	s.signal()
+
+	return true
+}
+
+func (s *coalescingScheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *coalescingScheduler) Drain(ctx context.Context) {
+	for {
+		select {
+		case <-s.wake:
+		case <-s.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+
+		for s.next() {
+			select {
+			case <-s.done:
+				return
+			case <-ctx.Done():
+				return
+			default:
+			}
+		}
+	}
+}
+
+// next runs the pending requests, or the first job when none is pending, reporting false if
+// there was nothing to run.
+func (s *coalescingScheduler) next() bool {
+	s.mu.Lock()
+	reqs := s.pending
+	s.pending = nil
+	var job func()
+	if len(reqs) == 0 && len(s.jobs) > 0 {
+		job = s.jobs[0]
+		s.jobs = s.jobs[1:]
+	}
+	s.mu.Unlock()
+
+	switch {
+	case len(reqs) == 1:
+		s.run(reqs[0])
+	case len(reqs) > 1:
+		s.runBatch(reqs)
+	case job != nil:
+		job()
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (s *coalescingScheduler) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// switchScheduler hands the requests to the coalescing scheduler while singleFlight
+// reports true, and to the FIFO scheduler otherwise.
+type switchScheduler struct {
+	fifo         *fifoScheduler
+	coalescing   *coalescingScheduler
+	singleFlight func() bool
+}
+
+func (s *switchScheduler) Enqueue(req Request) bool {
+	if s.singleFlight() {
+		return s.coalescing.Enqueue(req)
+	}
+
+	return s.fifo.Enqueue(req)
+}
+
+func (s *switchScheduler) Go(job func()) bool {
+	if s.singleFlight() {
+		return s.coalescing.Go(job)
+	}
+
+	return s.fifo.Go(job)
+}
+
+func (s *switchScheduler) Drain(ctx context.Context) {
+	go s.coalescing.Drain(ctx)
+	s.fifo.Drain(ctx)
+}
+
+func (s *switchScheduler) Close() {
+	s.coalescing.Close()
+	s.fifo.Close()
+}
+
+const defaultMaxConcurrency = 4
+
+// processLimit caps the number of golangci-lint processes running at once. Background
+// processes give way to those of the lints the user waits for: they don't start while such a
+// lint runs or waits, and the running ones are paused meanwhile, no longer counting against the cap.
+type processLimit struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	running int
+	max     func() int
+	// exclusive reports whether only one process may exist at a time, as in single-flight
+	// mode: the background processes are then cancelled rather than paused.
+	exclusive func() bool
+	// users counts the user lints running or waiting for a process.
+	users int
+	// background holds the running background processes, paused counting those paused.
+	background map[*exec.Cmd]*backgroundProcess
+	paused     int
+	// preempted is called whenever a background process is paused or cancelled.
+	preempted func()
+}
+
+func newProcessLimit(max func() int, exclusive func() bool, preempted func()) *processLimit {
+	l := &processLimit{max: max, exclusive: exclusive, preempted: preempted, background: make(map[*exec.Cmd]*backgroundProcess)}
+	l.cond = sync.NewCond(&l.mu)
+
+	return l
+}
+
+// acquire waits until a process may start.
+func (l *processLimit) acquire(background bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if background {
+		for l.users > 0 || l.running >= l.max() {
+			l.cond.Wait()
+		}
+		l.running++
+
+		return
+	}
+
+	l.users++
+	if l.users == 1 {
+		l.preemptBackground()
+	}
+	for l.running-l.paused >= l.max() {
+		l.cond.Wait()
+	}
+	l.running++
+}
+
+func (l *processLimit) release(background bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.running--
+	if !background {
+		l.users--
+		if l.users == 0 {
+			l.resumeBackground()
+		}
+	}
+	l.cond.Broadcast()
+}
+
+// pendingLints holds the requests handed to the scheduler that haven't started yet, so that a
+// document asked to be linted several times for the same trigger in the meantime is linted once.
+type pendingLints struct {
+	mu       sync.Mutex
+	requests map[Request]struct{}
+}
+
+// add reports false if a request for uri and trigger is already pending.
+func (p *pendingLints) add(uri DocumentURI, trigger Trigger) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := Request{URI: uri, Trigger: trigger}
+	if _, ok := p.requests[key]; ok {
+		return false
+	}
+	if p.requests == nil {
+		p.requests = make(map[Request]struct{})
+	}
+	p.requests[key] = struct{}{}
+
+	return true
+}
+
+// done forgets the request for uri and trigger.
+func (p *pendingLints) done(uri DocumentURI, trigger Trigger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.requests, Request{URI: uri, Trigger: trigger})
+}
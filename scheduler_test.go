@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
+)
+
+func TestCoalescingSchedulerBatchesPending(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		ran     [][]DocumentURI
+		started = make(chan struct{}, 16)
+		release = make(chan struct{})
+	)
+	record := func(reqs ...Request) {
+		var uris []DocumentURI
+		for _, req := range reqs {
+			uris = append(uris, req.URI)
+		}
+		mu.Lock()
+		ran = append(ran, uris)
+		mu.Unlock()
+		started <- struct{}{}
+		<-release
+	}
+	s := newCoalescingScheduler(func(req Request) { record(req) }, func(reqs []Request) { record(reqs...) })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Drain(ctx)
+	defer s.Close()
+
+	s.Enqueue(Request{URI: "file:///a.go"})
+	<-started
+	s.Go(func() { record(Request{URI: "job"}) })
+	s.Enqueue(Request{URI: "file:///b/b.go"})
+	s.Enqueue(Request{URI: "file:///c/c.go"})
+	s.Enqueue(Request{URI: "file:///b/b.go"})
+	close(release)
+	<-started
+	<-started
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := [][]DocumentURI{
+		{"file:///a.go"},
+		// One batch of the documents requested meanwhile, before the job queued ahead of them.
+		{"file:///c/c.go", "file:///b/b.go"},
+		{"job"},
+	}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+}
+
+func TestProcessLimitSingleFlightCancels(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("needs sleep(1)")
+	}
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	l := newProcessLimit(func() int { return 1 }, func() bool { return true }, nil)
+	l.acquire(true)
+	cmd := exec.Command(sleep, "60")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	l.track(cmd)
+
+	acquired := make(chan struct{})
+	go func() {
+		l.acquire(false)
+		close(acquired)
+	}()
+
+	select {
+	case <-exited:
+	case <-acquired:
+		t.Fatal("the user lint started beside the background process")
+	case <-time.After(testTimeout):
+		t.Fatal("the background process wasn't cancelled")
+	}
+	select {
+	case <-acquired:
+		t.Fatal("the user lint started before the background process released its slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if !l.untrack(cmd) {
+		t.Error("the background process isn't reported cancelled")
+	}
+	l.release(true)
+	select {
+	case <-acquired:
+	case <-time.After(testTimeout):
+		t.Fatal("the user lint didn't start")
+	}
+	l.release(false)
+}
+
+// TestSingleFlight checks that in single-flight mode the documents opened during a workspace
+// run wait for it and are then linted together, one process at a time.
+func TestSingleFlight(t *testing.T) {
+	runner := &fakeRunner{release: make(chan struct{})}
+	ts := newTestServer(t, testConfig{
+		options: map[string]interface{}{"maxConcurrency": 0},
+		files: map[string]string{
+			"b/b.go": "package b\n",
+			"c/c.go": "package c\n",
+		},
+		runner: runner,
+	})
+
+	if err := ts.call("workspace/executeCommand", ExecuteCommandParams{Command: cmdRunWorkspace}, nil); err != nil {
+		t.Fatal(err)
+	}
+	runs := ts.waitRuns(1)
+	if got := runs[0].Args[len(runs[0].Args)-1]; got != "./..." {
+		t.Errorf("workspace run of %s, want ./...", got)
+	}
+
+	ts.open("a.go")
+	ts.open("b/b.go")
+	ts.open("c/c.go")
+	time.Sleep(100 * time.Millisecond)
+	if n := len(runner.Runs()); n != 1 {
+		t.Fatalf("%d runs during the workspace run, want none", n-1)
+	}
+
+	close(runner.release)
+	for _, name := range []string{"a.go", "b/b.go", "c/c.go"} {
+		ts.waitPublished(name)
+	}
+
+	runs = runner.Runs()
+	if len(runs) != 2 {
+		t.Fatalf("%d runs, want the workspace run and one for the opened documents", len(runs))
+	}
+	if got, want := strings.Join(runs[1].Args, " "), ". ./b ./c"; !strings.HasSuffix(got, want) {
+		t.Errorf("coalesced run %q doesn't cover %q", got, want)
+	}
+	if n := runner.MaxRunning(); n != 1 {
+		t.Errorf("%d processes at once, want 1", n)
+	}
+}
+
+// slowWorkspaceRunner runs the workspace runs as real processes, and the others with next.
+type slowWorkspaceRunner struct {
+	next lint.Runner
+
+	mu   sync.Mutex
+	cmds []*exec.Cmd
+}
+
+func (r *slowWorkspaceRunner) Start(cmd *exec.Cmd) (io.Reader, func() error, error) {
+	workspace := false
+	for _, arg := range cmd.Args {
+		workspace = workspace || arg == "./..."
+	}
+	if !workspace {
+		return r.next.Start(cmd)
+	}
+
+	stdout, wait, err := lint.ExecRunner{}.Start(cmd)
+	if err == nil {
+		r.mu.Lock()
+		r.cmds = append(r.cmds, cmd)
+		r.mu.Unlock()
+	}
+
+	return stdout, wait, err
+}
+
+// kill kills the processes started, paused or not.
+func (r *slowWorkspaceRunner) kill() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cmd := range r.cmds {
+		_ = cmd.Process.Kill()
+	}
+}
+
+// started returns the number of workspace processes started.
+func (r *slowWorkspaceRunner) started() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.cmds)
+}
+
+// TestUserLintPreemptsWorkspaceRun checks that with a single process allowed, a document
+// opened during a long workspace run is linted right away, the workspace run giving way.
+func TestUserLintPreemptsWorkspaceRun(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("needs a shell script")
+	}
+
+	// A golangci-lint whose workspace runs take a minute, and which fails to detect features.
+	bin := filepath.Join(t.TempDir(), "golangci-lint")
+	script := "#!/bin/sh\ncase \"$*\" in *./...*) exec sleep 60 ;; esac\nexit 1\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := newTestServer(t, testConfig{options: map[string]interface{}{
+		"command":        []string{bin, "run"},
+		"maxConcurrency": 1,
+	}})
+	runner := &slowWorkspaceRunner{next: ts.runner}
+	ts.h.runner = runner
+	t.Cleanup(runner.kill)
+
+	if err := ts.call("workspace/executeCommand", ExecuteCommandParams{Command: cmdRunWorkspace}, nil); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(testTimeout)
+	for runner.started() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the workspace run didn't start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	start := time.Now()
+	ts.open("a.go")
+	ts.waitPublished("a.go")
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("the opened document was linted after %s", d)
+	}
+
+	if n := ts.h.stats.snapshot().BackgroundPreemptions; n != 1 {
+		t.Errorf("%d background preemptions, want 1", n)
+	}
+}
+
+func TestPendingLints(t *testing.T) {
+	var p pendingLints
+	if !p.add("file:///a.go", TriggerOpen) {
+		t.Fatal("first request refused")
+	}
+	if p.add("file:///a.go", TriggerOpen) {
+		t.Error("second request for the same trigger accepted while the first is pending")
+	}
+	if !p.add("file:///a.go", TriggerSave) {
+		t.Error("request for another trigger refused")
+	}
+	if !p.add("file:///b.go", TriggerOpen) {
+		t.Error("request for another document refused")
+	}
+
+	p.done("file:///a.go", TriggerOpen)
+	if !p.add("file:///a.go", TriggerOpen) {
+		t.Error("request refused once the previous one started")
+	}
+}
+
+// schedulers returns a scheduler of each kind, running requests and batches with run.
+func schedulers(run func(reqs ...Request)) map[string]Scheduler {
+	return map[string]Scheduler{
+		"fifo":       newFIFOScheduler(func(req Request) { run(req) }),
+		"coalescing": newCoalescingScheduler(func(req Request) { run(req) }, func(reqs []Request) { run(reqs...) }),
+	}
+}
+
+// TestSchedulerCloseRace enqueues requests and jobs from many goroutines while the scheduler
+// closes: none may panic sending after the close or stay blocked, and Drain must return.
+func TestSchedulerCloseRace(t *testing.T) {
+	for name, s := range schedulers(func(...Request) { time.Sleep(time.Millisecond) }) {
+		s := s
+		t.Run(name, func(t *testing.T) {
+			drained := make(chan struct{})
+			go func() {
+				s.Drain(context.Background())
+				close(drained)
+			}()
+
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					for j := 0; j < 100; j++ {
+						trigger := TriggerOpen
+						if j%2 == 0 {
+							trigger = TriggerWatchedFiles
+						}
+						s.Enqueue(Request{URI: DocumentURI(fmt.Sprintf("file:///%d/%d.go", i, j)), Trigger: trigger})
+						s.Go(func() {})
+					}
+				}(i)
+			}
+			time.Sleep(10 * time.Millisecond)
+			s.Close()
+
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+			for _, c := range []chan struct{}{done, drained} {
+				select {
+				case <-c:
+				case <-time.After(testTimeout):
+					t.Fatal("deadlocked after Close")
+				}
+			}
+
+			if s.Enqueue(Request{URI: "file:///late.go"}) {
+				t.Error("request accepted after Close")
+			}
+			if s.Go(func() { t.Error("job run after Close") }) {
+				t.Error("job accepted after Close")
+			}
+			s.Close()
+		})
+	}
+}
+
+// TestSchedulerFlood checks that a flood of requests while a lint runs doesn't pile up: the
+// FIFO scheduler hands each over to the worker, the coalescing one keeps one per document.
+func TestSchedulerFlood(t *testing.T) {
+	const documents = 100
+
+	var (
+		mu      sync.Mutex
+		ran     int
+		batches int
+	)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	run := func(reqs ...Request) {
+		select {
+		case started <- struct{}{}:
+			<-release
+		default:
+		}
+		mu.Lock()
+		ran += len(reqs)
+		batches++
+		mu.Unlock()
+	}
+
+	t.Run("coalescing", func(t *testing.T) {
+		s := newCoalescingScheduler(func(req Request) { run(req) }, func(reqs []Request) { run(reqs...) })
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go s.Drain(ctx)
+		defer s.Close()
+
+		s.Enqueue(Request{URI: "file:///first.go"})
+		<-started
+		for i := 0; i < 100000; i++ {
+			if !s.Enqueue(Request{URI: DocumentURI(fmt.Sprintf("file:///%d.go", i%documents))}) {
+				t.Fatal("request refused")
+			}
+		}
+		s.mu.Lock()
+		pending := len(s.pending)
+		s.mu.Unlock()
+		if pending != documents {
+			t.Errorf("%d pending requests, want one per document", pending)
+		}
+
+		close(release)
+		deadline := time.Now().Add(testTimeout)
+		for {
+			mu.Lock()
+			n, b := ran, batches
+			mu.Unlock()
+			if n == documents+1 {
+				if b != 2 {
+					t.Errorf("%d runs, want the first and one batch", b)
+				}
+
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%d of %d requests ran", n, documents+1)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("fifo", func(t *testing.T) {
+		started = make(chan struct{}, 1)
+		release = make(chan struct{})
+		s := newFIFOScheduler(func(req Request) { run(req) })
+		go s.Drain(context.Background())
+
+		s.Enqueue(Request{URI: "file:///first.go"})
+		<-started
+		enqueued := make(chan bool)
+		go func() {
+			enqueued <- s.Enqueue(Request{URI: "file:///second.go"})
+		}()
+		select {
+		case <-enqueued:
+			t.Fatal("a request was queued while the worker is busy")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		// Closing releases the blocked sender.
+		s.Close()
+		select {
+		case ok := <-enqueued:
+			if ok {
+				t.Error("the blocked request was accepted after Close")
+			}
+		case <-time.After(testTimeout):
+			t.Fatal("the blocked sender wasn't released by Close")
+		}
+		close(release)
+	})
+}
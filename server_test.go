@@ -0,0 +1,356 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
+)
+
+// testTimeout bounds every wait of the tests on the server.
+const testTimeout = 10 * time.Second
+
+// testLogger keeps the lines a test server logs. The server may still log after its test
+// ended, which t.Logf doesn't allow.
+type testLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *testLogger) Printf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func (l *testLogger) DebugJSON(label string, arg interface{}) {
+	b, _ := json.Marshal(arg)
+	l.Printf("%s: %s", label, b)
+}
+
+func (l *testLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return strings.Join(l.lines, "\n")
+}
+
+// fakeRun is a golangci-lint run of a fakeRunner.
+type fakeRun struct {
+	Dir  string
+	Args []string
+	// Env is the environment of the run, nil for the server's.
+	Env []string
+}
+
+// fakeRunner stands in for golangci-lint. Every run prints the output returned by output, or
+// no issues, and doesn't exit before release is closed, if set. It then exits with err.
+type fakeRunner struct {
+	output  func(cmd *exec.Cmd) string
+	release chan struct{}
+	err     error
+
+	mu         sync.Mutex
+	runs       []fakeRun
+	running    int
+	maxRunning int
+	started    chan struct{}
+}
+
+func (r *fakeRunner) Start(cmd *exec.Cmd) (io.Reader, func() error, error) {
+	r.mu.Lock()
+	r.runs = append(r.runs, fakeRun{Dir: cmd.Dir, Args: cmd.Args, Env: cmd.Env})
+	r.running++
+	if r.running > r.maxRunning {
+		r.maxRunning = r.running
+	}
+	if r.started == nil {
+		r.started = make(chan struct{}, 1024)
+	}
+	started := r.started
+	r.mu.Unlock()
+
+	select {
+	case started <- struct{}{}:
+	default:
+	}
+
+	output := `{"Issues":[]}`
+	if r.output != nil {
+		output = r.output(cmd)
+	}
+
+	return strings.NewReader(output), func() error {
+		if r.release != nil {
+			<-r.release
+		}
+
+		r.mu.Lock()
+		r.running--
+		r.mu.Unlock()
+
+		return r.err
+	}, nil
+}
+
+// Runs returns the runs started so far.
+func (r *fakeRunner) Runs() []fakeRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]fakeRun(nil), r.runs...)
+}
+
+// MaxRunning returns the most runs that were in flight at once.
+func (r *fakeRunner) MaxRunning() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.maxRunning
+}
+
+// testServer is a langHandler served over a pipe to a jsonrpc2 client, linting a temporary
+// module with a fakeRunner.
+type testServer struct {
+	t      *testing.T
+	h      *langHandler
+	runner *fakeRunner
+	logger *testLogger
+	client *jsonrpc2.Conn
+	root   string
+	// initResult is the result of initialize.
+	initResult json.RawMessage
+
+	mu          sync.Mutex
+	diagnostics map[DocumentURI][]Diagnostic
+	// history holds every publication, in order.
+	history   []PublishDiagnosticsParams
+	published chan DocumentURI
+	handle    func(conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error)
+}
+
+// testConfig configures a testServer.
+type testConfig struct {
+	// options are the initialization options, over the ones every test server uses.
+	options map[string]interface{}
+	// files are the files of the module linted, by slash-separated path.
+	files  map[string]string
+	runner *fakeRunner
+	// capabilities are the capabilities of the client.
+	capabilities ClientCapabilities
+	// workspaceFolders are the workspace folders besides the root, by slash-separated path.
+	workspaceFolders []string
+	// untrusted leaves the configured command to be trusted by the user.
+	untrusted bool
+	// noInitialized leaves the initialized notification to the test.
+	noInitialized bool
+	// handle answers the requests the server sends the client.
+	handle func(conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error)
+}
+
+func newTestServer(t *testing.T, config testConfig) *testServer {
+	t.Helper()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/test\n\ngo 1.16\n",
+		"a.go":   "package test\n\nfunc A() {}\n",
+	}
+	for name, text := range config.files {
+		files[name] = text
+	}
+	for name, text := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if config.runner == nil {
+		config.runner = &fakeRunner{}
+	}
+	logger := &testLogger{}
+	h := newLangHandler(logger, false)
+	h.runner = config.runner
+	h.trust.path = ""
+	h.trust.all = !config.untrusted
+
+	ts := &testServer{
+		t:           t,
+		h:           h,
+		runner:      config.runner,
+		logger:      logger,
+		root:        root,
+		diagnostics: make(map[DocumentURI][]Diagnostic),
+		published:   make(chan DocumentURI, 1024),
+		handle:      config.handle,
+	}
+
+	ctx := context.Background()
+	serverSide, clientSide := net.Pipe()
+	server := jsonrpc2.NewConn(ctx, jsonrpc2.NewBufferedStream(serverSide, jsonrpc2.VSCodeObjectCodec{}), newHandler(h))
+	ts.client = jsonrpc2.NewConn(ctx, jsonrpc2.NewBufferedStream(clientSide, jsonrpc2.VSCodeObjectCodec{}), jsonrpc2.HandlerWithError(ts.serve))
+	t.Cleanup(func() {
+		// Closing the pipe first fails the writes a stuck server may be blocked in.
+		serverSide.Close()
+		clientSide.Close()
+		ts.client.Close()
+		server.Close()
+		if t.Failed() {
+			t.Logf("server log:\n%s", logger)
+		}
+	})
+
+	options := map[string]interface{}{
+		"warmup":           false,
+		"watcher":          watcherOff,
+		"instanceLockWait": 0,
+	}
+	for name, value := range config.options {
+		options[name] = value
+	}
+	raw, err := json.Marshal(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := InitializeParams{RootURI: string(pathToURI(root)), InitializationOptions: raw, Capabilities: config.capabilities}
+	for _, name := range config.workspaceFolders {
+		params.WorkspaceFolders = append(params.WorkspaceFolders, WorkspaceFolder{URI: string(ts.uri(name)), Name: name})
+	}
+	if err := ts.call("initialize", params, &ts.initResult); err != nil {
+		t.Fatal(err)
+	}
+	if !config.noInitialized {
+		if err := ts.client.Notify(ctx, "initialized", struct{}{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return ts
+}
+
+func (ts *testServer) serve(_ context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+	if req.Method == "textDocument/publishDiagnostics" && req.Params != nil {
+		var params PublishDiagnosticsParams
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			return nil, err
+		}
+		ts.mu.Lock()
+		ts.diagnostics[params.URI] = params.Diagnostics
+		ts.history = append(ts.history, params)
+		ts.mu.Unlock()
+		ts.published <- params.URI
+
+		return nil, nil
+	}
+	if ts.handle != nil {
+		return ts.handle(conn, req)
+	}
+
+	return nil, nil
+}
+
+// call calls method on the server, failing after testTimeout.
+func (ts *testServer) call(method string, params, result interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	return ts.client.Call(ctx, method, params, result)
+}
+
+// path returns the path of the file name of the module.
+func (ts *testServer) path(name string) string {
+	return filepath.Join(ts.root, filepath.FromSlash(name))
+}
+
+// uri returns the URI of the file name of the module.
+func (ts *testServer) uri(name string) DocumentURI {
+	return pathToURI(ts.path(name))
+}
+
+// open opens the file name of the module in the client.
+func (ts *testServer) open(name string) {
+	ts.t.Helper()
+
+	text, err := os.ReadFile(ts.path(name))
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	params := DidOpenTextDocumentParams{TextDocument: TextDocumentItem{
+		URI:        ts.uri(name),
+		LanguageID: "go",
+		Version:    1,
+		Text:       string(text),
+	}}
+	if err := ts.client.Notify(context.Background(), "textDocument/didOpen", params); err != nil {
+		ts.t.Fatal(err)
+	}
+}
+
+// waitPublished waits until diagnostics were published for the file name.
+func (ts *testServer) waitPublished(name string) []Diagnostic {
+	ts.t.Helper()
+
+	uri := ts.uri(name)
+	timeout := time.After(testTimeout)
+	for {
+		ts.mu.Lock()
+		diagnostics, ok := ts.diagnostics[uri]
+		ts.mu.Unlock()
+		if ok {
+			return diagnostics
+		}
+
+		select {
+		case <-ts.published:
+		case <-timeout:
+			ts.t.Fatalf("no diagnostics published for %s", name)
+		}
+	}
+}
+
+// waitRuns waits until the runner started n runs.
+func (ts *testServer) waitRuns(n int) []fakeRun {
+	ts.t.Helper()
+
+	deadline := time.Now().Add(testTimeout)
+	for {
+		if runs := ts.runner.Runs(); len(runs) >= n {
+			return runs
+		}
+		if time.Now().After(deadline) {
+			ts.t.Fatalf("%d of %d runs started", len(ts.runner.Runs()), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// issuesOutput returns the JSON output of golangci-lint reporting issues.
+func issuesOutput(t *testing.T, issues ...Issue) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(lint.Result{Issues: issues}); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The origins of the value of a flag, from the weakest to the strongest.
+const (
+	originDefault     = "default"
+	originFile        = "file"
+	originFlag        = "flag"
+	originInitOptions = "initOptions"
+)
+
+// actionFlags make the server do something else than serving, so the config file can't set them.
+var actionFlags = map[string]bool{"print-command": true, "print-config": true, "once": true, "replay": true}
+
+// flagOptions maps the flags to the option of initializationOptions that overrides them.
+var flagOptions = map[string]string{"max-concurrency": "maxConcurrency"}
+
+// resolvedFlag is the effective value of a flag and where it comes from.
+type resolvedFlag struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Origin string `json:"origin"`
+}
+
+// serverConfigPath returns the path of the config file holding the defaults of the flags,
+// golangci-lint-langserver/config.yml under the user config directory, or "" if there is none.
+func serverConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "golangci-lint-langserver", "config.yml")
+}
+
+// loadServerConfig sets the flags of fs that the config file at path sets and the command line
+// doesn't, the file being keyed by flag name, e.g. "max-concurrency: 2". It returns the
+// flags it set. A missing file sets nothing.
+func loadServerConfig(fs *flag.FlagSet, path string) (map[string]bool, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(b, &values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	onCommandLine := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		onCommandLine[f.Name] = true
+	})
+
+	for name := range values {
+		if fs.Lookup(name) == nil || actionFlags[name] {
+			return nil, fmt.Errorf("%s: unknown flag %q", path, name)
+		}
+	}
+
+	set := make(map[string]bool)
+	for name, value := range values {
+		if onCommandLine[name] {
+			continue
+		}
+		if err := fs.Set(name, fmt.Sprint(value)); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, name, err)
+		}
+		set[name] = true
+	}
+
+	return set, nil
+}
+
+// resolveFlags returns the effective value of every flag of fs, initOptions overriding the
+// flags that have an option.
+func resolveFlags(fs *flag.FlagSet, fromFile map[string]bool, initOptions string) []resolvedFlag {
+	var options map[string]json.RawMessage
+	if initOptions != "" {
+		_ = json.Unmarshal([]byte(initOptions), &options)
+	}
+
+	onCommandLine := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		onCommandLine[f.Name] = !fromFile[f.Name]
+	})
+
+	var flags []resolvedFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		if actionFlags[f.Name] {
+			return
+		}
+
+		resolved := resolvedFlag{Name: f.Name, Value: f.Value.String(), Origin: originDefault}
+		switch {
+		case onCommandLine[f.Name]:
+			resolved.Origin = originFlag
+		case fromFile[f.Name]:
+			resolved.Origin = originFile
+		}
+		if value, ok := options[flagOptions[f.Name]]; ok {
+			resolved.Value, resolved.Origin = string(value), originInitOptions
+		}
+		flags = append(flags, resolved)
+	})
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+
+	return flags
+}
+
+// printConfig writes the effective value of every flag and its origin.
+func printConfig(w io.Writer, path string, flags []resolvedFlag) {
+	if path == "" {
+		path = "(no user config directory)"
+	}
+	fmt.Fprintf(w, "config file: %s\n", path)
+	for _, f := range flags {
+		fmt.Fprintf(w, "%s = %s (%s)\n", f.Name, shellQuote(f.Value), f.Origin)
+	}
+}
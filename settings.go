@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+// settingsFile holds editor preferences shared by a workspace, with the keys of initializationOptions.
+const settingsFile = ".golangci-langserver.yml"
+
+// loadSettingsFile returns the content of root/.golangci-langserver.yml as JSON, or nil when there is none.
+// Errors carry the file name and, for syntax errors, the line.
+func loadSettingsFile(root string, msgs *messages.Catalog) (json.RawMessage, error) {
+	if root == "" {
+		return nil, nil
+	}
+
+	path := filepath.Join(root, settingsFile)
+	b, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return nil, msgs.Errorf(messages.InvalidSettingsFile, path, strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+
+	var settings map[string]interface{}
+	if err := node.Decode(&settings); err != nil {
+		return nil, msgs.Errorf(messages.InvalidSettingsFile, path, positionedError(node.Content[0], err))
+	}
+
+	raw, err := json.Marshal(settings)
+	if err != nil {
+		return nil, msgs.Errorf(messages.InvalidSettingsFile, path, err)
+	}
+
+	if _, err := decodeOptions(raw, msgs); err != nil {
+		return nil, msgs.Errorf(messages.InvalidSettingsFile, path, positionedError(node.Content[0], err))
+	}
+
+	return raw, nil
+}
+
+// positionedError prefixes err with the line and column of the key of root that it names first,
+// alone or as the start of the path of a nested key, or of root itself. Later names may be
+// suggestions, as in "did you mean".
+func positionedError(root *yaml.Node, err error) string {
+	line, column := root.Line, root.Column
+	first := -1
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i]
+		for _, end := range []string{`"`, ".", "["} {
+			if at := strings.Index(err.Error(), `"`+key.Value+end); at >= 0 && (first < 0 || at < first) {
+				line, column, first = key.Line, key.Column, at
+			}
+		}
+	}
+
+	return fmt.Sprintf("%d:%d: %s", line, column, err)
+}
+
+// mergeOptions overlays the options sent by the client over those of the settings file.
+func mergeOptions(file, client json.RawMessage) json.RawMessage {
+	if len(file) == 0 {
+		return client
+	}
+
+	client = bytes.TrimSpace(client)
+	if len(client) == 0 || bytes.Equal(client, []byte("null")) {
+		return file
+	}
+
+	var base, overlay map[string]json.RawMessage
+	if json.Unmarshal(file, &base) != nil || json.Unmarshal(client, &overlay) != nil {
+		// decodeOptions reports the client options that aren't an object.
+		return client
+	}
+
+	for name, value := range overlay {
+		for key := range base {
+			if strings.EqualFold(key, name) {
+				delete(base, key)
+			}
+		}
+		base[name] = value
+	}
+
+	merged, _ := json.Marshal(base)
+
+	return merged
+}
+
+// decodeClientOptions decodes the options sent by the client over those of the settings file.
+// A broken settings file is reported and ignored.
+func (h *langHandler) decodeClientOptions(client json.RawMessage) (Options, error) {
+	h.clientOptions = client
+
+	file, err := loadSettingsFile(h.root(), h.catalog())
+	if err != nil {
+		h.notifyError(err.Error())
+	}
+
+	return decodeOptions(mergeOptions(file, client), h.catalog())
+}
+
+// reloadSettingsFile applies a changed settings file.
+func (h *langHandler) reloadSettingsFile() {
+	opts, err := h.decodeClientOptions(h.clientOptions)
+	if err == nil {
+		err = h.applyOptions(opts)
+	}
+	if err != nil {
+		h.notifyError(h.catalog().Sprintf(messages.InvalidSettings, err))
+
+		return
+	}
+
+	h.invalidate(settingsFile + " changed")
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSettingsFilePrecedence checks that the options of the client win over the settings file,
+// which wins over the defaults.
+func TestSettingsFilePrecedence(t *testing.T) {
+	tests := []struct {
+		name              string
+		file              string
+		client            string
+		wantThreshold     int
+		wantRepeatWindow  int
+		wantRangeStyle    string
+		wantNotifications int
+	}{
+		{
+			name:             "defaults",
+			wantThreshold:    defaultSaveBatchThreshold,
+			wantRepeatWindow: defaultMessageRepeatWindow,
+			wantRangeStyle:   largeRangeStyleFirstLine,
+		},
+		{
+			name:             "file over defaults",
+			file:             "saveBatchThreshold: 8\nlargeRangeStyle: full\n",
+			wantThreshold:    8,
+			wantRepeatWindow: defaultMessageRepeatWindow,
+			wantRangeStyle:   largeRangeStyleFull,
+		},
+		{
+			name:             "client over file",
+			file:             "saveBatchThreshold: 8\nlargeRangeStyle: full\n",
+			client:           `{"saveBatchThreshold":2,"messageRepeatWindow":60}`,
+			wantThreshold:    2,
+			wantRepeatWindow: 60,
+			wantRangeStyle:   largeRangeStyleFull,
+		},
+		{
+			name:             "client over file whatever the case of the key",
+			file:             "SaveBatchThreshold: 8\n",
+			client:           `{"saveBatchThreshold":2}`,
+			wantThreshold:    2,
+			wantRepeatWindow: defaultMessageRepeatWindow,
+			wantRangeStyle:   largeRangeStyleFirstLine,
+		},
+		{
+			name:             "null client options",
+			file:             "saveBatchThreshold: 8\n",
+			client:           `null`,
+			wantThreshold:    8,
+			wantRepeatWindow: defaultMessageRepeatWindow,
+			wantRangeStyle:   largeRangeStyleFirstLine,
+		},
+		{
+			name:              "broken file ignored",
+			file:              "saveBatchThreshold: [\n",
+			client:            `{"messageRepeatWindow":60}`,
+			wantThreshold:     defaultSaveBatchThreshold,
+			wantRepeatWindow:  60,
+			wantRangeStyle:    largeRangeStyleFirstLine,
+			wantNotifications: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &testLogger{}
+			h := newLangHandler(logger, false)
+			h.rootDir = t.TempDir()
+			if tt.file != "" {
+				if err := os.WriteFile(filepath.Join(h.rootDir, settingsFile), []byte(tt.file), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			opts, err := h.decodeClientOptions(json.RawMessage(tt.client))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opts.SaveBatchThreshold != tt.wantThreshold {
+				t.Errorf("saveBatchThreshold %d, want %d", opts.SaveBatchThreshold, tt.wantThreshold)
+			}
+			if opts.MessageRepeatWindow != tt.wantRepeatWindow {
+				t.Errorf("messageRepeatWindow %d, want %d", opts.MessageRepeatWindow, tt.wantRepeatWindow)
+			}
+			if opts.LargeRangeStyle != tt.wantRangeStyle {
+				t.Errorf("largeRangeStyle %q, want %q", opts.LargeRangeStyle, tt.wantRangeStyle)
+			}
+			if n := strings.Count(logger.String(), settingsFile); n != tt.wantNotifications {
+				t.Errorf("%d errors about %s logged, want %d:\n%s", n, settingsFile, tt.wantNotifications, logger)
+			}
+		})
+	}
+}
+
+func TestLoadSettingsFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{name: "syntax", file: "saveBatchThreshold: 1\n  bad: [\n", want: "line 2"},
+		{name: "unknown option", file: "saveBatchThreshold: 1\nsaveBatchTreshold: 2\n", want: "2:1: "},
+		{name: "wrong type", file: "warmup: true\nsaveBatchThreshold: many\n", want: "2:1: "},
+		{name: "unknown nested option", file: "warmup: true\nprocessPriority:\n  cpuLimt: 2\n", want: "2:1: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			if err := os.WriteFile(filepath.Join(root, settingsFile), []byte(tt.file), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			h := newLangHandler(&testLogger{}, false)
+			_, err := loadSettingsFile(root, h.catalog())
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), settingsFile) {
+				t.Errorf("loadSettingsFile() error = %v, want one naming %s at %q", err, settingsFile, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import "strings"
+
+// issueSeverity maps the severity of issue through the severityMap option and the built-in table.
+// Unknown severities are reported as warnings and logged once.
+func (h *langHandler) issueSeverity(issue *Issue) DiagnosticSeverity {
+	if severity, ok := parseSeverity(issue.SeverityOverride); ok {
+		return severity
+	}
+
+	name := severityName(issue)
+
+	if custom, ok := h.currentOptions().customLinter(issue.FromLinter); ok && issue.Severity == "" && custom.DefaultSeverity != "" {
+		severity, _ := parseSeverity(custom.DefaultSeverity)
+
+		return severity
+	}
+
+	for from, to := range h.currentOptions().SeverityMap {
+		if strings.EqualFold(from, name) {
+			severity, _ := parseSeverity(to)
+
+			return severity
+		}
+	}
+
+	if severity, ok := SeverityFromString(name); ok {
+		return severity
+	}
+
+	if _, logged := h.unknownSeverities.LoadOrStore(strings.ToLower(name), struct{}{}); !logged {
+		h.logger.Printf("golangci-lint-langserver: warn: unknown severity %q reported by %s, using warning; map it with the severityMap option", name, issue.FromLinter)
+	}
+
+	return DSWarning
+}
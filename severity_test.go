@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSeverityFromString(t *testing.T) {
+	tests := []struct {
+		vocabulary string
+		severities map[string]DiagnosticSeverity
+	}{
+		{
+			vocabulary: "golangci-lint",
+			severities: map[string]DiagnosticSeverity{"error": DSError, "warning": DSWarning, "info": DSInformation, "hint": DSHint, "err": DSError, "warn": DSWarning},
+		},
+		{
+			vocabulary: "Code Climate",
+			severities: map[string]DiagnosticSeverity{"blocker": DSError, "critical": DSError, "major": DSError, "minor": DSWarning, "info": DSInformation},
+		},
+		{
+			vocabulary: "SARIF",
+			severities: map[string]DiagnosticSeverity{"error": DSError, "warning": DSWarning, "note": DSInformation, "none": DSHint},
+		},
+	}
+	for _, tt := range tests {
+		for name, want := range tt.severities {
+			for _, s := range []string{name, strings.ToUpper(name), strings.ToUpper(name[:1]) + name[1:]} {
+				if got, ok := SeverityFromString(s); !ok || got != want {
+					t.Errorf("%s: SeverityFromString(%q) = %v, %v, want %v", tt.vocabulary, s, got, ok, want)
+				}
+			}
+		}
+	}
+
+	for _, s := range []string{"", "fatal", "low", "medium"} {
+		if got, ok := SeverityFromString(s); ok {
+			t.Errorf("SeverityFromString(%q) = %v, want unknown", s, got)
+		}
+	}
+}
+
+func TestIssueSeverity(t *testing.T) {
+	logger := &testLogger{}
+	h := newLangHandler(logger, false)
+	opts := defaultOptions()
+	opts.SeverityMap = map[string]string{"Medium": "warning", "major": "info"}
+	opts.CustomLinters = map[string]CustomLinter{"mylinter": {DefaultSeverity: "hint"}}
+	h.options = opts
+
+	tests := []struct {
+		linter   string
+		severity string
+		override string
+		want     DiagnosticSeverity
+	}{
+		{linter: "errcheck", severity: "blocker", want: DSError},
+		{linter: "errcheck", severity: "note", want: DSInformation},
+		{linter: "errcheck", severity: "medium", want: DSWarning},
+		// severityMap takes precedence over the built-in vocabularies.
+		{linter: "errcheck", severity: "MAJOR", want: DSInformation},
+		{linter: "errcheck", severity: "blocker", override: "hint", want: DSHint},
+		{linter: "mylinter", want: DSHint},
+		{linter: "mylinter", severity: "error", want: DSError},
+		{linter: "errcheck", severity: "fatal", want: DSWarning},
+		{linter: "errcheck", severity: "Fatal", want: DSWarning},
+	}
+	for _, tt := range tests {
+		issue := &Issue{FromLinter: tt.linter, Severity: tt.severity, SeverityOverride: tt.override}
+		if got := h.issueSeverity(issue); got != tt.want {
+			t.Errorf("%s %q: issueSeverity() = %v, want %v", tt.linter, tt.severity, got, tt.want)
+		}
+	}
+
+	if n := strings.Count(logger.String(), `unknown severity`); n != 1 {
+		t.Errorf("unknown severity logged %d times, want once:\n%s", n, logger)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import "strings"
+
+const (
+	maxSnippetWidth = 120
+	ellipsis        = "..."
+)
+
+// sourceSnippet renders line with a caret under the 1-based byte column, like compiler output.
+// Leading whitespace is trimmed and long lines are cut around the column.
+func sourceSnippet(line string, column int) string {
+	trimmed := strings.TrimLeft(line, " \t")
+	col := max(column-1-(len(line)-len(trimmed)), 0)
+	line = strings.TrimRight(trimmed, " \t\r")
+	if col > len(line) {
+		col = len(line)
+	}
+
+	prefix, suffix := "", ""
+	if len(line) > maxSnippetWidth {
+		start := max(col-maxSnippetWidth/2, 0)
+		end := start + maxSnippetWidth
+		if end > len(line) {
+			end = len(line)
+			start = end - maxSnippetWidth
+		}
+		if start > 0 {
+			prefix = ellipsis
+		}
+		if end < len(line) {
+			suffix = ellipsis
+		}
+		col = col - start + len(prefix)
+		line = line[start:end]
+	}
+	line = prefix + line + suffix
+
+	// Keep tabs in the caret line so it stays aligned with the source line.
+	var caret strings.Builder
+	for i := 0; i < col && i < len(line); i++ {
+		if line[i] == '\t' {
+			caret.WriteByte('\t')
+		} else {
+			caret.WriteByte(' ')
+		}
+	}
+	caret.WriteByte('^')
+
+	return line + "\n" + caret.String()
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+const (
+	cmdSnoozeIssue  = "golangci-lint.snoozeIssue"
+	cmdClearSnoozed = "golangci-lint.clearSnoozed"
+)
+
+// SnoozeIssueArgs is the argument of the golangci-lint.snoozeIssue command.
+type SnoozeIssueArgs struct {
+	URI     DocumentURI `json:"uri"`
+	IssueID string      `json:"issueId"`
+}
+
+// snoozes holds the fingerprints of the issues hidden for the session. They are kept in
+// memory only, so a restart shows the issues again.
+type snoozes struct {
+	mu  sync.Mutex
+	set map[string]struct{}
+}
+
+func (s *snoozes) add(fp string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.set == nil {
+		s.set = make(map[string]struct{})
+	}
+	s.set[fp] = struct{}{}
+}
+
+func (s *snoozes) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set = nil
+}
+
+func (s *snoozes) has(fp string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.set[fp]
+
+	return ok
+}
+
+func (s *snoozes) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.set)
+}
+
+// filterSnoozed drops the snoozed issues of a run from dir, fingerprinting them against the
+// text of their file.
+func (h *langHandler) filterSnoozed(dir string, issues []Issue) (kept, dropped []Issue) {
+	if h.snoozes.count() == 0 {
+		return issues, nil
+	}
+
+	lines := make(map[string][]string)
+	kept = issues[:0]
+	for _, issue := range issues {
+		issue := issue
+
+		path := issueFilePath(dir, &issue)
+		if _, ok := lines[path]; !ok {
+			text, _ := h.documents.text(h.paths.pathToURI(canonicalPath(path)))
+			lines[path] = strings.Split(text, "\n")
+		}
+		if h.snoozes.has(fingerprint(&issue, lines[path])) {
+			dropped = append(dropped, issue)
+
+			continue
+		}
+		kept = append(kept, issue)
+	}
+
+	return kept, dropped
+}
+
+// snoozeAction offers to hide the issue behind diagnostic for the session.
+func (h *langHandler) snoozeAction(uri DocumentURI, issue *Issue, diagnostic Diagnostic) CodeAction {
+	title := h.catalog().Sprintf(messages.SnoozeIssue)
+
+	return CodeAction{
+		Title:       title,
+		Kind:        CAKQuickFix,
+		Diagnostics: []Diagnostic{diagnostic},
+		Command:     &Command{Title: title, Command: h.commandID(cmdSnoozeIssue), Arguments: []interface{}{SnoozeIssueArgs{URI: uri, IssueID: issueID(issue)}}},
+	}
+}
+
+// executeSnoozeIssue hides an issue for the session and republishes the diagnostics of its
+// document without it; later runs drop it too, wherever its line moves.
+func (h *langHandler) executeSnoozeIssue(arguments []json.RawMessage) error {
+	var args SnoozeIssueArgs
+	if len(arguments) > 0 {
+		if err := json.Unmarshal(arguments[0], &args); err != nil {
+			return err
+		}
+	}
+
+	issue, ok := h.issues.find(args.URI, args.IssueID)
+	if !ok {
+		return &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: h.catalog().Sprintf(messages.IssueGone)}
+	}
+	text, _ := h.documents.text(args.URI)
+	h.snoozes.add(fingerprint(&issue, strings.Split(text, "\n")))
+	h.logger.Printf("golangci-lint-langserver: snoozed %s issue in %s: %s", issue.FromLinter, h.paths.uriToPath(string(args.URI)), issue.Text)
+
+	var kept []Issue
+	for _, cached := range h.issues.get(args.URI) {
+		if issueID(&cached) != args.IssueID {
+			kept = append(kept, cached)
+		}
+	}
+	h.issues.replace(args.URI, kept, h.issues.run(args.URI))
+
+	h.republishWithout(args.URI, args.IssueID)
+
+	return nil
+}
+
+// republishWithout republishes the last diagnostics of uri without those of the issue id,
+// as a new report for pull clients.
+func (h *langHandler) republishWithout(uri DocumentURI, id string) {
+	if !h.isPullMode() {
+		h.publishDiagnostics(uri, withoutIssue(h.publisher.published()[uri], id))
+
+		return
+	}
+
+	r, ok := h.reports.get(uri)
+	if !ok {
+		return
+	}
+	h.reports.store(uri, r.revision, withoutIssue(r.diagnostics, id))
+	if h.clientCaps.Workspace.Diagnostics.RefreshSupport {
+		h.callAsync("workspace/diagnostic/refresh", nil, nil)
+	}
+}
+
+// withoutIssue returns diagnostics without those of the issue id.
+func withoutIssue(diagnostics []Diagnostic, id string) []Diagnostic {
+	kept := make([]Diagnostic, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		if d.Data != nil && d.Data.IssueID == id {
+			continue
+		}
+		kept = append(kept, d)
+	}
+
+	return kept
+}
+
+// executeClearSnoozed shows the snoozed issues again by linting the open documents again.
+func (h *langHandler) executeClearSnoozed() {
+	h.snoozes.clear()
+	h.invalidate("snoozed issues cleared")
+}
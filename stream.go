@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+const (
+	// maxResyncs is the number of framing errors in a row after which the stream gives up.
+	maxResyncs = 10
+	// maxMessageSize bounds the Content-Length of a message.
+	maxMessageSize = 256 << 20
+	// maxLoggedBytes is the number of the offending bytes of a framing error that are logged.
+	maxLoggedBytes = 64
+)
+
+var contentLengthHeader = []byte("content-length:")
+
+// errFraming is a message that isn't framed with a valid Content-Length header.
+var errFraming = errors.New("malformed message framing")
+
+// resyncStream is the VS Code framing of jsonrpc2.VSCodeObjectCodec, which survives
+// malformed headers and stray bytes between messages from a buggy client: it skips to the
+// next Content-Length header rather than closing the connection.
+type resyncStream struct {
+	r      *bufio.Reader
+	logger logger
+
+	// resyncs is the number of framing errors since the last valid message.
+	resyncs int
+
+	mu sync.Mutex
+	w  *bufio.Writer
+	c  io.Closer
+}
+
+func newResyncStream(rwc io.ReadWriteCloser, logger logger) *resyncStream {
+	return &resyncStream{r: bufio.NewReader(rwc), w: bufio.NewWriter(rwc), c: rwc, logger: logger}
+}
+
+func (s *resyncStream) WriteObject(obj interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := (jsonrpc2.VSCodeObjectCodec{}).WriteObject(s.w, obj); err != nil {
+		return err
+	}
+
+	return s.w.Flush()
+}
+
+// ReadObject reads the next message, skipping over those it can't make sense of.
+func (s *resyncStream) ReadObject(v interface{}) error {
+	for {
+		body, err := s.readMessage()
+		if err == nil {
+			if err = json.Unmarshal(body, v); err != nil {
+				err = fmt.Errorf("%w: %s", errFraming, err)
+			}
+		}
+		if err == nil {
+			s.resyncs = 0
+
+			return nil
+		}
+		if !errors.Is(err, errFraming) {
+			return err
+		}
+
+		s.resyncs++
+		s.logger.Printf("golangci-lint-langserver: %s after %s", err, loggedBytes(body))
+		if s.resyncs >= maxResyncs {
+			return fmt.Errorf("giving up after %d framing errors in a row: %w", maxResyncs, err)
+		}
+		n, skipped, err := s.skipToHeader()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			s.logger.Printf("golangci-lint-langserver: skipped %d bytes to the next header: %s", n, loggedBytes(skipped))
+		}
+	}
+}
+
+// readMessage reads the headers and the body of a message. On a framing error, the body is
+// the bytes read that don't make sense.
+func (s *resyncStream) readMessage() ([]byte, error) {
+	length := -1
+	for {
+		line, err := s.r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return append([]byte{}, line...), fmt.Errorf("%w: header line too long", errFraming)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		header := strings.TrimRight(string(line), "\r\n")
+		if header == "" {
+			break
+		}
+		if i := bytes.Index(bytes.ToLower(line), contentLengthHeader); i > 0 {
+			// Stray bytes before the header.
+			s.logger.Printf("golangci-lint-langserver: %s: stray bytes before the header: %s", errFraming, loggedBytes(line[:i]))
+			header = header[i:]
+		}
+
+		colon := strings.IndexByte(header, ':')
+		if colon < 0 {
+			return []byte(header), fmt.Errorf("%w: header without a colon", errFraming)
+		}
+		if strings.EqualFold(header[:colon], "Content-Length") {
+			n, err := strconv.Atoi(strings.TrimSpace(header[colon+1:]))
+			if err != nil || n < 0 || n > maxMessageSize {
+				return []byte(header), fmt.Errorf("%w: invalid Content-Length", errFraming)
+			}
+			length = n
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("%w: missing Content-Length", errFraming)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.r, body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// skipToHeader discards the bytes up to the next Content-Length header, returning their
+// number and the first of them for the log.
+func (s *resyncStream) skipToHeader() (int, []byte, error) {
+	var skipped []byte
+	n := 0
+	for {
+		head, err := s.r.Peek(len(contentLengthHeader))
+		if bytes.EqualFold(head, contentLengthHeader) {
+			return n, skipped, nil
+		}
+		if err != nil {
+			return n, skipped, err
+		}
+
+		b, _ := s.r.ReadByte()
+		n++
+		if len(skipped) <= maxLoggedBytes {
+			skipped = append(skipped, b)
+		}
+	}
+}
+
+func (s *resyncStream) Close() error {
+	return s.c.Close()
+}
+
+// loggedBytes formats the start of b in hex for the log.
+func loggedBytes(b []byte) string {
+	if len(b) > maxLoggedBytes {
+		return hex.EncodeToString(b[:maxLoggedBytes]) + "..."
+	}
+
+	return hex.EncodeToString(b)
+}
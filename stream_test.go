@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// frame frames body as a VS Code message.
+func frame(body string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Writer
+}
+
+func (readCloser) Close() error { return nil }
+
+// readStream reads the messages of input until an error, returning their ids.
+func readStream(input string) ([]int, error) {
+	s := newResyncStream(readCloser{strings.NewReader(input), ioutil.Discard}, &testLogger{})
+
+	var ids []int
+	for {
+		var msg struct {
+			ID int `json:"id"`
+		}
+		if err := s.ReadObject(&msg); err != nil {
+			return ids, err
+		}
+		ids = append(ids, msg.ID)
+	}
+}
+
+func TestResyncStream(t *testing.T) {
+	m1, m2 := frame(`{"id":1}`), frame(`{"id":2}`)
+
+	tests := []struct {
+		name  string
+		input string
+		want  []int
+	}{
+		{name: "valid", input: m1 + m2, want: []int{1, 2}},
+		{name: "lower-case header", input: strings.ToLower(m1[:16]) + m1[16:], want: []int{1}},
+		{name: "stray bytes before the first message", input: "\x00\xffgarbage\n" + m1 + m2, want: []int{1, 2}},
+		{name: "stray bytes between messages", input: m1 + "\r\nnoise" + m2, want: []int{1, 2}},
+		{name: "stray bytes on the header line", input: m1 + "xx" + m2, want: []int{1, 2}},
+		{name: "Content-Length not a number", input: "Content-Length: abc\r\n\r\n{}" + m1, want: []int{1}},
+		{name: "negative Content-Length", input: "Content-Length: -1\r\n\r\n" + m1, want: []int{1}},
+		{name: "Content-Length too large", input: fmt.Sprintf("Content-Length: %d\r\n\r\n", maxMessageSize+1) + m1, want: []int{1}},
+		{name: "missing Content-Length", input: "Content-Type: x\r\n\r\n{}" + m1, want: []int{1}},
+		{name: "header line without a colon", input: "Content-Length 8\r\n\r\n" + m1 + m2, want: []int{1, 2}},
+		{name: "body not JSON", input: frame("{oops") + m2, want: []int{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := readStream(tt.input)
+			if !errors.Is(err, io.EOF) {
+				t.Errorf("ended with %v, want EOF", err)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("read %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestResyncStreamGivesUp(t *testing.T) {
+	bad := "Content-Length: x\r\n\r\n"
+	m1, m2 := frame(`{"id":1}`), frame(`{"id":2}`)
+
+	ids, err := readStream(strings.Repeat(bad, maxResyncs-1) + m1 + strings.Repeat(bad, maxResyncs-1) + m2)
+	if !errors.Is(err, io.EOF) || fmt.Sprint(ids) != "[1 2]" {
+		t.Errorf("%d errors in a row: read %v, ended with %v; want [1 2] and EOF", maxResyncs-1, ids, err)
+	}
+
+	ids, err = readStream(strings.Repeat(bad, maxResyncs) + m1)
+	if len(ids) != 0 || !errors.Is(err, errFraming) {
+		t.Fatalf("%d errors in a row: read %v, ended with %v; want a framing error", maxResyncs, ids, err)
+	}
+	if want := fmt.Sprintf("giving up after %d framing errors", maxResyncs); !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q doesn't say %q", err, want)
+	}
+}
+
+func TestResyncStreamWrite(t *testing.T) {
+	var buf bytes.Buffer
+	s := newResyncStream(readCloser{strings.NewReader(""), &buf}, &testLogger{})
+	if err := s.WriteObject(map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := readStream(buf.String())
+	if !errors.Is(err, io.EOF) || fmt.Sprint(ids) != "[1]" {
+		t.Errorf("read back %v, ended with %v", ids, err)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// placeholderPattern matches the placeholders of a command, such as ${dir}.
+var placeholderPattern = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// hasPlaceholders reports whether an argument of command holds a placeholder.
+func hasPlaceholders(command []string) bool {
+	for _, arg := range command {
+		if placeholderPattern.MatchString(arg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// placeholderValues returns the values of the placeholders for lc, leaving out those it
+// can't resolve: ${file} and ${relFile} outside of a document lint, ${moduleRoot} outside
+// of a module.
+func placeholderValues(lc lintCommand) map[string]string {
+	target := lc.Args[len(lc.Args)-1]
+	values := map[string]string{"dir": target, "relDir": target}
+	if filepath.IsAbs(target) {
+		values["dir"] = filepath.Clean(target)
+		if rel, err := filepath.Rel(lc.Dir, target); err == nil {
+			values["relDir"] = rel
+		}
+	}
+
+	moduleDir := lc.Dir
+	if lc.File != "" {
+		values["file"] = lc.File
+		if rel, err := filepath.Rel(lc.Dir, lc.File); err == nil {
+			values["relFile"] = rel
+		}
+		moduleDir = filepath.Dir(lc.File)
+	}
+	if root := findModuleRoot(moduleDir); root != "" {
+		values["moduleRoot"] = root
+	}
+
+	return values
+}
+
+// expandPlaceholders substitutes the placeholders of the command of lc, whose target then
+// goes only where ${dir} says instead of being appended. A command without placeholders is
+// returned as is; one whose placeholder has no value returns its name.
+func expandPlaceholders(lc lintCommand) (lintCommand, string) {
+	command := lc.Args[:len(lc.Args)-1]
+	if !hasPlaceholders(command) {
+		return lc, ""
+	}
+
+	values := placeholderValues(lc)
+	var missing string
+	args := make([]string, len(command))
+	for i, arg := range command {
+		args[i] = placeholderPattern.ReplaceAllStringFunc(arg, func(placeholder string) string {
+			name := strings.TrimSuffix(strings.TrimPrefix(placeholder, "${"), "}")
+			value, ok := values[name]
+			if !ok && missing == "" {
+				missing = placeholder
+			}
+
+			return value
+		})
+	}
+	if missing != "" {
+		return lc, missing
+	}
+	lc.Args = args
+
+	return lc, ""
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+func TestExpandPlaceholders(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/m\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pkg := filepath.Join(root, "pkg")
+	file := filepath.Join(pkg, "a.go")
+
+	tests := []struct {
+		name        string
+		lc          lintCommand
+		want        []string
+		wantMissing string
+	}{
+		{
+			name: "no placeholders",
+			lc:   lintCommand{Args: []string{"golangci-lint", "run", pkg}, Dir: root, File: file},
+			want: []string{"golangci-lint", "run", pkg},
+		},
+		{
+			name: "several placeholders",
+			lc: lintCommand{
+				Args: []string{"make", "lint", "PKG=${dir}", "REL=${relDir}", "FILES=${relFile}:${file}", "ROOT=${moduleRoot}", pkg},
+				Dir:  root,
+				File: file,
+			},
+			want: []string{"make", "lint", "PKG=" + pkg, "REL=pkg", "FILES=" + filepath.Join("pkg", "a.go") + ":" + file, "ROOT=" + root},
+		},
+		{
+			name: "package pattern",
+			lc:   lintCommand{Args: []string{"bazel", "run", "//:lint", "--", "${relDir}", "./..."}, Dir: root},
+			want: []string{"bazel", "run", "//:lint", "--", "./..."},
+		},
+		{
+			name:        "file of a workspace run",
+			lc:          lintCommand{Args: []string{"lint", "${file}", "./..."}, Dir: root},
+			want:        []string{"lint", "${file}", "./..."},
+			wantMissing: "${file}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing := expandPlaceholders(tt.lc)
+			if missing != tt.wantMissing {
+				t.Errorf("missing %q, want %q", missing, tt.wantMissing)
+			}
+			if !reflect.DeepEqual(got.Args, tt.want) {
+				t.Errorf("args %q, want %q", got.Args, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutablePlaceholderRejected(t *testing.T) {
+	msgs := messages.New("", nil)
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "command", raw: `{"command": ["${moduleRoot}/bin/golangci-lint", "run"]}`, want: `"command"`},
+		{name: "folder command", raw: `{"folders": {"sub": {"command": ["${dir}/lint"]}}}`, want: `"folders.sub.command"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeOptions([]byte(tt.raw), msgs)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("decodeOptions() = %v, want an error about %s", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := decodeOptions([]byte(`{"command": ["golangci-lint", "run", "--path-prefix=${relDir}"]}`), msgs); err != nil {
+		t.Errorf("placeholder in an argument: %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package cgo
+
+/*
+#include <stdint.h>
+
+static uint32_t checksum(const unsigned char *buf, size_t n) {
+	uint32_t sum = 0;
+	for (size_t i = 0; i < n; i++) {
+		sum = sum * 31 + buf[i];
+	}
+	return sum;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// Checksum returns the checksum of b.
+func Checksum(b []byte) (uint32, error) {
+	if len(b) == 0 {
+		return 0, errors.New("empty input")
+	}
+
+	sum := C.checksum((*C.uchar)(unsafe.Pointer(&b[0])), C.size_t(len(b)))
+
+	return uint32(sum), nil
+}
@@ -0,0 +1,6 @@
+package cgo
+
+// Double doubles n.
+func Double(n int) int {
+	return n * 2
+}
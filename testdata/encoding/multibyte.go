@@ -0,0 +1,3 @@
+package test
+
+var s = "é日本😀" + x
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func isTestFile(path string) bool {
+	return strings.HasSuffix(path, "_test.go")
+}
+
+// testsExcluded reports whether golangci-lint run with args from dir skips test files,
+// through --tests=false or run.tests: false in its YAML or JSON configuration.
+func testsExcluded(dir string, args []string) bool {
+	for i := len(args) - 1; i >= 1; i-- {
+		if strings.HasPrefix(args[i], "--tests=") {
+			return args[i] == "--tests=false" || args[i] == "--tests=0"
+		}
+	}
+
+	path := findConfigPath(dir, args[1:])
+	if path == "" || filepath.Ext(path) == ".toml" {
+		return false
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	var config struct {
+		Run struct {
+			Tests *bool `yaml:"tests"`
+		} `yaml:"run"`
+	}
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		return false
+	}
+
+	return config.Run.Tests != nil && !*config.Run.Tests
+}
+
+// fileTestsExcluded reports whether the lint of the file at path skips test files.
+func (h *langHandler) fileTestsExcluded(path string) bool {
+	command, _ := h.commandFor(path)
+	lc := resolveFileCommand(command, h.root(), h.workModules(), path)
+
+	return testsExcluded(lc.Dir, lc.Args)
+}
+
+// runCovers reports whether the lint run for uri looked at sibling. With test files excluded,
+// it doesn't look at them, and the run for a test file doesn't happen at all.
+func runCovers(uri, sibling DocumentURI, testsExcluded bool) bool {
+	if !testsExcluded {
+		return true
+	}
+
+	return !isTestFile(string(uri)) && !isTestFile(string(sibling))
+}
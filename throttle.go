@@ -0,0 +1,59 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultWatchedFilesBurst = 8
+	defaultWatchedFilesRate  = 30 // package lints per minute
+)
+
+// tokenBucket limits the package lints started by changes on disk: a batch of changes may
+// start as many lints as there are tokens, and tokens come back at a steady rate up to the burst.
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	config func() (burst int, perMinute int)
+}
+
+// take reports whether n lints may start now, taking their tokens. When they may not, the
+// bucket is emptied, so that a storm of changes keeps being throttled until it calms down.
+// A burst of 0 disables the throttle.
+func (b *tokenBucket) take(n int, now time.Time) bool {
+	burst, perMinute := b.config()
+	if burst <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else {
+		b.tokens += now.Sub(b.last).Minutes() * float64(perMinute)
+		if b.tokens > float64(burst) {
+			b.tokens = float64(burst)
+		}
+	}
+	b.last = now
+
+	if float64(n) > b.tokens {
+		b.tokens = 0
+
+		return false
+	}
+	b.tokens -= float64(n)
+
+	return true
+}
+
+func (h *langHandler) watchedFilesThrottle() (int, int) {
+	opts := h.currentOptions()
+
+	return opts.WatchedFilesBurst, opts.WatchedFilesRate
+}
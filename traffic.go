@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+const trafficSize = 50
+
+// messageSummary describes one JSON-RPC message without its content.
+type messageSummary struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"` // "in" or "out"
+	Method    string    `json:"method,omitempty"`
+	ID        string    `json:"id,omitempty"`
+	Size      int       `json:"size"` // of the params or the result
+	Error     string    `json:"error,omitempty"`
+}
+
+// traffic keeps the summaries of the last trafficSize messages, so that a crash report can
+// tell which message led to it. Recording only copies a few fields under the lock.
+type traffic struct {
+	mu       sync.Mutex
+	messages [trafficSize]messageSummary
+	next     int
+	full     bool
+}
+
+func (t *traffic) add(m messageSummary) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages[t.next] = m
+	t.next = (t.next + 1) % trafficSize
+	t.full = t.full || t.next == 0
+}
+
+// list returns the summaries, oldest first.
+func (t *traffic) list() []messageSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.full {
+		return append([]messageSummary{}, t.messages[:t.next]...)
+	}
+
+	return append(append([]messageSummary{}, t.messages[t.next:]...), t.messages[:t.next]...)
+}
+
+// recorder returns a jsonrpc2 OnRecv or OnSend callback recording the messages in direction.
+func (t *traffic) recorder(direction string) func(*jsonrpc2.Request, *jsonrpc2.Response) {
+	return func(req *jsonrpc2.Request, resp *jsonrpc2.Response) {
+		m := messageSummary{Time: time.Now(), Direction: direction}
+		if req != nil {
+			m.Method = req.Method
+			if !req.Notif {
+				m.ID = req.ID.String()
+			}
+			if req.Params != nil {
+				m.Size = len(*req.Params)
+			}
+		}
+		if resp != nil {
+			m.ID = resp.ID.String()
+			m.Size = 0
+			if resp.Result != nil {
+				m.Size = len(*resp.Result)
+			}
+			if resp.Error != nil {
+				m.Error = resp.Error.Message
+			}
+		}
+		t.add(m)
+	}
+}
+
+// connOpts records the messages of the connection.
+func (t *traffic) connOpts() []jsonrpc2.ConnOpt {
+	return []jsonrpc2.ConnOpt{jsonrpc2.OnRecv(t.recorder("in")), jsonrpc2.OnSend(t.recorder("out"))}
+}
+
+// dumpTraffic logs the last messages, oldest first.
+func (h *langHandler) dumpTraffic() {
+	messages := h.traffic.list()
+	h.logger.Printf("golangci-lint-langserver: last %d messages:", len(messages))
+	for _, m := range messages {
+		h.logger.Printf("golangci-lint-langserver:   %s %-3s %s id=%s size=%d %s", m.Time.Format(time.RFC3339Nano), m.Direction, m.Method, m.ID, m.Size, m.Error)
+	}
+}
+
+func (h *langHandler) handleDumpState(_ context.Context, _ *jsonrpc2.Conn, _ *jsonrpc2.Request) (result interface{}, err error) {
+	return h.debugState(), nil
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+// trustedCommand is the executable name that runs without confirmation when found in PATH.
+const trustedCommand = "golangci-lint"
+
+// trustFile holds the decisions of the user per workspace root and executable, under os.UserConfigDir.
+const trustFile = "golangci-lint-langserver/trust.json"
+
+// trustStore decides whether the executables configured by a workspace may run. The command
+// comes from initializationOptions, which clients fill from workspace settings, so a
+// repository could otherwise make the server run any binary it ships.
+type trustStore struct {
+	mu sync.Mutex
+	// all skips every check, for -trust-all and -replay.
+	all bool
+	// path is the file decisions are kept in, or "" when there is no config directory.
+	path      string
+	loaded    bool
+	decisions map[string]map[string]bool
+	// prompt is held while the user is asked, so concurrent runs wait for the same answer.
+	prompt sync.Mutex
+	// parked holds per executable the lint requests waiting for the user to decide on it.
+	parked map[string][]Request
+}
+
+func newTrustStore() *trustStore {
+	s := &trustStore{decisions: make(map[string]map[string]bool)}
+	if dir, err := os.UserConfigDir(); err == nil {
+		s.path = filepath.Join(dir, filepath.FromSlash(trustFile))
+	}
+
+	return s
+}
+
+// trustedByDefault reports whether bin is golangci-lint from PATH or an executable installed
+// by go install.
+func trustedByDefault(bin string) bool {
+	if bin == trustedCommand {
+		return true
+	}
+	if !filepath.IsAbs(bin) {
+		return false
+	}
+
+	dirs := filepath.SplitList(build.Default.GOPATH)
+	for i, dir := range dirs {
+		dirs[i] = filepath.Join(dir, "bin")
+	}
+	if gobin := os.Getenv("GOBIN"); gobin != "" {
+		dirs = append(dirs, gobin)
+	}
+	for _, dir := range dirs {
+		if filepath.IsAbs(dir) && samePath(filepath.Dir(filepath.Clean(bin)), dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sensitiveEnv are the variables that decide which programs golangci-lint and the go
+// command it runs start, e.g. GOFLAGS=-toolexec=./x.sh.
+var sensitiveEnv = []string{
+	"CC", "CGO_CFLAGS", "CGO_CXXFLAGS", "CGO_LDFLAGS", "CXX", "DYLD_INSERT_LIBRARIES",
+	"GOENV", "GOFLAGS", "GOROOT", "GOTOOLCHAIN", "LD_PRELOAD", "PATH", "PKG_CONFIG",
+}
+
+// trustSubject names what the user decides on: bin, followed by the variables of env, as set
+// by the env of a folder override, that decide which programs run, e.g.
+// "golangci-lint GOFLAGS=-toolexec=./x.sh".
+func trustSubject(bin string, env []string) string {
+	var sensitive []string
+	for _, kv := range env {
+		name := strings.SplitN(kv, "=", 2)[0]
+		for _, s := range sensitiveEnv {
+			if strings.EqualFold(name, s) {
+				sensitive = append(sensitive, kv)
+			}
+		}
+	}
+	if len(sensitive) == 0 {
+		return bin
+	}
+	sort.Strings(sensitive)
+
+	return bin + " " + strings.Join(sensitive, " ")
+}
+
+// decision returns what was decided for bin run with env in the workspace at root, if
+// anything. Only bin without sensitive variables may be trusted by default.
+func (s *trustStore) decision(root, bin string, env []string) (allowed, decided bool) {
+	subject := trustSubject(bin, env)
+	if s.all || (subject == bin && trustedByDefault(bin)) {
+		return true, true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.loaded && s.path != "" {
+		if b, err := ioutil.ReadFile(s.path); err == nil {
+			_ = json.Unmarshal(b, &s.decisions)
+		}
+		if s.decisions == nil {
+			s.decisions = make(map[string]map[string]bool)
+		}
+	}
+	s.loaded = true
+
+	allowed, decided = s.decisions[root][subject]
+
+	return allowed, decided
+}
+
+// decide records a decision on a trustSubject, writing it to the trust file when persist is set.
+func (s *trustStore) decide(root, subject string, allowed, persist bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.decisions[root] == nil {
+		s.decisions[root] = make(map[string]bool)
+	}
+	s.decisions[root][subject] = allowed
+
+	if !persist || s.path == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(s.decisions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.path, b, 0o600)
+}
+
+// trusted reports whether bin may run with env in this workspace without asking.
+func (h *langHandler) trusted(bin string, env []string) bool {
+	allowed, _ := h.trust.decision(h.root(), bin, env)
+
+	return allowed
+}
+
+// authorize asks the user with window/showMessageRequest whether bin may run with env in
+// this workspace, unless that was decided before, and returns an error when it may not.
+// It must be called neither from the handler goroutine, which reads the answer, nor from
+// the lint worker, which the handler goroutine may wait for; see park.
+func (h *langHandler) authorize(bin string, env []string) error {
+	subject := trustSubject(bin, env)
+	if allowed, decided := h.trust.decision(h.root(), bin, env); decided || h.conn == nil {
+		return h.trustError(subject, allowed)
+	}
+
+	h.trust.prompt.Lock()
+	defer h.trust.prompt.Unlock()
+
+	// Another run may have asked while this one waited.
+	if allowed, decided := h.trust.decision(h.root(), bin, env); decided {
+		return h.trustError(subject, allowed)
+	}
+
+	msgs := h.catalog()
+	allow, deny := msgs.Sprintf(messages.TrustAllow), msgs.Sprintf(messages.TrustDeny)
+	params := &ShowMessageRequestParams{
+		Type:    MTWarning,
+		Message: msgs.Sprintf(messages.TrustPrompt, subject),
+		Actions: []MessageActionItem{{Title: allow}, {Title: deny}},
+	}
+
+	var answer *MessageActionItem
+	if err := h.conn.Call(context.Background(), "window/showMessageRequest", params, &answer); err != nil {
+		h.logger.Printf("golangci-lint-langserver: %s", err)
+	}
+
+	// A dismissed prompt denies bin until the server restarts, and asks again then.
+	allowed := answer != nil && answer.Title == allow
+	if err := h.trust.decide(h.root(), subject, allowed, answer != nil); err != nil {
+		h.logger.Printf("golangci-lint-langserver: failed to save the trust decision: %s", err)
+	}
+	if allowed {
+		// Features were not detected for an executable that wasn't trusted yet.
+		if err := h.applyOptions(h.currentOptions()); err != nil {
+			h.notifyError(err.Error())
+		}
+	}
+
+	return h.trustError(subject, allowed)
+}
+
+// undecided returns the executable linting path and its environment if the user has yet to
+// be asked about them.
+func (h *langHandler) undecided(path string) (string, []string, bool) {
+	command, env := h.commandFor(path)
+	if len(command) == 0 || h.conn == nil {
+		return "", nil, false
+	}
+	_, decided := h.trust.decision(h.root(), command[0], env)
+
+	return command[0], env, !decided
+}
+
+// park holds req until the user decided whether bin may run with env, asking from a goroutine
+// of its own: the lint worker must not wait for the answer, as the handler goroutine reading
+// it may itself be waiting to hand the worker a request. The requests are enqueued again
+// once allowed.
+func (h *langHandler) park(bin string, env []string, req Request) {
+	subject := trustSubject(bin, env)
+
+	h.trust.mu.Lock()
+	if h.trust.parked == nil {
+		h.trust.parked = make(map[string][]Request)
+	}
+	reqs, asking := h.trust.parked[subject]
+	h.trust.parked[subject] = append(reqs, req)
+	h.trust.mu.Unlock()
+	if asking {
+		return
+	}
+
+	go func() {
+		err := h.authorize(bin, env)
+
+		h.trust.mu.Lock()
+		reqs := h.trust.parked[subject]
+		delete(h.trust.parked, subject)
+		h.trust.mu.Unlock()
+
+		if err != nil {
+			for _, req := range reqs {
+				h.endProvisional(filepath.Dir(h.paths.uriToPath(string(req.URI))), nil)
+			}
+			h.notifyError(err.Error())
+
+			return
+		}
+		for _, req := range reqs {
+			if !h.scheduler.Enqueue(req) {
+				h.logger.Printf("golangci-lint-langserver: not linting %s after shutdown", req.URI)
+			}
+		}
+	}()
+}
+
+// authorizeFor authorizes the executable linting path.
+func (h *langHandler) authorizeFor(path string) error {
+	command, env := h.commandFor(path)
+	if len(command) == 0 {
+		return nil
+	}
+
+	return h.authorize(command[0], env)
+}
+
+func (h *langHandler) trustError(subject string, allowed bool) error {
+	if allowed {
+		return nil
+	}
+
+	return h.catalog().Errorf(messages.CommandNotTrusted, subject)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+func TestTrustSubject(t *testing.T) {
+	tests := []struct {
+		name string
+		env  []string
+		want string
+	}{
+		{name: "no env", want: "golangci-lint"},
+		{name: "harmless env", env: []string{"GOPRIVATE=example.com", "GOGC=50"}, want: "golangci-lint"},
+		{
+			name: "sensitive env sorted",
+			env:  []string{"PATH=/tmp/bin", "GOFLAGS=-mod=vendor", "GOGC=50"},
+			want: "golangci-lint GOFLAGS=-mod=vendor PATH=/tmp/bin",
+		},
+		{name: "toolchain", env: []string{"GOTOOLCHAIN=go1.99"}, want: "golangci-lint GOTOOLCHAIN=go1.99"},
+		{name: "compiler", env: []string{"CC=/tmp/cc"}, want: "golangci-lint CC=/tmp/cc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trustSubject("golangci-lint", tt.env); got != tt.want {
+				t.Errorf("trustSubject() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrustDecisionEnv(t *testing.T) {
+	s := newTrustStore()
+	s.path = ""
+
+	if allowed, decided := s.decision("/work", trustedCommand, nil); !allowed || !decided {
+		t.Errorf("golangci-lint from PATH: allowed %v, decided %v, want trusted by default", allowed, decided)
+	}
+	env := []string{"PATH=/work/bin"}
+	if _, decided := s.decision("/work", trustedCommand, env); decided {
+		t.Error("golangci-lint with a workspace PATH is trusted by default")
+	}
+
+	if err := s.decide("/work", trustSubject(trustedCommand, env), true, false); err != nil {
+		t.Fatal(err)
+	}
+	if allowed, decided := s.decision("/work", trustedCommand, env); !allowed || !decided {
+		t.Errorf("allowed env: allowed %v, decided %v", allowed, decided)
+	}
+	if _, decided := s.decision("/work", trustedCommand, []string{"PATH=/elsewhere"}); decided {
+		t.Error("allowing one PATH allowed another")
+	}
+}
+
+// TestTrustPromptDoesNotBlock checks that the server keeps reading messages while the user is
+// asked whether to trust the command: a didOpen arriving before the answer must neither
+// deadlock the worker nor get lost.
+func TestTrustPromptDoesNotBlock(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		prompts int
+		ts      *testServer
+	)
+	ts = newTestServer(t, testConfig{
+		options:   map[string]interface{}{"command": []string{"/nonexistent/bin/golangci-lint", "run"}},
+		files:     map[string]string{"b.go": "package test\n\nfunc B() {}\n"},
+		untrusted: true,
+		handle: func(conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+			if req.Method != "window/showMessageRequest" {
+				return nil, nil
+			}
+			var params ShowMessageRequestParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			if !strings.Contains(params.Message, "/nonexistent/bin/golangci-lint") {
+				t.Errorf("prompt %q doesn't name the command", params.Message)
+			}
+
+			mu.Lock()
+			prompts++
+			first := prompts == 1
+			mu.Unlock()
+			if first {
+				// Opening another file before answering used to block the handler goroutine
+				// on the worker, which waited for this answer.
+				params := DidOpenTextDocumentParams{TextDocument: TextDocumentItem{
+					URI: ts.uri("b.go"), LanguageID: "go", Version: 1, Text: "package test\n\nfunc B() {}\n",
+				}}
+				if err := conn.Notify(context.Background(), "textDocument/didOpen", params); err != nil {
+					return nil, err
+				}
+			}
+
+			return MessageActionItem{Title: ts.h.catalog().Sprintf(messages.TrustAllow)}, nil
+		},
+	})
+
+	ts.open("a.go")
+	ts.waitPublished("a.go")
+	ts.waitPublished("b.go")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if prompts != 1 {
+		t.Errorf("asked %d times, want once", prompts)
+	}
+}
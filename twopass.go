@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// twoPasses tracks the full passes of the twoPass option. Every lint request of a document
+// starts a new generation, which cancels the full pass of the previous one.
+type twoPasses struct {
+	mu     sync.Mutex
+	gen    map[DocumentURI]int
+	cancel map[DocumentURI]context.CancelFunc
+}
+
+// supersede starts a new generation for uri, killing its running full pass.
+func (p *twoPasses) supersede(uri DocumentURI) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.gen == nil {
+		p.gen = make(map[DocumentURI]int)
+		p.cancel = make(map[DocumentURI]context.CancelFunc)
+	}
+	p.gen[uri]++
+	if cancel, ok := p.cancel[uri]; ok {
+		cancel()
+		delete(p.cancel, uri)
+	}
+}
+
+func (p *twoPasses) generation(uri DocumentURI) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.gen[uri]
+}
+
+// start returns the context of the full pass of generation gen of uri, or false if a newer
+// request superseded it.
+func (p *twoPasses) start(uri DocumentURI, gen int) (context.Context, func(), bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.gen[uri] != gen {
+		return nil, nil, false
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if p.cancel == nil {
+		p.cancel = make(map[DocumentURI]context.CancelFunc)
+	}
+	p.cancel[uri] = cancel
+
+	return ctx, func() {
+		cancel()
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		if p.gen[uri] == gen {
+			delete(p.cancel, uri)
+		}
+	}, true
+}
+
+// twoPass reports whether uri is linted in two passes. Pull clients ask for one report.
+func (h *langHandler) twoPass(uri DocumentURI) bool {
+	return h.currentOptions().TwoPass && !h.isPullMode() && !isLintConfig(h.paths.uriToPath(string(uri)))
+}
+
+// withFastLinters makes lc run only the fast linters.
+func (h *langHandler) withFastLinters(lc lintCommand) lintCommand {
+	command, target := lc.Args[:len(lc.Args)-1], lc.Args[len(lc.Args)-1]
+	command = removeFlag(removeFlag(removeFlag(command, "--enable-only"), "--enable"), "-E")
+
+	args := make([]string, 0, len(command)+2)
+	args = append(args, command...)
+	args = append(args, "--enable-only="+strings.Join(h.currentOptions().FastLinters, ","), target)
+	lc.Args, lc.Fast = args, true
+
+	return lc
+}
+
+// fastPass lints the package of req with the fast linters, publishes the result unless a
+// newer request came meanwhile, and schedules the full pass, which replaces it.
+func (h *langHandler) fastPass(req Request) {
+	defer h.recoverPanic("fast lint " + string(req.URI))
+
+	gen := h.passes.generation(req.URI)
+	diagnostics, err := h.lintPass(context.Background(), req.URI, true)
+	if h.passes.generation(req.URI) != gen {
+		return
+	}
+	h.publishLint(req.URI, diagnostics, err)
+
+	// The worker runs this request, so it can't take the next one before it returns.
+	go h.scheduleFullPass(req.URI, gen)
+}
+
+// scheduleFullPass hands the full pass following the fast pass of generation gen to the scheduler.
+func (h *langHandler) scheduleFullPass(uri DocumentURI, gen int) {
+	doc, _ := h.documents.get(uri)
+	req := Request{URI: uri, Trigger: TriggerFullPass, Version: doc.Version, EnqueuedAt: time.Now(), Generation: gen}
+	if !h.scheduler.Enqueue(req) {
+		h.logger.Printf("golangci-lint-langserver: not linting %s after shutdown", uri)
+	}
+}
+
+// fullPass lints the package of req with the configured linters, replacing the diagnostics of
+// the fast pass, unless a newer request superseded it.
+func (h *langHandler) fullPass(req Request) {
+	defer h.recoverPanic("lint " + string(req.URI))
+
+	ctx, done, ok := h.passes.start(req.URI, req.Generation)
+	if !ok {
+		h.logger.DebugJSON("golangci-lint-langserver: full pass superseded:", req)
+
+		return
+	}
+	defer done()
+
+	diagnostics, err := h.lintPass(ctx, req.URI, false)
+	if ctx.Err() != nil || h.passes.generation(req.URI) != req.Generation {
+		h.logger.Printf("golangci-lint-langserver: full pass of %s cancelled by a newer lint", filepath.Base(h.paths.uriToPath(string(req.URI))))
+
+		return
+	}
+	h.publishLint(req.URI, diagnostics, err)
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"io/ioutil"
 	"net/url"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"unicode"
 )
@@ -34,3 +36,122 @@ func isWindowsDriveURIPath(uri string) bool {
 
 	return uri[0] == '/' && unicode.IsLetter(rune(uri[1])) && uri[2] == ':'
 }
+
+func pathToURI(path string) DocumentURI {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows drive letter paths like C:/foo need a leading slash in a file URI.
+		path = "/" + path
+	}
+
+	return DocumentURI((&url.URL{Scheme: "file", Path: path}).String())
+}
+
+// caseInsensitiveFS reports whether the default filesystem of the platform ignores case.
+func caseInsensitiveFS() bool {
+	return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+}
+
+func samePath(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if caseInsensitiveFS() {
+		return strings.EqualFold(a, b)
+	}
+
+	return a == b
+}
+
+// canonicalPath resolves the on-disk casing of path on case-insensitive filesystems
+// so that diagnostics open the existing buffer instead of a duplicate one. Paths of many issues
+// are better resolved with a casingResolver.
+func canonicalPath(path string) string {
+	return newCasingResolver().path(path)
+}
+
+// casingResolver resolves paths like canonicalPath, remembering the casing of every directory
+// and its entries, so that the issues of a run don't list the same directories over and over.
+type casingResolver struct {
+	resolved map[string]string
+	entries  map[string][]string
+}
+
+func newCasingResolver() *casingResolver {
+	return &casingResolver{resolved: make(map[string]string), entries: make(map[string][]string)}
+}
+
+// path returns path in its on-disk casing on case-insensitive filesystems, or as is.
+func (r *casingResolver) path(path string) string {
+	if !caseInsensitiveFS() || !filepath.IsAbs(path) {
+		return path
+	}
+
+	return r.resolve(filepath.Clean(path))
+}
+
+// resolve returns the absolute, clean path with every existing component in its on-disk
+// casing; components that don't exist are kept.
+func (r *casingResolver) resolve(path string) string {
+	if resolved, ok := r.resolved[path]; ok {
+		return resolved
+	}
+
+	resolved := path
+	if parent := filepath.Dir(path); parent != path {
+		dir := r.resolve(parent)
+		name := filepath.Base(path)
+		match := name
+		for _, entry := range r.names(dir) {
+			if entry == name {
+				match = name
+
+				break
+			}
+			if strings.EqualFold(entry, name) {
+				match = entry
+			}
+		}
+		resolved = filepath.Join(dir, match)
+	}
+	r.resolved[path] = resolved
+
+	return resolved
+}
+
+// names returns the names of the entries of dir, none if it can't be read.
+func (r *casingResolver) names(dir string) []string {
+	if names, ok := r.entries[dir]; ok {
+		return names
+	}
+
+	var names []string
+	if entries, err := ioutil.ReadDir(dir); err == nil {
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+	}
+	r.entries[dir] = names
+
+	return names
+}
+
+// symlinkResolver resolves the symlinks of directories, remembering the results.
+type symlinkResolver map[string]string
+
+func newSymlinkResolver() symlinkResolver {
+	return make(symlinkResolver)
+}
+
+// path returns dir with its symlinks resolved, or dir itself when that fails.
+func (r symlinkResolver) path(dir string) string {
+	if resolved, ok := r[dir]; ok {
+		return resolved
+	}
+
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		resolved = dir
+	}
+	r[dir] = resolved
+
+	return resolved
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCasingResolver(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "Proj", "Sub")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("File%d.go", i)), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := newCasingResolver()
+	for i := 0; i < 50; i++ {
+		got := r.resolve(filepath.Join(root, "proj", "SUB", fmt.Sprintf("file%d.go", i)))
+		if want := filepath.Join(dir, fmt.Sprintf("File%d.go", i)); got != want {
+			t.Fatalf("resolve() = %s, want %s", got, want)
+		}
+	}
+	if got, want := r.resolve(filepath.Join(root, "proj", "sub", "new.go")), filepath.Join(dir, "new.go"); got != want {
+		t.Errorf("missing file: resolve() = %s, want %s", got, want)
+	}
+
+	// Every directory from the filesystem root to Sub is listed once, whatever the number of files.
+	depth := strings.Count(strings.TrimPrefix(dir, filepath.VolumeName(dir)), string(filepath.Separator))
+	if len(r.entries) != depth+1 {
+		t.Errorf("listed %d directories, want %d", len(r.entries), depth+1)
+	}
+}
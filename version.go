@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+const detectTimeout = 10 * time.Second
+
+// minSupportedVersion is the oldest golangci-lint whose JSON output matches GolangCILintResult.
+var minSupportedVersion = golangciVersion{Major: 1, Minor: 23}
+
+type golangciVersion struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	Patch int `json:"patch"`
+}
+
+func (v golangciVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+func (v golangciVersion) Less(o golangciVersion) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+// featureSet describes what the installed golangci-lint understands.
+// The zero value means nothing could be detected and the user command is used as is.
+type featureSet struct {
+	Detected       bool            `json:"detected"`
+	Version        golangciVersion `json:"version"`
+	OutFormat      bool            `json:"outFormat"`      // --out-format (v1)
+	OutputJSONPath bool            `json:"outputJSONPath"` // --output.json.path (v2)
+	IssuesExitCode bool            `json:"issuesExitCode"`
+	PathPrefix     bool            `json:"pathPrefix"`
+	ShowStats      bool            `json:"showStats"` // --show-stats, printed after the JSON by default in v2
+}
+
+func (f featureSet) IsV2() bool {
+	return f.Version.Major >= 2
+}
+
+var versionRegexp = regexp.MustCompile(`version v?(\d+)\.(\d+)\.(\d+)`)
+
+func parseVersion(out string) (golangciVersion, bool) {
+	m := versionRegexp.FindStringSubmatch(out)
+	if m == nil {
+		return golangciVersion{}, false
+	}
+
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+
+	return golangciVersion{Major: major, Minor: minor, Patch: patch}, true
+}
+
+func parseHelpFlags(help string) featureSet {
+	return featureSet{
+		OutFormat:      strings.Contains(help, "--out-format"),
+		OutputJSONPath: strings.Contains(help, "--output.json.path"),
+		IssuesExitCode: strings.Contains(help, "--issues-exit-code"),
+		PathPrefix:     strings.Contains(help, "--path-prefix"),
+		ShowStats:      strings.Contains(help, "--show-stats"),
+	}
+}
+
+func runDetect(bin string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), detectTimeout)
+	defer cancel()
+
+	//nolint:gosec
+	b, err := exec.CommandContext(ctx, bin, args...).CombinedOutput()
+
+	return string(b), err
+}
+
+// detectFeatures runs `golangci-lint version` and `golangci-lint run --help` to find out which flags exist.
+func detectFeatures(bin string) (featureSet, error) {
+	out, err := runDetect(bin, "version")
+	if err != nil {
+		// golangci-lint older than 1.20 only knows --version.
+		out, err = runDetect(bin, "--version")
+	}
+	if err != nil {
+		return featureSet{}, err
+	}
+
+	version, ok := parseVersion(out)
+	if !ok {
+		return featureSet{}, fmt.Errorf("unrecognized version output: %q", strings.TrimSpace(out))
+	}
+
+	help, _ := runDetect(bin, "run", "--help")
+
+	features := parseHelpFlags(help)
+	features.Detected = true
+	features.Version = version
+
+	return features, nil
+}
+
+func (f featureSet) checkSupported(msgs *messages.Catalog) error {
+	if !f.Detected || !f.Version.Less(minSupportedVersion) {
+		return nil
+	}
+
+	return msgs.Errorf(messages.UnsupportedVersion, f.Version, minSupportedVersion)
+}
+
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == name || strings.HasPrefix(arg, name+"=") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeFlag drops every occurrence of the flag, including a separate value argument.
+func removeFlag(args []string, name string) []string {
+	result := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == name:
+			i++
+		case strings.HasPrefix(args[i], name+"="):
+		default:
+			result = append(result, args[i])
+		}
+	}
+
+	return result
+}
+
+// normalizeCommand makes sure the command asks golangci-lint for JSON output in the dialect of the detected version.
+// It also replaces any --issues-exit-code with 0, so that finding issues is never mistaken for a failure,
+// and turns off the stats footer unless the command asks for it.
+func normalizeCommand(command []string, f featureSet) []string {
+	if !f.Detected || len(command) == 0 {
+		return command
+	}
+
+	args := append([]string{}, command[1:]...)
+
+	switch {
+	case f.IsV2() && f.OutputJSONPath:
+		args = removeFlag(args, "--out-format")
+		if !hasFlag(args, "--output.json.path") {
+			args = append(args, "--output.json.path=stdout")
+		}
+	case f.OutFormat:
+		if !hasFlag(args, "--out-format") {
+			args = append(args, "--out-format=json")
+		}
+	}
+
+	if f.IssuesExitCode {
+		args = append(removeFlag(args, "--issues-exit-code"), "--issues-exit-code=0")
+	}
+
+	if f.ShowStats && !hasFlag(args, "--show-stats") {
+		args = append(args, "--show-stats=false")
+	}
+
+	return append([]string{command[0]}, args...)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readHelp returns testdata/help/name.txt, the output of `golangci-lint version` followed by
+// that of `golangci-lint run --help`, captured from a release.
+func readHelp(t *testing.T, name string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", "help", name+".txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+// detectedFeatures returns the featureSet detectFeatures finds for the captured release name.
+func detectedFeatures(t *testing.T, name string) featureSet {
+	t.Helper()
+
+	out := readHelp(t, name)
+	version, ok := parseVersion(out)
+	if !ok {
+		t.Fatalf("no version in %s", name)
+	}
+	features := parseHelpFlags(out)
+	features.Detected = true
+	features.Version = version
+
+	return features
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		out    string
+		want   golangciVersion
+		wantOK bool
+	}{
+		{out: "golangci-lint has version 1.23.8 built from 5ce6e12 on 2020-03-02T11:48:43Z\n", want: golangciVersion{1, 23, 8}, wantOK: true},
+		{out: "golangci-lint has version v1.64.8 built with go1.24.1 from (unknown, modified: ?) on (unknown)\n", want: golangciVersion{1, 64, 8}, wantOK: true},
+		{out: "golangci-lint has version 2.1.6 built with go1.24.2 from eabc2638 on 2025-05-04T15:41:19Z\n", want: golangciVersion{2, 1, 6}, wantOK: true},
+		{out: "golangci-lint has version (devel) built with go1.24.2 from (unknown, modified: ?) on (unknown)\n"},
+		{out: "unknown command \"version\" for \"golangci-lint\"\n"},
+	}
+	for _, tt := range tests {
+		got, ok := parseVersion(tt.out)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("parseVersion(%q) = %v, %v, want %v, %v", tt.out, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestParseHelpFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		want featureSet
+	}{
+		{name: "v1.23", want: featureSet{OutFormat: true, IssuesExitCode: true}},
+		{name: "v1.64", want: featureSet{OutFormat: true, IssuesExitCode: true, PathPrefix: true, ShowStats: true}},
+		{name: "v2.1", want: featureSet{OutputJSONPath: true, IssuesExitCode: true, PathPrefix: true, ShowStats: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseHelpFlags(readHelp(t, tt.name)); got != tt.want {
+				t.Errorf("parseHelpFlags() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		release string
+		command string
+		want    string
+	}{
+		{
+			name:    "undetected",
+			command: "golangci-lint run --issues-exit-code=1",
+			want:    "golangci-lint run --issues-exit-code=1",
+		},
+		{
+			name:    "v1.23",
+			release: "v1.23",
+			command: "golangci-lint run",
+			want:    "golangci-lint run --out-format=json --issues-exit-code=0",
+		},
+		{
+			name:    "v1.64",
+			release: "v1.64",
+			command: "golangci-lint run",
+			want:    "golangci-lint run --out-format=json --issues-exit-code=0 --show-stats=false",
+		},
+		{
+			name:    "v1.64 keeps the output format and stats asked for",
+			release: "v1.64",
+			command: "golangci-lint run --out-format json --show-stats",
+			want:    "golangci-lint run --out-format json --show-stats --issues-exit-code=0",
+		},
+		{
+			name:    "v2.1",
+			release: "v2.1",
+			command: "golangci-lint run",
+			want:    "golangci-lint run --output.json.path=stdout --issues-exit-code=0 --show-stats=false",
+		},
+		{
+			name:    "v2.1 drops the v1 output format",
+			release: "v2.1",
+			command: "golangci-lint run --out-format=json --output.json.path=stdout",
+			want:    "golangci-lint run --output.json.path=stdout --issues-exit-code=0 --show-stats=false",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var features featureSet
+			if tt.release != "" {
+				features = detectedFeatures(t, tt.release)
+			}
+			got := normalizeCommand(strings.Fields(tt.command), features)
+			if want := strings.Fields(tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("normalizeCommand() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestNormalizeCommandIssuesExitCode(t *testing.T) {
+	features := detectedFeatures(t, "v1.64")
+	for _, command := range []string{
+		"golangci-lint run --issues-exit-code 7",
+		"golangci-lint run --issues-exit-code=7",
+		"golangci-lint run --issues-exit-code=7 --issues-exit-code 3",
+		"golangci-lint run --issues-exit-code=0",
+	} {
+		got := normalizeCommand(strings.Fields(command), features)
+		want := strings.Fields("golangci-lint run --out-format=json --issues-exit-code=0 --show-stats=false")
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("normalizeCommand(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
+
+// TestIssuesExitCode checks that a run exiting with the issues exit code the user configured
+// reports its issues rather than an error.
+func TestIssuesExitCode(t *testing.T) {
+	var issue Issue
+	issue.FromLinter = "unused"
+	issue.Text = "func A is unused"
+	issue.Severity = "warning"
+	issue.Pos.Filename = "a.go"
+	issue.Pos.Line = 3
+
+	runner := &fakeRunner{
+		output: func(*exec.Cmd) string { return issuesOutput(t, issue) },
+		err:    errors.New("exit status 7"),
+	}
+	ts := newTestServer(t, testConfig{
+		options: map[string]interface{}{"command": []string{"golangci-lint", "run", "--issues-exit-code", "7"}},
+		runner:  runner,
+	})
+
+	ts.open("a.go")
+	diagnostics := ts.waitPublished("a.go")
+	if len(diagnostics) != 1 || diagnostics[0].Message != "unused: func A is unused" {
+		t.Errorf("diagnostics %+v, want the issue alone", diagnostics)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+const warmupToken = `"golangci-lint-langserver/warmup"`
+
+// warmup tracks the background run that fills the build and analysis caches after initialized.
+type warmup struct {
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	duration time.Duration
+	reported bool
+}
+
+// preempt stops a running warm-up so that the user's lint doesn't wait behind it.
+func (w *warmup) preempt() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cancel != nil {
+		w.cancel()
+		w.cancel = nil
+	}
+}
+
+// warmUp lints the root module once at low priority and throws the result away; its only
+// purpose is that the first lint the user waits for finds warm caches.
+func (h *langHandler) warmUp() {
+	defer h.recoverPanic("warm-up")
+
+	// Asking here shows the prompt for an untrusted command right after startup.
+	if err := h.authorizeFor(h.root()); err != nil {
+		h.logger.Printf("golangci-lint-langserver: not warming up: %s", err)
+
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h.warmup.mu.Lock()
+	h.warmup.cancel = cancel
+	h.warmup.mu.Unlock()
+
+	token := h.createProgress(warmupToken)
+	h.progress(token, &WorkDoneProgressBegin{Kind: "begin", Title: h.catalog().Sprintf(messages.WarmingUp)})
+	defer h.progress(token, &WorkDoneProgressEnd{Kind: "end"})
+
+	root := findModuleRoot(h.root())
+	if root == "" {
+		root = h.root()
+	}
+	lc := h.folderLintCommand(root, "./...")
+	if targets, all := h.includedTargets(root); !all {
+		if len(targets) == 0 {
+			h.logger.Printf("golangci-lint-langserver: not warming up: %s is outside the included packages", root)
+
+			return
+		}
+		lc.Args = append(lc.Args[:len(lc.Args)-1], targets...)
+	}
+	lc.Env = append(lc.Env, h.currentOptions().env()...)
+	if nice, err := exec.LookPath("nice"); err == nil {
+		lc.Args = append([]string{nice, "-n", "19"}, lc.Args...)
+	}
+
+	start := time.Now()
+	_, err := h.execLint(lc.cmdContext(ctx), true)
+	duration := time.Since(start)
+
+	h.warmup.mu.Lock()
+	defer h.warmup.mu.Unlock()
+
+	if ctx.Err() != nil {
+		h.logger.Printf("golangci-lint-langserver: warm-up interrupted after %s by a lint request", duration)
+
+		return
+	}
+	h.warmup.cancel = nil
+	h.warmup.duration = duration
+
+	if err != nil {
+		h.logger.Printf("golangci-lint-langserver: warm-up failed after %s: %s", duration, err)
+
+		return
+	}
+	h.logger.Printf("golangci-lint-langserver: warm-up (cold caches) took %s", duration)
+}
+
+// reportWarmRun logs how long the first lint after a completed warm-up took.
+func (h *langHandler) reportWarmRun(duration time.Duration) {
+	h.warmup.mu.Lock()
+	defer h.warmup.mu.Unlock()
+
+	if h.warmup.duration == 0 || h.warmup.reported {
+		return
+	}
+	h.warmup.reported = true
+
+	h.logger.Printf("golangci-lint-langserver: first lint after warm-up took %s (warm-up took %s)", duration, h.warmup.duration)
+}
+
+// createProgress asks the client to create a progress token. It returns nil when the client
+// doesn't support server-initiated progress. It must not be called from the handler goroutine.
+func (h *langHandler) createProgress(token string) ProgressToken {
+	if !h.clientCaps.Window.WorkDoneProgress {
+		return nil
+	}
+
+	params := struct {
+		Token ProgressToken `json:"token"`
+	}{Token: json.RawMessage(token)}
+	if err := h.conn.Call(context.Background(), "window/workDoneProgress/create", &params, nil); err != nil {
+		h.logger.Printf("golangci-lint-langserver: %s", err)
+
+		return nil
+	}
+
+	return params.Token
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+const defaultWatchedFilesDelay = 500 // milliseconds
+
+// dirBatcher collects the directories of files changed outside the editor and hands
+// them to flush once no change arrived for delay, so that a branch switch touching
+// thousands of files results in one run per affected package.
+type dirBatcher struct {
+	mu    sync.Mutex
+	dirs  map[string]struct{}
+	timer *time.Timer
+
+	delay func() time.Duration
+	flush func(dirs map[string]struct{})
+}
+
+func (b *dirBatcher) add(dir string) {
+	delay := b.delay()
+	if delay <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.dirs == nil {
+		b.dirs = make(map[string]struct{})
+	}
+	b.dirs[dir] = struct{}{}
+
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.timer = time.AfterFunc(delay, b.fire)
+}
+
+func (b *dirBatcher) fire() {
+	b.mu.Lock()
+	dirs := b.dirs
+	b.dirs = nil
+	b.mu.Unlock()
+
+	if len(dirs) > 0 {
+		b.flush(dirs)
+	}
+}
+
+func (h *langHandler) watchedFilesDelay() time.Duration {
+	return time.Duration(h.currentOptions().WatchedFilesDelay) * time.Millisecond
+}
+
+// registerWatchers asks the client to send workspace/didChangeWatchedFiles for Go files, the
+// settings file and the files isWatchedConfig accepts, unless the watcher option says otherwise.
+func (h *langHandler) registerWatchers() {
+	if h.currentOptions().Watcher != watcherClient || !h.clientCaps.Workspace.DidChangeWatchedFiles.DynamicRegistration {
+		return
+	}
+
+	params := &RegistrationParams{
+		Registrations: []Registration{{
+			ID:     "golangci-lint-langserver.watchedFiles",
+			Method: "workspace/didChangeWatchedFiles",
+			RegisterOptions: &DidChangeWatchedFilesRegistrationOptions{
+				Watchers: []FileSystemWatcher{
+					{GlobPattern: "**/*.go"},
+					{GlobPattern: "**/" + settingsFile},
+					{GlobPattern: "**/.golangci.{yml,yaml,toml,json}"},
+					{GlobPattern: "**/" + goModFile},
+					{GlobPattern: "**/" + goWorkFile},
+				},
+			},
+		}},
+	}
+	h.callAsync("client/registerCapability", params, nil)
+}
+
+func (h *langHandler) handleWorkspaceDidChangeWatchedFiles(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	var params DidChangeWatchedFilesParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	h.watchedChanges(params.Changes)
+
+	return nil, nil
+}
+
+// watchedChanges handles files changed on disk, as reported by the client or the internal watcher:
+// configuration files invalidate every result, other files get their packages linted again.
+func (h *langHandler) watchedChanges(changes []FileEvent) {
+	var configs []string
+	for _, change := range changes {
+		path := h.paths.uriToPath(string(change.URI))
+		if h.root() != "" && path == filepath.Join(h.root(), settingsFile) {
+			h.reloadSettingsFile()
+
+			continue
+		}
+		if isWatchedConfig(path) {
+			configs = append(configs, filepath.Base(path))
+
+			continue
+		}
+
+		if !h.included(filepath.Dir(path)) {
+			continue
+		}
+		if change.Type == FCTDeleted {
+			h.clearFile(change.URI)
+		}
+
+		h.watched.add(filepath.Dir(path))
+	}
+
+	if len(configs) > 0 {
+		h.invalidate(strings.Join(configs, ", ") + " changed on disk")
+	}
+}
+
+// clearFile drops the diagnostics of a file deleted from disk.
+func (h *langHandler) clearFile(uri DocumentURI) {
+	h.issues.replace(uri, nil, nil)
+
+	h.publishedMu.Lock()
+	defer h.publishedMu.Unlock()
+
+	dir := filepath.Dir(h.paths.uriToPath(string(uri)))
+	if _, ok := h.published[dir][uri]; !ok {
+		return
+	}
+	delete(h.published[dir], uri)
+
+	if !h.isPullMode() {
+		h.publishDiagnostics(uri, []Diagnostic{})
+	}
+}
+
+// lintChangedDirs lints again the packages of dirs that have open documents,
+// running once per package, or once per module like a burst of saves when the
+// throttle doesn't allow that many runs.
+func (h *langHandler) lintChangedDirs(dirs map[string]struct{}) {
+	var uris []DocumentURI
+	for _, uri := range h.documents.uris() {
+		dir := filepath.Dir(h.paths.uriToPath(string(uri)))
+		if _, ok := dirs[dir]; !ok {
+			continue
+		}
+		delete(dirs, dir)
+		uris = append(uris, uri)
+	}
+	if len(uris) == 0 {
+		return
+	}
+
+	if h.isPullMode() {
+		h.invalidate("files changed on disk")
+
+		return
+	}
+
+	if !h.throttle.take(len(uris), time.Now()) {
+		h.logger.Printf("golangci-lint-langserver: throttling: files changed on disk in %d packages, linting them with one run per module", len(uris))
+		h.lintBatch(uris, TriggerWatchedFiles)
+
+		return
+	}
+
+	h.logger.Printf("golangci-lint-langserver: files changed on disk, linting %d packages again", len(uris))
+	for _, uri := range uris {
+		h.enqueue(uri, TriggerWatchedFiles)
+	}
+}
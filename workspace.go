@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nametake/golangci-lint-langserver/messages"
+)
+
+const cmdRunWorkspace = "golangci-lint.runWorkspace"
+
+// workspaceUnit is one golangci-lint run of a workspace lint.
+type workspaceUnit struct {
+	root   string
+	target string
+}
+
+// dir returns the directory the target of u starts from.
+func (u workspaceUnit) dir() string {
+	return filepath.Join(u.root, strings.TrimSuffix(u.target, "..."))
+}
+
+// lintWorkspace lints every package under the root and publishes the diagnostics of all files.
+// Files edited while golangci-lint was running are not published but linted again on their own,
+// since the positions of the workspace run no longer match their content.
+//
+// When stream is set, the workspace is linted one top-level directory at a time and the
+// diagnostics of each are published as soon as it completes, reporting progress on token if any.
+func (h *langHandler) lintWorkspace(stream bool, token ProgressToken) {
+	defer h.recoverPanic("workspace lint")
+
+	if err := h.authorizeFor(h.root()); err != nil {
+		h.notifyError(err.Error())
+
+		return
+	}
+
+	start := time.Now()
+	revisions := h.documents.revisions()
+
+	// In a go.work workspace every module is linted from its own directory.
+	roots := h.workModules()
+	if len(roots) == 0 {
+		roots = []string{h.root()}
+	}
+
+	// Nested folder overrides are linted from their directory with their own command;
+	// the runs above them drop their issues. A relative key resolves under every workspace
+	// folder, so only the directories that exist are linted.
+	for _, root := range append([]string{}, roots...) {
+		for _, dir := range h.folderDirsUnder(root) {
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				roots = append(roots, dir)
+			}
+		}
+	}
+
+	var units []workspaceUnit
+	seen := make(map[string]bool)
+	add := func(unit workspaceUnit) {
+		if !seen[unit.dir()] {
+			seen[unit.dir()] = true
+			units = append(units, unit)
+		}
+	}
+	for _, root := range roots {
+		if targets, all := h.includedTargets(root); !all {
+			for _, target := range targets {
+				add(workspaceUnit{root: root, target: target})
+			}
+
+			continue
+		}
+		if !stream {
+			add(workspaceUnit{root: root, target: "./..."})
+
+			continue
+		}
+		for _, target := range workspaceTargets(root) {
+			add(workspaceUnit{root: root, target: target})
+		}
+	}
+
+	h.progress(token, &WorkDoneProgressBegin{Kind: "begin", Title: h.catalog().Sprintf(messages.LintingWorkspace)})
+
+	diagnostics := make(map[DocumentURI][]Diagnostic)
+	stale := make(map[DocumentURI]struct{})
+	for i, unit := range units {
+		h.progress(token, &WorkDoneProgressReport{
+			Kind:       "report",
+			Message:    h.catalog().Sprintf(messages.LintedPackages, unit.target, i+1, len(units)),
+			Percentage: i * 100 / len(units),
+		})
+
+		unitDiagnostics, err := h.lintWorkspaceUnit(unit, start, revisions, stale)
+		if err != nil {
+			h.notifyError(h.catalog().Sprintf(messages.WorkspaceRunFailed, h.errToDiagnostics(err, nil)[0].Message))
+
+			for _, packageDiagnostics := range h.isolateFailure(unit.dir(), nil) {
+				for uri, ds := range packageDiagnostics {
+					unitDiagnostics[uri] = ds
+				}
+			}
+		}
+		for uri, ds := range unitDiagnostics {
+			diagnostics[uri] = ds
+		}
+		if stream {
+			h.publishFiles(unitDiagnostics)
+		}
+	}
+
+	h.clearWorkspace(diagnostics, stale)
+	if !stream {
+		h.publishFiles(diagnostics)
+	}
+
+	h.progress(token, &WorkDoneProgressEnd{Kind: "end"})
+
+	for uri := range stale {
+		h.logger.Printf("golangci-lint-langserver: %s changed during the workspace run, linting it again", uri)
+		h.schedule(uri, TriggerStale)
+	}
+}
+
+// lintWorkspaceUnit runs unit and returns the diagnostics of every file with issues,
+// adding the files edited since start to stale instead.
+func (h *langHandler) lintWorkspaceUnit(unit workspaceUnit, start time.Time, revisions map[DocumentURI]int, stale map[DocumentURI]struct{}) (map[DocumentURI][]Diagnostic, error) {
+	diagnostics := make(map[DocumentURI][]Diagnostic)
+
+	folder := h.folderDir(unit.dir())
+	lc := h.lintCommandFor(unit.dir(), unit.root, unit.target)
+	lc.Background = true
+	result, run, err := h.runLint(lc)
+	for errors.Is(err, errPreempted) {
+		h.logger.Printf("golangci-lint-langserver: workspace run of %s cancelled for a lint request, running it again", unit.dir())
+		result, run, err = h.runLint(lc)
+	}
+	if err != nil {
+		return diagnostics, err
+	}
+	if result == nil {
+		return diagnostics, nil
+	}
+
+	issues := make(map[DocumentURI][]Issue)
+	casing := newCasingResolver()
+	for _, issue := range result.Issues {
+		issue := issue
+
+		path := issueFilePath(unit.root, &issue)
+		if h.folderDir(path) != folder {
+			// Linted by the run of a deeper folder override.
+			continue
+		}
+		uri := h.paths.pathToURI(casing.path(path))
+		diagnostics[uri] = append(diagnostics[uri], h.fileDiagnostic(uri, path, &issue))
+		issues[uri] = append(issues[uri], issue)
+	}
+	if len(result.Panics) > 0 {
+		for uri := range h.issues.counts() {
+			path := h.paths.uriToPath(string(uri))
+			if !isSubdir(unit.dir(), path) || h.folderDir(path) != folder {
+				continue
+			}
+			for _, issue := range h.keepPanicked(uri, result.Panics) {
+				issue := issue
+				diagnostics[uri] = append(diagnostics[uri], h.fileDiagnostic(uri, path, &issue))
+				issues[uri] = append(issues[uri], issue)
+			}
+		}
+	}
+
+	for uri := range diagnostics {
+		if h.changedSince(uri, start, revisions) {
+			stale[uri] = struct{}{}
+			delete(diagnostics, uri)
+
+			continue
+		}
+		h.issues.replace(uri, issues[uri], run)
+		h.highlightNew(uri, issues[uri], diagnostics[uri])
+		diagnostics[uri] = h.splitFormatting(uri, issues[uri], diagnostics[uri])
+		h.addRunFooter(diagnostics[uri], run)
+	}
+
+	return diagnostics, nil
+}
+
+// workspaceTargets splits `./...` under root into the package of root itself and one pattern per
+// top-level directory, skipping the directories `./...` skips and those without Go files.
+func workspaceTargets(root string) []string {
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		return []string{"./..."}
+	}
+
+	var targets []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+			targets = append(targets, ".")
+
+			break
+		}
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor" {
+			continue
+		}
+
+		dir := filepath.Join(root, name)
+		if isModuleRoot(dir) || !hasGoFiles(dir) {
+			continue
+		}
+		targets = append(targets, "./"+name+"/...")
+	}
+
+	return targets
+}
+
+// isModuleRoot reports whether dir is the root of a nested module.
+func isModuleRoot(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, goModFile))
+
+	return err == nil
+}
+
+// hasGoFiles reports whether dir or any directory below it contains a Go file.
+func hasGoFiles(dir string) bool {
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".go") {
+			return errFound
+		}
+
+		return nil
+	})
+
+	return err == errFound
+}
+
+var errFound = errors.New("found")
+
+// progress reports value on token, unless the client gave none.
+func (h *langHandler) progress(token ProgressToken, value interface{}) {
+	if len(token) == 0 {
+		return
+	}
+
+	if err := h.conn.Notify(context.Background(), "$/progress", &ProgressParams{Token: token, Value: value}); err != nil {
+		h.logger.Printf("%s", err)
+	}
+}
+
+// changedSince reports whether uri was edited after the workspace run captured revisions at start.
+func (h *langHandler) changedSince(uri DocumentURI, start time.Time, revisions map[DocumentURI]int) bool {
+	if revision, ok := revisions[uri]; ok {
+		doc, ok := h.documents.get(uri)
+
+		return !ok || doc.Revision != revision
+	}
+
+	if _, ok := h.documents.get(uri); ok {
+		// Opened during the run.
+		return true
+	}
+
+	info, err := os.Stat(h.paths.uriToPath(string(uri)))
+	if err != nil {
+		return true
+	}
+
+	return info.ModTime().After(start)
+}
+
+// clearWorkspace clears every previously published file under the root that has no
+// diagnostics after a workspace run, except the stale ones.
+func (h *langHandler) clearWorkspace(diagnostics map[DocumentURI][]Diagnostic, stale map[DocumentURI]struct{}) {
+	h.publishedMu.Lock()
+	defer h.publishedMu.Unlock()
+
+	for dir, uris := range h.published {
+		if !isSubdir(h.root(), dir) {
+			continue
+		}
+
+		for uri := range uris {
+			if _, ok := stale[uri]; ok {
+				continue
+			}
+			if _, ok := diagnostics[uri]; !ok {
+				h.publishDiagnostics(uri, []Diagnostic{})
+				h.issues.replace(uri, nil, nil)
+				delete(uris, uri)
+			}
+		}
+	}
+}
+
+// publishFiles publishes the diagnostics of a workspace run.
+func (h *langHandler) publishFiles(diagnostics map[DocumentURI][]Diagnostic) {
+	h.publishedMu.Lock()
+	defer h.publishedMu.Unlock()
+
+	for uri, ds := range diagnostics {
+		h.publishDiagnostics(uri, ds)
+
+		dir := filepath.Dir(h.paths.uriToPath(string(uri)))
+		if h.published[dir] == nil {
+			h.published[dir] = make(map[DocumentURI]struct{})
+		}
+		h.published[dir][uri] = struct{}{}
+	}
+}
+
+// isSubdir reports whether dir is root or inside it.
+func isSubdir(root, dir string) bool {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// workModules returns the modules of the go.work at the root, invalidating results when it changed.
+func (h *langHandler) workModules() []string {
+	if h.root() == "" {
+		return nil
+	}
+
+	modules, changed := h.goWork.load(h.root())
+	if changed {
+		h.invalidate("go.work changed")
+	}
+
+	return modules
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nametake/golangci-lint-langserver/lint"
+)
+
+// gatedWorkspaceRunner holds the workspace runs of next until release is closed.
+type gatedWorkspaceRunner struct {
+	next    lint.Runner
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *gatedWorkspaceRunner) Start(cmd *exec.Cmd) (io.Reader, func() error, error) {
+	stdout, wait, err := r.next.Start(cmd)
+	workspace := false
+	for _, arg := range cmd.Args {
+		workspace = workspace || arg == "./..."
+	}
+	if err != nil || !workspace {
+		return stdout, wait, err
+	}
+
+	r.started <- struct{}{}
+
+	return stdout, func() error {
+		<-r.release
+
+		return wait()
+	}, nil
+}
+
+// TestWorkspaceRunSkipsEditedFiles checks that the files saved with new content or changed on
+// disk during a workspace run don't get its stale diagnostics, but are linted again after it.
+func TestWorkspaceRunSkipsEditedFiles(t *testing.T) {
+	names := []string{"a.go", "b/b.go", "c/c.go", "d/d.go"}
+	var ts *testServer
+	runner := &fakeRunner{output: func(cmd *exec.Cmd) string {
+		text := "package issue"
+		for _, arg := range cmd.Args {
+			if arg == "./..." {
+				text = "workspace issue"
+			}
+		}
+
+		var issues []Issue
+		for _, name := range names {
+			var issue Issue
+			issue.FromLinter = "fake"
+			issue.Text = text
+			issue.Pos.Filename, _ = filepath.Rel(cmd.Dir, ts.path(name))
+			issue.Pos.Line = 1
+			issue.Pos.Column = 1
+			issues = append(issues, issue)
+		}
+
+		return issuesOutput(t, issues...)
+	}}
+	ts = newTestServer(t, testConfig{
+		files: map[string]string{
+			"b/b.go": "package b\n",
+			"c/c.go": "package c\n",
+			"d/d.go": "package d\n",
+		},
+		runner: runner,
+	})
+	gated := &gatedWorkspaceRunner{next: runner, started: make(chan struct{}, 1), release: make(chan struct{})}
+	ts.h.runner = gated
+
+	ts.open("a.go")
+	ts.open("b/b.go")
+	ts.waitPublished("a.go")
+	ts.waitPublished("b/b.go")
+	ts.mu.Lock()
+	ts.diagnostics = make(map[DocumentURI][]Diagnostic)
+	ts.history = nil
+	ts.mu.Unlock()
+
+	if err := ts.call("workspace/executeCommand", ExecuteCommandParams{Command: cmdRunWorkspace}, nil); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-gated.started:
+	case <-time.After(testTimeout):
+		t.Fatal("the workspace run didn't start")
+	}
+
+	// b.go is saved with new content and c.go, which isn't open, changes on disk.
+	text := "package b\n\nfunc B() {}\n"
+	params := DidSaveTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: ts.uri("b/b.go")}, Text: &text}
+	if err := ts.client.Notify(context.Background(), "textDocument/didSave", params); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(ts.path("c/c.go"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	// Let the server take the save in before the run completes.
+	time.Sleep(100 * time.Millisecond)
+	close(gated.release)
+
+	for _, name := range names {
+		ts.waitPublished(name)
+	}
+	// The stale files are linted again on their own once the workspace run published.
+	deadline := time.Now().Add(testTimeout)
+	for {
+		ts.mu.Lock()
+		b, c := ts.diagnostics[ts.uri("b/b.go")], ts.diagnostics[ts.uri("c/c.go")]
+		ts.mu.Unlock()
+		if len(b) > 0 && strings.Contains(b[0].Message, "package issue") && len(c) > 0 && strings.Contains(c[0].Message, "package issue") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the stale files weren't linted again: b.go %+v, c.go %+v", b, c)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for _, params := range ts.history {
+		stale := params.URI == ts.uri("b/b.go") || params.URI == ts.uri("c/c.go")
+		for _, d := range params.Diagnostics {
+			if workspace := strings.Contains(d.Message, "workspace issue"); workspace == stale {
+				t.Errorf("%s: published %q", params.URI, d.Message)
+			}
+		}
+	}
+}